@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/gostream-official/artists/impl/inject"
+	"github.com/gostream-official/artists/impl/routes"
+	"github.com/gostream-official/artists/pkg/apigateway"
+	"github.com/gostream-official/artists/pkg/router"
+
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	The package initializer function.
+//	Initializes the log level to info.
+func init() {
+	log.Level = log.LevelInfo
+}
+
+// Description:
+//
+//	The main function.
+//	Represents the entry point of the application for local testing of the serverless deployment.
+//
+//	Reads API Gateway proxy request events (payload format version 1.0 or 2.0) from stdin,
+//	one JSON value after another, and writes one JSON encoded proxy response per line to stdout.
+func main() {
+	log.Infof("booting serverless service instance ...")
+
+	injector, mongoInstance := inject.NewFromEnvironment()
+
+	engine := router.NewLambdaRouter()
+	engine.Use(router.AccessLog())
+
+	routes.Register(engine, injector)
+
+	err := engine.Run(0)
+	if err != nil {
+		log.Fatalf("failed to launch router engine: %s", err)
+	}
+
+	ctx := context.Background()
+
+	decoder := json.NewDecoder(bufio.NewReader(os.Stdin))
+	writer := bufio.NewWriter(os.Stdout)
+
+	for {
+		event := json.RawMessage{}
+
+		err := decoder.Decode(&event)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			log.Fatalf("failed to read event: %s", err)
+		}
+
+		response, err := apigateway.Handle(ctx, engine, event)
+		if err != nil {
+			log.Errorf("failed to handle event: %s", err)
+			continue
+		}
+
+		writer.Write(response)
+		writer.WriteString("\n")
+		writer.Flush()
+	}
+
+	if mongoInstance != nil {
+		err = mongoInstance.Disconnect(ctx)
+		if err != nil {
+			log.Errorf("failed to disconnect from mongo instance: %s", err)
+		}
+	}
+}
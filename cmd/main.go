@@ -1,22 +1,27 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
-	"github.com/gostream-official/artists/impl/funcs/createartist"
-	"github.com/gostream-official/artists/impl/funcs/deleteartist"
-	"github.com/gostream-official/artists/impl/funcs/getartist"
-	"github.com/gostream-official/artists/impl/funcs/getartists"
-	"github.com/gostream-official/artists/impl/funcs/updateartist"
 	"github.com/gostream-official/artists/impl/inject"
+	"github.com/gostream-official/artists/impl/purge"
+	"github.com/gostream-official/artists/impl/routes"
 	"github.com/gostream-official/artists/pkg/env"
 	"github.com/gostream-official/artists/pkg/router"
-	"github.com/gostream-official/artists/pkg/store"
 
 	"github.com/revx-official/output/log"
 )
 
+// Description:
+//
+//	The time allowed to close the database connection on shutdown,
+//	independent of the time spent draining in-flight requests.
+const disconnectTimeout = 10 * time.Second
+
 // Description:
 //
 //	The package initializer function.
@@ -43,43 +48,130 @@ func main() {
 		log.Fatalf("Received invalid execution port")
 	}
 
-	mongoUsername, err := env.GetEnvironmentVariable("MONGO_USERNAME")
-	if err != nil {
-		log.Fatalf("Cannot retrieve mongo username via environment variable")
+	injector, mongoInstance := inject.NewFromEnvironment()
+
+	log.Infof("launching router engine ...")
+	engine := router.Default()
+	engine.Use(router.AccessLog())
+
+	routes.Register(engine, injector)
+
+	gracePeriod := shutdownGracePeriod()
+
+	purger := purge.Purger{
+		Store:     injector.ArtistStore,
+		Auditor:   injector.Auditor,
+		Retention: deletedArtistRetention(),
+		Interval:  purgeInterval(),
 	}
 
-	mongoPassword, err := env.GetEnvironmentVariable("MONGO_PASSWORD")
-	if err != nil {
-		log.Fatalf("Cannot retrieve mongo password via environment variable")
+	signals, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	purged := make(chan struct{})
+
+	go func() {
+		defer close(purged)
+		purger.Run(signals)
+	}()
+
+	engineErrors := make(chan error, 1)
+
+	go func() {
+		engineErrors <- engine.Run(uint16(executionPort))
+	}()
+
+	select {
+	case err := <-engineErrors:
+		if err != nil {
+			log.Fatalf("failed to launch router engine: %s", err)
+		}
+	case <-signals.Done():
+		log.Infof("received termination signal, shutting down ...")
 	}
 
-	mongoHost := env.GetEnvironmentVariableWithFallback("MONGO_HOST", "127.0.0.1:27017")
+	stop()
 
-	connectionURI := fmt.Sprintf("mongodb://%s:%s@%s", mongoUsername, mongoPassword, mongoHost)
-	instance, err := store.NewMongoInstance(connectionURI)
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
 
-	log.Infof("establishing database connection ...")
+	err = engine.Shutdown(ctx)
 	if err != nil {
-		log.Fatalf("failed to connect to mongo instance: %s", err)
+		log.Errorf("failed to drain in-flight requests: %s", err)
 	}
 
-	log.Infof("successfully established database connection")
+	// The purger stops at the termination signal, but may still be finishing a purge.
+	<-purged
+
+	if mongoInstance != nil {
+		log.Infof("closing database connection ...")
+
+		disconnectCtx, cancelDisconnect := context.WithTimeout(context.Background(), disconnectTimeout)
+		defer cancelDisconnect()
 
-	injector := inject.Injector{
-		MongoInstance: instance,
+		err = mongoInstance.Disconnect(disconnectCtx)
+		if err != nil {
+			log.Errorf("failed to disconnect from mongo instance: %s", err)
+		}
 	}
 
-	log.Infof("launching router engine ...")
-	engine := router.Default()
+	log.Infof("service instance shut down")
+}
 
-	engine.HandleWith("GET", "/artists", getartists.Handler).Inject(injector)
-	engine.HandleWith("GET", "/artists/:id", getartist.Handler).Inject(injector)
-	engine.HandleWith("POST", "/artists", createartist.Handler).Inject(injector)
-	engine.HandleWith("PUT", "/artists/:id", updateartist.Handler).Inject(injector)
-	engine.HandleWith("DELETE", "/artists/:id", deleteartist.Handler).Inject(injector)
+// Description:
+//
+//	Reads the shutdown grace period from the environment.
+//	In-flight requests are given this much time to complete on shutdown.
+//	Terminates the application if the configured duration is invalid.
+//
+// Returns:
+//
+//	The shutdown grace period.
+func shutdownGracePeriod() time.Duration {
+	gracePeriodEnvVar := env.GetEnvironmentVariableWithFallback("SHUTDOWN_GRACE_PERIOD", "30s")
 
-	err = engine.Run(uint16(executionPort))
-	if err != nil {
-		log.Fatalf("failed to launch router engine: %s", err)
+	gracePeriod, err := time.ParseDuration(gracePeriodEnvVar)
+	if err != nil || gracePeriod < 0 {
+		log.Fatalf("Received invalid shutdown grace period: %s", gracePeriodEnvVar)
+	}
+
+	return gracePeriod
+}
+
+// Description:
+//
+//	Reads the retention of soft-deleted artists from the environment.
+//	Terminates the application if the configured duration is invalid.
+//
+// Returns:
+//
+//	The time soft-deleted artists are kept before they are purged.
+func deletedArtistRetention() time.Duration {
+	retentionEnvVar := env.GetEnvironmentVariableWithFallback("DELETED_ARTIST_RETENTION", "720h")
+
+	retention, err := time.ParseDuration(retentionEnvVar)
+	if err != nil || retention < 0 {
+		log.Fatalf("Received invalid deleted artist retention: %s", retentionEnvVar)
 	}
+
+	return retention
+}
+
+// Description:
+//
+//	Reads the interval between purges of soft-deleted artists from the environment.
+//	Terminates the application if the configured duration is invalid.
+//
+// Returns:
+//
+//	The purge interval.
+func purgeInterval() time.Duration {
+	intervalEnvVar := env.GetEnvironmentVariableWithFallback("PURGE_INTERVAL", "1h")
+
+	interval, err := time.ParseDuration(intervalEnvVar)
+	if err != nil || interval < time.Second {
+		log.Fatalf("Received invalid purge interval: %s", intervalEnvVar)
+	}
+
+	return interval
 }
@@ -0,0 +1,358 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gostream-official/artists/impl/models"
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/store"
+
+	"github.com/google/uuid"
+)
+
+// Description:
+//
+//	The request header carrying the identity of the actor.
+//	The header is supplied by the client and not verified, so any client can claim any identity.
+const ActorHeader = "X-Actor-ID"
+
+// Description:
+//
+//	The actor recorded for requests without actor identity.
+const AnonymousActor = "anonymous"
+
+// Description:
+//
+//	The actor recorded for changes made by the service itself, e.g. purges.
+const SystemActor = "system"
+
+// Description:
+//
+//	The kind of an artist change.
+type Action string
+
+const (
+
+	// The artist was created.
+	ActionCreate Action = "create"
+
+	// The artist was replaced or patched.
+	ActionUpdate Action = "update"
+
+	// The artist was soft-deleted.
+	ActionDelete Action = "delete"
+
+	// The soft-deleted artist was restored.
+	ActionRestore Action = "restore"
+
+	// Other artists were merged into the artist, or the artist was merged into another artist.
+	ActionMerge Action = "merge"
+
+	// The soft-deleted artist was deleted permanently.
+	ActionPurge Action = "purge"
+)
+
+// Description:
+//
+//	The artist fields which are not compared, because they are recorded separately.
+var ignoredFields = map[string]bool{
+	"id":      true,
+	"version": true,
+}
+
+// Description:
+//
+//	The origin of a change.
+type Origin struct {
+
+	// The identity of the actor who made the change, as claimed by the client.
+	ClaimedActor string
+
+	// The correlation id of the request which made the change.
+	CorrelationID string
+}
+
+// Description:
+//
+//	Determines the origin of the changes made by a request.
+//	The actor is taken from the 'X-Actor-ID' header, the correlation id is the id reported in problems.
+//	The service does not authenticate requests, so the actor is untrusted and only recorded as claimed.
+//
+// Parameters:
+//
+//	request The request.
+//
+// Returns:
+//
+//	The origin of the request.
+func OriginOf(request *api.APIRequest) Origin {
+	actor := strings.TrimSpace(request.Header(ActorHeader))
+	if actor == "" {
+		actor = AnonymousActor
+	}
+
+	return Origin{
+		ClaimedActor:  actor,
+		CorrelationID: request.Parallel.ID,
+	}
+}
+
+// Description:
+//
+//	A change of a single artist.
+type Change struct {
+
+	// The kind of change.
+	Action Action
+
+	// The artist before the change. Nil, if the artist was created.
+	Before *models.ArtistInfo
+
+	// The artist after the change. Nil, if the artist was deleted permanently.
+	After *models.ArtistInfo
+}
+
+// Description:
+//
+//	Records artist changes in the audit collection.
+type Auditor struct {
+
+	// The audit entry store.
+	Store store.Store[models.ArtistAuditEntry]
+
+	// The transactor of the artist and audit entry stores.
+	Transactor store.Transactor
+}
+
+// Description:
+//
+//	Creates the indexes of the audit collection.
+//
+// Parameters:
+//
+//	ctx 	The operation context.
+//	entries The audit entry store.
+//
+// Returns:
+//
+//	An error if an index cannot be created.
+func CreateIndexes(ctx context.Context, entries store.Store[models.ArtistAuditEntry]) error {
+	return entries.CreateIndex(ctx, store.Index{
+		Name: "artistId_1_version_1",
+		Keys: []string{"artistId", "version"},
+	})
+}
+
+// Description:
+//
+//	Runs a function within a transaction and records the changes it returns within the same transaction.
+//	Nothing is recorded, if the function fails.
+//
+// Parameters:
+//
+//	ctx 	The operation context.
+//	origin 	The origin of the changes.
+//	fn 		The function to run. Must use the context passed to it for all store operations.
+//
+// Returns:
+//
+//	The error returned by the function, or an error if the transaction fails.
+func (auditor *Auditor) WithTransaction(ctx context.Context, origin Origin, fn func(ctx context.Context) ([]Change, error)) error {
+	return auditor.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		changes, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+
+		return auditor.Record(ctx, origin, changes...)
+	})
+}
+
+// Description:
+//
+//	Records artist changes. Must be called within the transaction making the changes.
+//
+// Parameters:
+//
+//	ctx 	The transaction context.
+//	origin 	The origin of the changes.
+//	changes The changes to record.
+//
+// Returns:
+//
+//	An error if an audit entry cannot be written.
+func (auditor *Auditor) Record(ctx context.Context, origin Origin, changes ...Change) error {
+	now := time.Now().UTC()
+
+	for _, change := range changes {
+		entry, err := NewEntry(origin, change, now)
+		if err != nil {
+			return err
+		}
+
+		err = auditor.Store.CreateItem(ctx, *entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Creates the audit entry of an artist change.
+//
+// Parameters:
+//
+//	origin 		The origin of the change.
+//	change 		The change.
+//	timestamp 	The time of the change.
+//
+// Returns:
+//
+//	The audit entry, or an error if the artists cannot be compared.
+func NewEntry(origin Origin, change Change, timestamp time.Time) (*models.ArtistAuditEntry, error) {
+	changes, err := Diff(change.Before, change.After)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.ArtistAuditEntry{
+		ID:            uuid.New().String(),
+		Action:        string(change.Action),
+		ClaimedActor:  origin.ClaimedActor,
+		CorrelationID: origin.CorrelationID,
+		Timestamp:     timestamp,
+		Changes:       changes,
+	}
+
+	if change.After != nil {
+		entry.ArtistID = change.After.ID
+		entry.Version = change.After.Version
+	} else if change.Before != nil {
+		entry.ArtistID = change.Before.ID
+		entry.Version = change.Before.Version + 1
+	}
+
+	return entry, nil
+}
+
+// Description:
+//
+//	Compares two states of an artist field by field.
+//	Nested fields are compared individually, e.g. 'stats.popularity', lists as a whole.
+//
+// Parameters:
+//
+//	before 	The artist before the change, or nil.
+//	after 	The artist after the change, or nil.
+//
+// Returns:
+//
+//	The changed fields, ordered by field name.
+//	An error if an artist cannot be encoded.
+func Diff(before *models.ArtistInfo, after *models.ArtistInfo) ([]models.ArtistFieldChange, error) {
+	beforeFields, err := flatten(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := flatten(after)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(beforeFields)+len(afterFields))
+
+	for name := range beforeFields {
+		names = append(names, name)
+	}
+
+	for name := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	changes := make([]models.ArtistFieldChange, 0)
+
+	for _, name := range names {
+		if ignoredFields[name] || reflect.DeepEqual(beforeFields[name], afterFields[name]) {
+			continue
+		}
+
+		changes = append(changes, models.ArtistFieldChange{
+			Field:  name,
+			Before: beforeFields[name],
+			After:  afterFields[name],
+		})
+	}
+
+	return changes, nil
+}
+
+// Description:
+//
+//	Encodes an artist into its fields, as exposed by the API.
+//
+// Parameters:
+//
+//	artist The artist, or nil.
+//
+// Returns:
+//
+//	The field values, keyed by dot-separated field name. Empty, if the artist is nil.
+//	An error if the artist cannot be encoded.
+func flatten(artist *models.ArtistInfo) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+
+	if artist == nil {
+		return fields, nil
+	}
+
+	bytes, err := json.Marshal(artist)
+	if err != nil {
+		return nil, err
+	}
+
+	document := make(map[string]interface{})
+
+	err = json.Unmarshal(bytes, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	flattenInto(fields, "", document)
+	return fields, nil
+}
+
+// Description:
+//
+//	Adds the fields of a decoded JSON object, recursing into nested objects.
+//
+// Parameters:
+//
+//	fields 		The fields to add to.
+//	prefix 		The field name prefix of the object.
+//	document 	The decoded JSON object.
+func flattenInto(fields map[string]interface{}, prefix string, document map[string]interface{}) {
+	for key, value := range document {
+		name := prefix + key
+
+		nested, ok := value.(map[string]interface{})
+		if ok {
+			flattenInto(fields, name+".", nested)
+			continue
+		}
+
+		fields[name] = value
+	}
+}
@@ -0,0 +1,396 @@
+package duplicates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gostream-official/artists/impl/models"
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/store"
+	"github.com/gostream-official/artists/pkg/store/query"
+)
+
+// Description:
+//
+//	The policy for artists whose name key equals the name key of another artist.
+type Policy string
+
+const (
+
+	// Rejects duplicate artist names with a conflict.
+	PolicyReject Policy = "reject"
+
+	// Allows duplicate artist names, but logs and reports a warning.
+	PolicyWarn Policy = "warn"
+)
+
+// Description:
+//
+//	Parses a duplicate name policy.
+//
+// Parameters:
+//
+//	value The policy name, either 'reject' or 'warn'.
+//
+// Returns:
+//
+//	The policy, or an error if the policy is unknown.
+func ParsePolicy(value string) (Policy, error) {
+	switch policy := Policy(value); policy {
+	case PolicyReject, PolicyWarn:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("duplicates: unknown policy '%s'", value)
+}
+
+// Description:
+//
+//	The name of the name key index, if duplicates are allowed.
+const nameKeyIndexName = "nameKey_1"
+
+// Description:
+//
+//	The name of the unique name key index, if duplicates are rejected.
+const uniqueNameKeyIndexName = "nameKey_unique"
+
+// Description:
+//
+//	The number of artists whose name keys are filled in per query.
+const BackfillBatchSize = 100
+
+// Description:
+//
+//	Creates the name key index of the artist collection, replacing the index of the other policy.
+//	Rejecting duplicates makes the index unique. Name keys of artists stored before name keys were
+//	introduced are filled in first, so that the index covers the whole catalog. Soft-deleted artists
+//	have no name key and are not indexed.
+//
+//	If stored name keys are not unique, duplicates cannot be rejected reliably and the warn policy
+//	is used instead, until the duplicates are merged or renamed.
+//
+// Parameters:
+//
+//	ctx 		The operation context.
+//	artists 	The artist store.
+//	policy 		The duplicate name policy.
+//
+// Returns:
+//
+//	The effective duplicate name policy, i.e. warn if the stored name keys are not unique.
+//	An error if name keys cannot be filled in, or an index cannot be created or dropped.
+func CreateIndexes(ctx context.Context, artists store.Store[models.ArtistInfo], policy Policy) (Policy, error) {
+	if policy == PolicyReject {
+		// Fails on a duplicate, if the unique index of a previous start exists.
+		err := BackfillNameKeys(ctx, artists)
+
+		if err == nil {
+			err = artists.DropIndex(ctx, nameKeyIndexName)
+			if err != nil {
+				return "", err
+			}
+
+			index := nameKeyIndex(uniqueNameKeyIndexName)
+			index.Unique = true
+
+			err = artists.CreateIndex(ctx, index)
+			if err == nil {
+				return PolicyReject, nil
+			}
+		}
+
+		if !errors.Is(err, store.ErrDuplicateKey) {
+			return "", err
+		}
+	}
+
+	err := artists.DropIndex(ctx, uniqueNameKeyIndexName)
+	if err != nil {
+		return "", err
+	}
+
+	err = artists.CreateIndex(ctx, nameKeyIndex(nameKeyIndexName))
+	if err != nil {
+		return "", err
+	}
+
+	err = BackfillNameKeys(ctx, artists)
+	if err != nil {
+		return "", err
+	}
+
+	return PolicyWarn, nil
+}
+
+// Description:
+//
+//	Fills in the name keys of all artists which do not have one, i.e. artists stored before
+//	name keys were introduced. Soft-deleted artists are skipped, as their name keys are removed
+//	on deletion and set again on restoration. The version of the artists is not changed.
+//
+// Parameters:
+//
+//	ctx 	The operation context.
+//	artists The artist store.
+//
+// Returns:
+//
+//	ErrDuplicateKey if a name key violates a unique index, another error if a query fails.
+func BackfillNameKeys(ctx context.Context, artists store.Store[models.ArtistInfo]) error {
+	missing := query.FilterOperatorExists{
+		Key:    "nameKey",
+		Exists: false,
+	}
+
+	filter := query.Filter{
+		Root:       models.NotDeleted(missing),
+		Projection: []string{"_id", "name"},
+		Limit:      BackfillBatchSize,
+	}
+
+	for {
+		items, err := artists.FindItems(ctx, &filter)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			updateFilter := query.Filter{
+				Root: query.FilterOperatorAnd{
+					And: []query.IQuery{
+						query.FilterOperatorEq{
+							Key:   "_id",
+							Value: item.ID,
+						},
+						missing,
+					},
+				},
+			}
+
+			update := query.Update{
+				Root: query.UpdateOperatorSet{
+					Set: map[string]interface{}{
+						"nameKey": models.NameKey(item.Name),
+					},
+				},
+			}
+
+			_, err := artists.UpdateItem(ctx, &updateFilter, &update)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(items) < BackfillBatchSize {
+			return nil
+		}
+	}
+}
+
+// Description:
+//
+//	Creates the sparse name key index definition.
+//
+// Parameters:
+//
+//	name The index name.
+//
+// Returns:
+//
+//	The index definition.
+func nameKeyIndex(name string) store.Index {
+	return store.Index{
+		Name:   name,
+		Keys:   []string{"nameKey"},
+		Sparse: true,
+	}
+}
+
+// Description:
+//
+//	Searches another artist with the given name key.
+//
+// Parameters:
+//
+//	ctx 	The operation context.
+//	store 	The artist store.
+//	id 		The id of the artist the name key belongs to, which is excluded.
+//	nameKey The name key to search for.
+//
+// Returns:
+//
+//	The conflicting artist, or nil if the name key is not taken.
+//	An error if the query fails.
+func FindConflict(ctx context.Context, store store.Store[models.ArtistInfo], id string, nameKey string) (*models.ArtistInfo, error) {
+	filter := query.Filter{
+		Root: query.FilterOperatorAnd{
+			And: []query.IQuery{
+				query.FilterOperatorEq{
+					Key:   "nameKey",
+					Value: nameKey,
+				},
+				query.FilterOperatorNeq{
+					Key:   "_id",
+					Value: id,
+				},
+			},
+		},
+		Limit: 1,
+	}
+
+	items, err := store.FindItems(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	return &items[0], nil
+}
+
+// Description:
+//
+//	Creates the problem rejecting a duplicate artist name.
+//
+// Parameters:
+//
+//	request 	The rejected request.
+//	conflict 	The conflicting artist, or nil if it is unknown.
+//
+// Returns:
+//
+//	The conflict problem, naming the conflicting artist.
+func ConflictProblem(request *api.APIRequest, conflict *models.ArtistInfo) *api.Problem {
+	problem := api.NewProblem(http.StatusConflict, "an artist with this name already exists").WithRequest(request)
+
+	if conflict != nil {
+		problem.With("conflictingId", conflict.ID)
+	}
+
+	return problem
+}
+
+// Description:
+//
+//	Creates the warning header value reporting an accepted duplicate artist name.
+//
+// Parameters:
+//
+//	conflict The conflicting artist.
+//
+// Returns:
+//
+//	The 'Warning' header value.
+func Warning(conflict *models.ArtistInfo) string {
+	return fmt.Sprintf("199 - \"artist name duplicates artist %s\"", conflict.ID)
+}
+
+// Description:
+//
+//	A group of artists with equal name keys.
+type Group struct {
+
+	// The name key shared by the artists.
+	NameKey string `json:"nameKey"`
+
+	// The artists of this group, at least two.
+	Artists []GroupArtist `json:"artists"`
+}
+
+// Description:
+//
+//	An artist within a group of duplicates.
+type GroupArtist struct {
+
+	// The id of the artist.
+	ID string `json:"id"`
+
+	// The name of the artist.
+	Name string `json:"name"`
+}
+
+// Description:
+//
+//	Finds all groups of artists with equal name keys. Soft-deleted artists are excluded.
+//
+// Parameters:
+//
+//	ctx 	The operation context.
+//	artists The artist store.
+//
+// Returns:
+//
+//	The groups of at least two artists, ordered by name key, see GroupArtists.
+//	An error if the query fails.
+func FindGroups(ctx context.Context, artists store.Store[models.ArtistInfo]) ([]Group, error) {
+	filter := query.Filter{
+		Root: models.NotDeleted(nil),
+		Sort: []query.SortKey{
+			{Key: "_id", Order: query.SortAscending},
+		},
+		Projection: []string{"_id", "name"},
+	}
+
+	items, err := artists.FindItems(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return GroupArtists(items), nil
+}
+
+// Description:
+//
+//	Groups artists with equal name keys.
+//	Name keys are derived from the names, so that artists without stored name key are included.
+//
+// Parameters:
+//
+//	artists The artists to group.
+//
+// Returns:
+//
+//	The groups of at least two artists, ordered by name key. Artists keep their order within a group.
+func GroupArtists(artists []models.ArtistInfo) []Group {
+	groups := make(map[string]*Group)
+	nameKeys := make([]string, 0)
+
+	for _, artist := range artists {
+		nameKey := models.NameKey(artist.Name)
+
+		group, ok := groups[nameKey]
+		if !ok {
+			group = &Group{
+				NameKey: nameKey,
+				Artists: make([]GroupArtist, 0, 2),
+			}
+
+			groups[nameKey] = group
+			nameKeys = append(nameKeys, nameKey)
+		}
+
+		group.Artists = append(group.Artists, GroupArtist{
+			ID:   artist.ID,
+			Name: artist.Name,
+		})
+	}
+
+	sort.Strings(nameKeys)
+
+	result := make([]Group, 0)
+
+	for _, nameKey := range nameKeys {
+		group := groups[nameKey]
+
+		if len(group.Artists) > 1 {
+			result = append(result, *group)
+		}
+	}
+
+	return result
+}
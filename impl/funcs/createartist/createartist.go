@@ -1,19 +1,17 @@
 package createartist
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
+	"errors"
 	"net/http"
-	"strings"
 
+	"github.com/gostream-official/artists/impl/audit"
+	"github.com/gostream-official/artists/impl/duplicates"
 	"github.com/gostream-official/artists/impl/inject"
 	"github.com/gostream-official/artists/impl/models"
 	"github.com/gostream-official/artists/pkg/api"
-	"github.com/gostream-official/artists/pkg/arrays"
 	"github.com/gostream-official/artists/pkg/marshal"
-	"github.com/gostream-official/artists/pkg/parallel"
 	"github.com/gostream-official/artists/pkg/store"
-	"github.com/gostream-official/artists/pkg/store/query"
 	"github.com/revx-official/output/log"
 
 	"github.com/google/uuid"
@@ -25,10 +23,10 @@ import (
 type CreateArtistRequestBody struct {
 
 	// The name of the artist.
-	Name string `json:"name" bson:"name"`
+	Name string `json:"name" bson:"name" validate:"required,notblank,maxlen=256"`
 
 	// The genres an artist is active in.
-	Genres []string `json:"genres" bson:"genres"`
+	Genres []string `json:"genres" bson:"genres" validate:"maxlen=32,unique,dive,notblank,maxlen=64"`
 
 	// The amount of followers the artist has.
 	Followers uint32 `json:"followers" bson:"followers"`
@@ -43,55 +41,12 @@ type CreateArtistRequestBody struct {
 type CreateArtistStatsRequestBody struct {
 
 	// The popularity factor of the artist.
-	Popularity float32 `json:"popularity" bson:"popularity"`
+	Popularity float32 `json:"popularity" bson:"popularity" validate:"min=0,max=1"`
 }
 
 // Description:
 //
-//	The error response body for the create artist endpoint.
-type CreateArtistErrorResponseBody struct {
-
-	// The error message.
-	Message string `json:"message"`
-}
-
-// Description:
-//
-//	Describes a validation error.
-type CreateArtistValidationError struct {
-
-	// The JSON field which is referenced by the error message.
-	FieldRef string `json:"ref"`
-
-	// The error message.
-	ErrorMessage string `json:"error"`
-}
-
-// Description:
-//
-//	Attempts to cast the input object to the endpoint injector.
-//	If this cast fails, we cannot proceed to process this request.
-//
-// Parameters:
-//
-//	object 	The injector object.
-//
-// Returns:
-//
-//	The injector if the cast is successful, an error otherwise.
-func GetSafeInjector(object interface{}) (*inject.Injector, error) {
-	injector, ok := object.(inject.Injector)
-
-	if !ok {
-		return nil, fmt.Errorf("createartist: failed to deduce injector")
-	}
-
-	return &injector, nil
-}
-
-// Description:
-//
-//	Unmarshals the request body for this endpoint.
+//	Unmarshals and validates the request body for this endpoint.
 //
 // Parameters:
 //
@@ -99,13 +54,12 @@ func GetSafeInjector(object interface{}) (*inject.Injector, error) {
 //
 // Returns:
 //
-//	The unmarshalled request body, or an error when unmarshalling fails.
+//	The unmarshalled request body, or an error when unmarshalling or validation fails.
+//	Validation failures are reported as *api.ValidationError.
 func ExtractRequestBody(request *api.APIRequest) (*CreateArtistRequestBody, error) {
 	body := &CreateArtistRequestBody{}
 
-	bytes := []byte(request.Body)
-	err := json.Unmarshal(bytes, body)
-
+	err := api.Bind(request, body)
 	if err != nil {
 		return nil, err
 	}
@@ -115,87 +69,26 @@ func ExtractRequestBody(request *api.APIRequest) (*CreateArtistRequestBody, erro
 
 // Description:
 //
-//	Validates the request body for this endpoint.
+//	Creates the artist and records its creation in the audit trail atomically.
 //
 // Parameters:
 //
-//	request The request body.
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//	artist 		The artist to create.
 //
 // Returns:
 //
-//	An error if the validation fails.
-func ValidateRequestBody(request *CreateArtistRequestBody) *CreateArtistValidationError {
-	artistName := strings.TrimSpace(request.Name)
-	artistGenres := arrays.Map[string](request.Genres, func(genre string) string {
-		return strings.TrimSpace(genre)
-	})
-
-	if len(artistName) == 0 {
-		return &CreateArtistValidationError{
-			FieldRef:     "name",
-			ErrorMessage: "value must not be empty",
-		}
-	}
-
-	for _, genre := range artistGenres {
-		if len(genre) == 0 {
-			return &CreateArtistValidationError{
-				FieldRef:     "genres",
-				ErrorMessage: "array value must not be empty",
-			}
+//	An error if the artist cannot be created. Nothing is recorded.
+func createInTransaction(request *api.APIRequest, injector *inject.Injector, artist models.ArtistInfo) error {
+	return injector.Auditor.WithTransaction(request.Context, audit.OriginOf(request), func(ctx context.Context) ([]audit.Change, error) {
+		err := injector.ArtistStore.CreateItem(ctx, artist)
+		if err != nil {
+			return nil, err
 		}
-	}
-
-	return ValidateArtistStatsRequestBody(&request.Stats)
-}
 
-// Description:
-//
-//	Validates the statistics request body for this endpoint.
-//
-// Parameters:
-//
-//	request The statistics request body.
-//
-// Returns:
-//
-//	An error if the validation fails.
-func ValidateArtistStatsRequestBody(request *CreateArtistStatsRequestBody) *CreateArtistValidationError {
-	return nil
-}
-
-// Description:
-//
-//	Checks whether the given artist id exists in the mongo store.
-//
-// Parameters:
-//
-//	store 		The mongo store to search.
-//	artistID 	The artist id to search.
-//
-// Returns:
-//
-//	An error, if the artist does exist already,
-//	if the database request failed, nothing if successful.
-func EnsureArtistDoesNotExist(store *store.MongoStore[models.ArtistInfo], artistID string) error {
-	filter := query.Filter{
-		Root: query.FilterOperatorEq{
-			Key:   "_id",
-			Value: artistID,
-		},
-		Limit: 1,
-	}
-
-	items, err := store.FindItems(&filter)
-	if err != nil {
-		return err
-	}
-
-	if len(items) == 0 {
-		return nil
-	}
-
-	return fmt.Errorf("artist already exists")
+		return []audit.Change{{Action: audit.ActionCreate, After: &artist}}, nil
+	})
 }
 
 // Description:
@@ -210,76 +103,79 @@ func EnsureArtistDoesNotExist(store *store.MongoStore[models.ArtistInfo], artist
 // Returns:
 //
 //	An API response object.
-func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
-	context := parallel.NewContext()
+func Handler(request *api.APIRequest, injector *inject.Injector) *api.APIResponse {
+	context := request.Parallel
 
 	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
 	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
 
-	injector, err := GetSafeInjector(object)
-	if err != nil {
-		log.Warnf("[%s] failed to get endpoint injector: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
-	}
-
 	requestBody, err := ExtractRequestBody(request)
 	if err != nil {
 		log.Warnf("[%s] failed to extract request body: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusBadRequest,
-			Body: CreateArtistErrorResponseBody{
-				Message: "invalid request body",
-			},
-		}
-	}
 
-	validationError := ValidateRequestBody(requestBody)
-	if validationError != nil {
-		log.Warnf("[%s] failed request body validation: %s", context.ID, validationError.ErrorMessage)
-		return &api.APIResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       validationError,
+		problem := api.NewProblem(http.StatusBadRequest, "invalid request body").WithRequest(request)
+
+		var bindErr *api.ValidationError
+		if errors.As(err, &bindErr) {
+			problem.WithErrors(bindErr.Violations...)
 		}
+
+		return problem.Response()
 	}
 
-	artistStore := store.NewMongoStore[models.ArtistInfo](injector.MongoInstance, "gostream", "artists")
+	artistStore := injector.ArtistStore
 
 	artist := models.ArtistInfo{
 		ID:        uuid.New().String(),
 		Name:      requestBody.Name,
+		NameKey:   models.NameKey(requestBody.Name),
 		Genres:    requestBody.Genres,
 		Followers: requestBody.Followers,
 		Stats: models.ArtistStats{
 			Popularity: requestBody.Stats.Popularity,
 		},
+		Version: 1,
 	}
 
-	err = EnsureArtistDoesNotExist(artistStore, artist.ID)
+	headers := map[string]string{}
+
+	conflict, err := duplicates.FindConflict(request.Context, artistStore, artist.ID, artist.NameKey)
 	if err != nil {
-		log.Warnf("[%s] artist already exists: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusConflict,
-			Body: CreateArtistErrorResponseBody{
-				Message: "artist already exists",
-			},
+		log.Errorf("[%s] failed to search duplicate artists: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, "")
+	}
+
+	if conflict != nil {
+		if injector.DuplicateNamePolicy == duplicates.PolicyReject {
+			log.Warnf("[%s] artist name duplicates artist %s", context.ID, conflict.ID)
+			return duplicates.ConflictProblem(request, conflict).Response()
 		}
+
+		log.Warnf("[%s] accepting artist name duplicating artist %s", context.ID, conflict.ID)
+		headers["Warning"] = duplicates.Warning(conflict)
 	}
 
 	log.Tracef("[%s] attempting to create database item ...", context.ID)
-	err = artistStore.CreateItem(artist)
+	err = createInTransaction(request, injector, artist)
+
+	if errors.Is(err, store.ErrDuplicateKey) {
+		log.Warnf("[%s] artist name was taken concurrently", context.ID)
+
+		conflict, _ = duplicates.FindConflict(request.Context, artistStore, artist.ID, artist.NameKey)
+		return duplicates.ConflictProblem(request, conflict).Response()
+	}
 
 	if err != nil {
 		log.Errorf("[%s] failed to create database item: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
+		return api.ProblemResponse(request, http.StatusInternalServerError, "")
 	}
 
+	headers["ETag"] = api.FormatETag(artist.Version)
+
 	log.Tracef("[%s] successfully completed request", context.ID)
 	return &api.APIResponse{
 		StatusCode: http.StatusOK,
+		Headers:    headers,
 		Body:       artist,
 	}
 }
@@ -1,43 +1,135 @@
 package deleteartist
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/gostream-official/artists/impl/audit"
 	"github.com/gostream-official/artists/impl/inject"
 	"github.com/gostream-official/artists/impl/models"
 	"github.com/gostream-official/artists/pkg/api"
 	"github.com/gostream-official/artists/pkg/marshal"
-	"github.com/gostream-official/artists/pkg/parallel"
 	"github.com/gostream-official/artists/pkg/store"
+	"github.com/gostream-official/artists/pkg/store/query"
 	"github.com/revx-official/output/log"
 )
 
 // Description:
 //
-//	Attempts to cast the input object to the endpoint injector.
-//	If this cast fails, we cannot proceed to process this request.
+//	The maximum number of attempts to delete an artist without precondition,
+//	while it is modified concurrently.
+const MaxAttempts = 5
+
+// Description:
+//
+//	Searches an artist with the given id in the database.
+//
+// Parameters:
+//
+//	ctx 	The operation context.
+//	store 	The store to search through.
+//	id 		The id to search for.
+//
+// Returns:
+//
+//	The artist, or nil if the artist does not exist.
+//	An error if the query fails.
+func FindArtistByID(ctx context.Context, store store.Store[models.ArtistInfo], id string) (*models.ArtistInfo, error) {
+	filter := query.Filter{
+		Root: models.NotDeleted(query.FilterOperatorEq{
+			Key:   "_id",
+			Value: id,
+		}),
+		Limit: 1,
+	}
+
+	items, err := store.FindItems(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	return &items[0], nil
+}
+
+// Description:
+//
+//	Creates the update soft-deleting an artist.
+//	Removes the name key, so that the name can be reused while the artist is deleted.
+//
+// Parameters:
+//
+//	now The deletion time.
+//
+// Returns:
+//
+//	The update operators.
+func SoftDeleteOperators(now time.Time) []query.IQuery {
+	return []query.IQuery{
+		query.UpdateOperatorSet{
+			Set: map[string]interface{}{
+				"deletedAt": now,
+			},
+		},
+		query.UpdateOperatorUnset{
+			Unset: []string{"nameKey"},
+		},
+	}
+}
+
+// Description:
+//
+//	Soft-deletes the artist in its current version and records the deletion in the audit trail atomically.
 //
 // Parameters:
 //
-//	object 	The injector object.
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//	artist 		The artist to delete.
 //
 // Returns:
 //
-//	The injector if the cast is successful, an error otherwise.
-func GetSafeInjector(object interface{}) (*inject.Injector, error) {
-	injector, ok := object.(inject.Injector)
+//	An error if the artist cannot be deleted, see UpdateItemVersion. Nothing is recorded.
+func deleteInTransaction(request *api.APIRequest, injector *inject.Injector, artist *models.ArtistInfo) error {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	deleteFilter := query.Filter{
+		Root: query.FilterOperatorEq{
+			Key:   "_id",
+			Value: artist.ID,
+		},
+	}
 
-	if !ok {
-		return nil, fmt.Errorf("deleteartist: failed to deduce injector")
+	deleteOperator := query.Update{
+		Root: query.UpdateOperatorCombine{
+			Combine: SoftDeleteOperators(now),
+		},
 	}
 
-	return &injector, nil
+	deleted := *artist
+	deleted.NameKey = ""
+	deleted.DeletedAt = &now
+	deleted.Version++
+
+	return injector.Auditor.WithTransaction(request.Context, audit.OriginOf(request), func(ctx context.Context) ([]audit.Change, error) {
+		err := injector.ArtistStore.UpdateItemVersion(ctx, &deleteFilter, artist.Version, &deleteOperator)
+		if err != nil {
+			return nil, err
+		}
+
+		return []audit.Change{{Action: audit.ActionDelete, Before: artist, After: &deleted}}, nil
+	})
 }
 
 // Description:
 //
-//	The router handler for: Get Track By ID
+//	The router handler for: Delete Artist By ID
+//	Artists are soft-deleted. They can be restored until they are purged.
 //
 // Parameters:
 //
@@ -47,38 +139,127 @@ func GetSafeInjector(object interface{}) (*inject.Injector, error) {
 // Returns:
 //
 //	An API response object.
-func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
-	context := parallel.NewContext()
+func Handler(request *api.APIRequest, injector *inject.Injector) *api.APIResponse {
+	context := request.Parallel
 
 	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
 	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
 
-	injector, err := GetSafeInjector(object)
-	if err != nil {
-		log.Errorf("[%s] failed to get endpoint injector: %s", context.ID, err)
+	idToDelete := request.PathParameters["id"]
+
+	if request.Header("If-Match") != "" || injector.RequireIfMatch {
+		return handleConditional(request, injector, idToDelete)
+	}
+
+	return handleUnconditional(request, injector, idToDelete)
+}
+
+// Description:
+//
+//	Soft-deletes an artist without precondition.
+//	If the artist is modified concurrently, it is read again and the deletion is retried.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//	id 			The id of the artist to delete.
+//
+// Returns:
+//
+//	An API response object.
+func handleUnconditional(request *api.APIRequest, injector *inject.Injector, id string) *api.APIResponse {
+	context := request.Parallel
+	artistStore := injector.ArtistStore
+
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		artist, err := FindArtistByID(request.Context, artistStore, id)
+		if err != nil {
+			log.Errorf("[%s] failed to retrieve database item: %s", context.ID, err)
+			return api.ProblemResponse(request, http.StatusInternalServerError, "")
+		}
+
+		if artist == nil {
+			return &api.APIResponse{
+				StatusCode: http.StatusNoContent,
+			}
+		}
+
+		log.Tracef("[%s] attempting to delete database item ...", context.ID)
+		err = deleteInTransaction(request, injector, artist)
+
+		if errors.Is(err, store.ErrVersionMismatch) || errors.Is(err, store.ErrNotFound) {
+			log.Warnf("[%s] artist was modified concurrently, retrying", context.ID)
+			continue
+		}
+
+		if err != nil {
+			log.Errorf("[%s] failed to delete database item: %s", context.ID, err)
+			return api.ProblemResponse(request, http.StatusInternalServerError, "")
+		}
+
 		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
+			StatusCode: http.StatusAccepted,
 		}
 	}
 
-	idToDelete := request.PathParameters["id"]
+	log.Errorf("[%s] artist was modified concurrently %d times, giving up", context.ID, MaxAttempts)
+	return api.ProblemResponse(request, http.StatusServiceUnavailable, "the artist is modified too frequently, try again later")
+}
 
-	store := store.NewMongoStore[models.ArtistInfo](injector.MongoInstance, "gostream", "artists")
-	count, err := store.DeleteItem(idToDelete)
+// Description:
+//
+//	Soft-deletes an artist under the 'If-Match' precondition.
+//	The artist is only deleted if it is still in the version the precondition was evaluated against.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//	id 			The id of the artist to delete.
+//
+// Returns:
+//
+//	An API response object.
+func handleConditional(request *api.APIRequest, injector *inject.Injector, id string) *api.APIResponse {
+	context := request.Parallel
+	artistStore := injector.ArtistStore
 
+	artist, err := FindArtistByID(request.Context, artistStore, id)
 	if err != nil {
-		log.Errorf("[%s] failed to delete database items: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
+		log.Errorf("[%s] failed to retrieve database item: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, "")
+	}
+
+	etag := ""
+	if artist != nil {
+		etag = api.FormatETag(artist.Version)
 	}
 
-	if count == 0 {
+	problem := api.CheckIfMatch(request, etag, injector.RequireIfMatch)
+	if problem != nil {
+		log.Warnf("[%s] failed precondition: %s", context.ID, problem.Detail)
+		return problem.Response()
+	}
+
+	if artist == nil {
 		return &api.APIResponse{
 			StatusCode: http.StatusNoContent,
 		}
 	}
 
+	err = deleteInTransaction(request, injector, artist)
+
+	if errors.Is(err, store.ErrVersionMismatch) || errors.Is(err, store.ErrNotFound) {
+		log.Warnf("[%s] artist was modified concurrently", context.ID)
+		return api.ProblemResponse(request, http.StatusPreconditionFailed, "the resource has been modified")
+	}
+
+	if err != nil {
+		log.Errorf("[%s] failed to delete database item: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, "")
+	}
+
 	return &api.APIResponse{
 		StatusCode: http.StatusAccepted,
 	}
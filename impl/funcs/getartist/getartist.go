@@ -1,14 +1,14 @@
 package getartist
 
 import (
-	"fmt"
+	"context"
 	"net/http"
+	"strings"
 
 	"github.com/gostream-official/artists/impl/inject"
 	"github.com/gostream-official/artists/impl/models"
 	"github.com/gostream-official/artists/pkg/api"
 	"github.com/gostream-official/artists/pkg/marshal"
-	"github.com/gostream-official/artists/pkg/parallel"
 	"github.com/gostream-official/artists/pkg/store"
 	"github.com/gostream-official/artists/pkg/store/query"
 	"github.com/revx-official/output/log"
@@ -16,24 +16,50 @@ import (
 
 // Description:
 //
-//	Attempts to cast the input object to the endpoint injector.
-//	If this cast fails, we cannot proceed to process this request.
+//	The maximum number of redirects followed, if merged artists were merged again.
+const MaxRedirects = 16
+
+// Description:
+//
+//	Resolves the artist a merged artist was merged into.
+//	Follows redirects of artists which were merged again, up to MaxRedirects.
 //
 // Parameters:
 //
-//	object 	The injector object.
+//	ctx 	The operation context.
+//	store 	The redirect store.
+//	id 		The id of the merged artist.
 //
 // Returns:
 //
-//	The injector if the cast is successful, an error otherwise.
-func GetSafeInjector(object interface{}) (*inject.Injector, error) {
-	injector, ok := object.(inject.Injector)
+//	The id of the artist the merged artist was finally merged into, or an empty string if the artist was not merged.
+//	An error if the query fails.
+func ResolveRedirect(ctx context.Context, store store.Store[models.ArtistRedirect], id string) (string, error) {
+	target := ""
 
-	if !ok {
-		return nil, fmt.Errorf("getartist: failed to deduce injector")
+	for hop := 0; hop < MaxRedirects; hop++ {
+		filter := query.Filter{
+			Root: query.FilterOperatorEq{
+				Key:   "_id",
+				Value: id,
+			},
+			Limit: 1,
+		}
+
+		items, err := store.FindItems(ctx, &filter)
+		if err != nil {
+			return "", err
+		}
+
+		if len(items) == 0 {
+			break
+		}
+
+		id = items[0].TargetID
+		target = id
 	}
 
-	return &injector, nil
+	return target, nil
 }
 
 // Description:
@@ -48,48 +74,55 @@ func GetSafeInjector(object interface{}) (*inject.Injector, error) {
 // Returns:
 //
 //	An API response object.
-func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
-	context := parallel.NewContext()
+func Handler(request *api.APIRequest, injector *inject.Injector) *api.APIResponse {
+	context := request.Parallel
 
 	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
 	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
 
-	injector, err := GetSafeInjector(object)
-	if err != nil {
-		log.Errorf("[%s] failed to get endpoint injector: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
-	}
-
-	store := store.NewMongoStore[models.ArtistInfo](injector.MongoInstance, "gostream", "artists")
+	artistStore := injector.ArtistStore
 
 	filter := query.Filter{
-		Root: query.FilterOperatorEq{
+		Root: models.NotDeleted(query.FilterOperatorEq{
 			Key:   "_id",
 			Value: request.PathParameters["id"],
-		},
+		}),
 		Limit: 10,
 	}
 
-	items, err := store.FindItems(&filter)
+	items, err := artistStore.FindItems(request.Context, &filter)
 
 	if err != nil {
 		log.Errorf("[%s] failed to retrieve database items: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
+		return api.ProblemResponse(request, http.StatusInternalServerError, "")
 	}
 
 	if len(items) == 0 {
+		target, err := ResolveRedirect(request.Context, injector.RedirectStore, request.PathParameters["id"])
+		if err != nil {
+			log.Errorf("[%s] failed to retrieve redirect: %s", context.ID, err)
+			return api.ProblemResponse(request, http.StatusInternalServerError, "")
+		}
+
+		if target == "" {
+			return api.ProblemResponse(request, http.StatusNotFound, "artist not found")
+		}
+
+		log.Infof("[%s] artist was merged into %s", context.ID, target)
 		return &api.APIResponse{
-			StatusCode: http.StatusNotFound,
+			StatusCode: http.StatusPermanentRedirect,
+			Headers: map[string]string{
+				"Location": strings.TrimSuffix(request.Path, request.PathParameters["id"]) + target,
+			},
 		}
 	}
 
 	resultItem := items[0]
 	return &api.APIResponse{
 		StatusCode: http.StatusOK,
-		Body:       resultItem,
+		Headers: map[string]string{
+			"ETag": api.FormatETag(resultItem.Version),
+		},
+		Body: resultItem,
 	}
 }
@@ -0,0 +1,198 @@
+package getartisthistory
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gostream-official/artists/impl/inject"
+	"github.com/gostream-official/artists/impl/models"
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/marshal"
+	"github.com/gostream-official/artists/pkg/paging"
+	"github.com/gostream-official/artists/pkg/store"
+	"github.com/gostream-official/artists/pkg/store/query"
+	"github.com/revx-official/output/log"
+
+	"github.com/google/uuid"
+)
+
+// Description:
+//
+//	Gets and validates the id path parameter.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The id path parameter.
+//	A violation if the id is not a valid uuid.
+func GetAndValidateID(request *api.APIRequest) (string, *api.Violation) {
+	id := request.PathParameters["id"]
+
+	_, err := uuid.Parse(id)
+	if err != nil {
+		return "", &api.Violation{
+			Parameter: "id",
+			Message:   "must be a valid uuid",
+		}
+	}
+
+	return id, nil
+}
+
+// Description:
+//
+//	Gets and validates the limit query parameter.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The requested page size. Zero, if no page size was requested.
+//	A violation if the limit is not a positive integer.
+func GetAndValidateLimit(request *api.APIRequest) (uint32, *api.Violation) {
+	limit, ok := request.QueryParameter("limit")
+	if !ok {
+		return 0, nil
+	}
+
+	value, err := strconv.ParseUint(limit, 10, 32)
+	if err != nil || value == 0 {
+		return 0, &api.Violation{
+			Parameter: "limit",
+			Message:   "must be a positive integer",
+		}
+	}
+
+	return uint32(value), nil
+}
+
+// Description:
+//
+//	Checks whether an artist with the given id exists, including soft-deleted artists.
+//
+// Parameters:
+//
+//	ctx 	The operation context.
+//	store 	The store to search through.
+//	id 		The id to search for.
+//
+// Returns:
+//
+//	Whether the artist exists.
+//	An error if the query fails.
+func ArtistExists(ctx context.Context, store store.Store[models.ArtistInfo], id string) (bool, error) {
+	filter := query.Filter{
+		Root: query.FilterOperatorEq{
+			Key:   "_id",
+			Value: id,
+		},
+		Projection: []string{"_id"},
+		Limit:      1,
+	}
+
+	items, err := store.FindItems(ctx, &filter)
+	if err != nil {
+		return false, err
+	}
+
+	return len(items) > 0, nil
+}
+
+// Description:
+//
+//	The router handler for retrieving the audit trail of an artist.
+//	Entries are ordered from the latest to the earliest change.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object.
+func Handler(request *api.APIRequest, injector *inject.Injector) *api.APIResponse {
+	context := request.Parallel
+
+	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
+	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
+
+	id, validationErr := GetAndValidateID(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed path parameter validation: %s", context.ID, validationErr.Message)
+		return api.NewProblem(http.StatusBadRequest, "invalid artist id").
+			WithRequest(request).
+			WithErrors(*validationErr).
+			Response()
+	}
+
+	limit, validationErr := GetAndValidateLimit(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed query parameter validation: %s", context.ID, validationErr.Message)
+		return api.NewProblem(http.StatusBadRequest, "invalid query parameters").
+			WithRequest(request).
+			WithErrors(*validationErr).
+			Response()
+	}
+
+	filter := query.Filter{
+		Root: query.FilterOperatorEq{
+			Key:   "artistId",
+			Value: id,
+		},
+		Sort: []query.SortKey{
+			{
+				Key:   "version",
+				Order: query.SortDescending,
+			},
+		},
+		Limit: limit,
+	}
+
+	cursor, _ := request.QueryParameter("cursor")
+
+	page, err := paging.FindPage(request.Context, injector.Auditor.Store, filter, cursor, &injector.Paginator)
+
+	if errors.Is(err, paging.ErrInvalidCursor) {
+		log.Warnf("[%s] received invalid cursor: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusBadRequest, "invalid cursor")
+	}
+
+	if err != nil {
+		log.Errorf("[%s] failed to retrieve database items: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, "")
+	}
+
+	if len(page.Items) == 0 && cursor == "" {
+		exists, err := ArtistExists(request.Context, injector.ArtistStore, id)
+		if err != nil {
+			log.Errorf("[%s] failed to retrieve database item: %s", context.ID, err)
+			return api.ProblemResponse(request, http.StatusInternalServerError, "")
+		}
+
+		if !exists {
+			log.Warnf("[%s] could not find artist: %s", context.ID, id)
+			return api.ProblemResponse(request, http.StatusNotFound, "artist not found")
+		}
+	}
+
+	headers := make(map[string]string)
+
+	if page.NextCursor != "" {
+		headers["Link"] = paging.NextLink(request, page.NextCursor)
+	}
+
+	log.Tracef("[%s] successfully completed request", context.ID)
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers:    headers,
+		Body:       page,
+	}
+}
@@ -1,40 +1,41 @@
 package getartists
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/gostream-official/artists/impl/inject"
 	"github.com/gostream-official/artists/impl/models"
 	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/arrays"
 	"github.com/gostream-official/artists/pkg/marshal"
-	"github.com/gostream-official/artists/pkg/parallel"
-	"github.com/gostream-official/artists/pkg/store"
+	"github.com/gostream-official/artists/pkg/paging"
 	"github.com/gostream-official/artists/pkg/store/query"
 	"github.com/revx-official/output/log"
 )
 
 // Description:
 //
-//	Attempts to cast the input object to the endpoint injector.
-//	If this cast fails, we cannot proceed to process this request.
-//
-// Parameters:
-//
-//	object 	The injector object.
-//
-// Returns:
-//
-//	The injector if the cast is successful, an error otherwise.
-func GetSafeInjector(object interface{}) (*inject.Injector, error) {
-	injector, ok := object.(inject.Injector)
+//	The response body for the get artists endpoint.
+type GetArtistsResponseBody struct {
 
-	if !ok {
-		return nil, fmt.Errorf("getartists: failed to deduce injector")
-	}
+	// The artists of the requested page.
+	Items interface{} `json:"items"`
+
+	// The continuation token for the next page. Omitted, if this is the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
 
-	return &injector, nil
+// Description:
+//
+//	The parser for filter expressions, restricted to the artist data model fields.
+var FilterParser = &query.RSQLParser{
+	Schema:   models.ArtistSchema,
+	MaxDepth: query.DefaultRSQLMaxDepth,
 }
 
 // Description:
@@ -47,8 +48,8 @@ func GetSafeInjector(object interface{}) (*inject.Injector, error) {
 //
 // Returns:
 //
-//	The created filter.
-func CreateFilterFromQueryParameters(request *api.APIRequest) query.Filter {
+//	The created filter, or an error if a query parameter is invalid.
+func CreateFilterFromQueryParameters(request *api.APIRequest) (query.Filter, error) {
 	andFilter := query.FilterOperatorAnd{
 		And: make([]query.IQuery, 0),
 	}
@@ -56,30 +57,233 @@ func CreateFilterFromQueryParameters(request *api.APIRequest) query.Filter {
 	var realLimit int
 	var realLimitErr error
 
-	limit, limitOk := request.QueryParameters["limit"]
+	limit, limitOk := request.QueryParameter("limit")
 	if limitOk {
 		realLimit, realLimitErr = strconv.Atoi(limit)
 	}
 
-	artistName, artistsName := request.QueryParameters["name"]
-	if artistsName {
+	artistNames := request.QueryParameterValues("name")
+	if len(artistNames) == 1 {
 		andFilter.And = append(andFilter.And, query.FilterOperatorEq{
 			Key:   "name",
-			Value: artistName,
+			Value: artistNames[0],
+		})
+	}
+
+	if len(artistNames) > 1 {
+		andFilter.And = append(andFilter.And, query.FilterOperatorIn{
+			Key: "name",
+			Values: arrays.Map[string](artistNames, func(name string) interface{} {
+				return name
+			}),
 		})
 	}
 
+	genres := ParseGenres(request.QueryParameterValues("genre"))
+	if len(genres) > 0 {
+		andFilter.And = append(andFilter.And, query.FilterOperatorIn{
+			Key: "genres",
+			Values: arrays.Map[string](genres, func(genre string) interface{} {
+				return genre
+			}),
+		})
+	}
+
+	namePrefix, namePrefixOk := request.QueryParameter("namePrefix")
+	if namePrefixOk {
+		nameFilter := query.FilterOperatorRegex{
+			Key:     "name",
+			Pattern: "^" + regexp.QuoteMeta(namePrefix),
+			Options: "i",
+		}
+
+		err := query.ValidateRegex(nameFilter.Pattern, nameFilter.Options)
+		if err != nil {
+			return query.Filter{}, fmt.Errorf("namePrefix is too long")
+		}
+
+		andFilter.And = append(andFilter.And, nameFilter)
+	}
+
+	for _, expression := range request.QueryParameterValues("filter") {
+		expressionFilter, err := FilterParser.Parse(expression)
+		if err != nil {
+			return query.Filter{}, err
+		}
+
+		andFilter.And = append(andFilter.And, expressionFilter)
+	}
+
 	resultFilter := query.Filter{}
 
 	if limitOk && realLimitErr == nil {
 		resultFilter.Limit = uint32(realLimit)
 	}
 
+	offset, offsetOk := request.QueryParameter("offset")
+	if offsetOk {
+		realOffset, err := strconv.ParseUint(offset, 10, 32)
+		if err != nil {
+			return query.Filter{}, fmt.Errorf("invalid offset: %s", offset)
+		}
+
+		resultFilter.Skip = uint32(realOffset)
+	}
+
+	sort, sortOk := request.QueryParameter("sort")
+	if sortOk {
+		sortKeys, err := ParseSort(sort)
+		if err != nil {
+			return query.Filter{}, err
+		}
+
+		resultFilter.Sort = sortKeys
+	}
+
+	fields, fieldsOk := request.QueryParameter("fields")
+	if fieldsOk {
+		projection, err := ParseFields(fields)
+		if err != nil {
+			return query.Filter{}, err
+		}
+
+		resultFilter.Projection = arrays.Map[string](projection, func(field string) string {
+			return models.ArtistProjectableFields[field]
+		})
+	}
+
 	if len(andFilter.And) > 0 {
 		resultFilter.Root = andFilter
 	}
 
-	return resultFilter
+	deletedMode := models.DeletedExclude
+
+	deleted, deletedOk := request.QueryParameter("deleted")
+	if deletedOk {
+		mode, err := models.ParseDeletedMode(deleted)
+		if err != nil {
+			return query.Filter{}, err
+		}
+
+		deletedMode = mode
+	}
+
+	resultFilter.Root = models.RestrictDeleted(resultFilter.Root, deletedMode)
+	return resultFilter, nil
+}
+
+// Description:
+//
+//	Parses the values of the genre query parameter.
+//	Every value is a single genre, e.g. 'genre=rock&genre=pop'. Commas are part of the genre,
+//	so that genres such as 'rhythm, blues' can be expressed.
+//
+// Parameters:
+//
+//	values The genre query parameter values.
+//
+// Returns:
+//
+//	The requested genres.
+func ParseGenres(values []string) []string {
+	return arrays.Map[string](values, func(genre string) string {
+		return strings.TrimSpace(genre)
+	})
+}
+
+// Description:
+//
+//	Parses a sort specification, e.g. '-stats.popularity,name'.
+//	A leading '-' sorts in descending order, a leading '+' or no prefix in ascending order.
+//
+// Parameters:
+//
+//	value The sort specification.
+//
+// Returns:
+//
+//	The parsed sort keys, or an error if a field is not sortable.
+func ParseSort(value string) ([]query.SortKey, error) {
+	sortKeys := make([]query.SortKey, 0)
+
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		order := query.SortAscending
+
+		if strings.HasPrefix(field, "-") {
+			order = query.SortDescending
+		}
+
+		field = strings.TrimLeft(field, "+-")
+
+		key, ok := models.ArtistSortableFields[field]
+		if !ok {
+			return nil, fmt.Errorf("field is not sortable: %s", field)
+		}
+
+		sortKeys = append(sortKeys, query.SortKey{
+			Key:   key,
+			Order: order,
+		})
+	}
+
+	return sortKeys, nil
+}
+
+// Description:
+//
+//	Parses a field selection, e.g. 'name,genres'.
+//
+// Parameters:
+//
+//	value The field selection.
+//
+// Returns:
+//
+//	The selected fields, or an error if a field cannot be selected.
+func ParseFields(value string) ([]string, error) {
+	fields := make([]string, 0)
+
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+
+		_, ok := models.ArtistProjectableFields[field]
+		if !ok {
+			return nil, fmt.Errorf("field cannot be selected: %s", field)
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// Description:
+//
+//	Reduces the given artists to the selected fields.
+//	The artist id is always included.
+//
+// Parameters:
+//
+//	items 	The artists to reduce.
+//	fields 	The selected fields.
+//
+// Returns:
+//
+//	The reduced artists, or an error if an artist cannot be converted.
+func ProjectItems(items []models.ArtistInfo, fields []string) ([]map[string]interface{}, error) {
+	result := make([]map[string]interface{}, 0, len(items))
+
+	for _, item := range items {
+		projected, err := marshal.Project(item, append([]string{"id"}, fields...))
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, projected)
+	}
+
+	return result, nil
 }
 
 // Description:
@@ -89,39 +293,82 @@ func CreateFilterFromQueryParameters(request *api.APIRequest) query.Filter {
 // Parameters:
 //
 //	request The incoming request.
-//	object 	The injector. Contains injected dependencies.
+//	injector 	The injector. Contains injected dependencies.
 //
 // Returns:
 //
 //	An API response object.
-func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
-	context := parallel.NewContext()
+func Handler(request *api.APIRequest, injector *inject.Injector) *api.APIResponse {
+	context := request.Parallel
 
 	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
 	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
 
-	injector, err := GetSafeInjector(object)
+	artistStore := injector.ArtistStore
+
+	filter, err := CreateFilterFromQueryParameters(request)
 	if err != nil {
-		log.Errorf("[%s] failed to get endpoint injector: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
+		log.Warnf("[%s] failed to create filter: %s", context.ID, err)
+
+		problem := api.NewProblem(http.StatusBadRequest, err.Error()).WithRequest(request)
+
+		var expressionErr *query.RSQLError
+		if errors.As(err, &expressionErr) {
+			problem.Detail = fmt.Sprintf("invalid filter: %s", expressionErr.Message)
+			problem.WithErrors(api.Violation{
+				Parameter: "filter",
+				Message:   expressionErr.Message,
+			})
+			problem.With("position", expressionErr.Position)
 		}
+
+		return problem.Response()
+	}
+
+	cursor, _ := request.QueryParameter("cursor")
+
+	if cursor != "" && filter.Skip > 0 {
+		log.Warnf("[%s] received both cursor and offset", context.ID)
+		return api.ProblemResponse(request, http.StatusBadRequest, "cursor and offset cannot be combined")
 	}
 
-	store := store.NewMongoStore[models.ArtistInfo](injector.MongoInstance, "gostream", "artists")
-	filter := CreateFilterFromQueryParameters(request)
+	page, err := paging.FindPage(request.Context, artistStore, filter, cursor, &injector.Paginator)
 
-	items, err := store.FindItems(&filter)
+	if errors.Is(err, paging.ErrInvalidCursor) {
+		log.Warnf("[%s] received invalid cursor: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusBadRequest, "invalid cursor")
+	}
 
 	if err != nil {
 		log.Errorf("[%s] failed to retrieve database items: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
+		return api.ProblemResponse(request, http.StatusInternalServerError, "")
+	}
+
+	headers := make(map[string]string)
+
+	if page.NextCursor != "" {
+		headers["Link"] = paging.NextLink(request, page.NextCursor)
+	}
+
+	responseBody := GetArtistsResponseBody{
+		Items:      page.Items,
+		NextCursor: page.NextCursor,
+	}
+
+	fields, fieldsOk := request.QueryParameter("fields")
+	if fieldsOk {
+		selectedFields, _ := ParseFields(fields)
+		responseBody.Items, err = ProjectItems(page.Items, selectedFields)
+
+		if err != nil {
+			log.Errorf("[%s] failed to project items: %s", context.ID, err)
+			return api.ProblemResponse(request, http.StatusInternalServerError, "")
 		}
 	}
 
 	return &api.APIResponse{
 		StatusCode: http.StatusOK,
-		Body:       items,
+		Headers:    headers,
+		Body:       responseBody,
 	}
 }
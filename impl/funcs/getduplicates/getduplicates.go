@@ -0,0 +1,63 @@
+package getduplicates
+
+import (
+	"net/http"
+
+	"github.com/gostream-official/artists/impl/duplicates"
+	"github.com/gostream-official/artists/impl/inject"
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/marshal"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	The response body for the duplicate artists report.
+type GetDuplicatesResponseBody struct {
+
+	// The groups of artists with equal name keys, ordered by name key.
+	Groups []duplicates.Group `json:"groups"`
+
+	// The total number of artists which duplicate another artist, i.e. all but one per group.
+	Duplicates int `json:"duplicates"`
+}
+
+// Description:
+//
+//	The router handler for: Get Duplicate Artists
+//	Reports all artists whose names are equal after normalization. Soft-deleted artists are excluded.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object.
+func Handler(request *api.APIRequest, injector *inject.Injector) *api.APIResponse {
+	context := request.Parallel
+
+	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
+	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
+
+	groups, err := duplicates.FindGroups(request.Context, injector.ArtistStore)
+	if err != nil {
+		log.Errorf("[%s] failed to retrieve database items: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, "")
+	}
+
+	responseBody := GetDuplicatesResponseBody{
+		Groups: groups,
+	}
+
+	for _, group := range groups {
+		responseBody.Duplicates += len(group.Artists) - 1
+	}
+
+	log.Tracef("[%s] successfully completed request", context.ID)
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Body:       responseBody,
+	}
+}
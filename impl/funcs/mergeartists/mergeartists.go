@@ -0,0 +1,540 @@
+package mergeartists
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gostream-official/artists/impl/audit"
+	"github.com/gostream-official/artists/impl/inject"
+	"github.com/gostream-official/artists/impl/models"
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/arrays"
+	"github.com/gostream-official/artists/pkg/marshal"
+	"github.com/gostream-official/artists/pkg/store"
+	"github.com/gostream-official/artists/pkg/store/query"
+	"github.com/revx-official/output/log"
+
+	"github.com/google/uuid"
+)
+
+// Description:
+//
+//	Combines the followers of merged artists by summing them up.
+const FollowersSum = "sum"
+
+// Description:
+//
+//	Combines the followers of merged artists by taking the maximum.
+const FollowersMax = "max"
+
+// Description:
+//
+//	The maximum number of genres of an artist.
+const MaxGenres = 32
+
+// Description:
+//
+//	The error aborting the merge transaction, if the merge is rejected.
+var errRejected = errors.New("merge: rejected")
+
+// Description:
+//
+//	The request body for the merge artists endpoint.
+type MergeArtistsRequestBody struct {
+
+	// The ids of the artists to merge into the target artist.
+	SourceIDs []string `json:"sourceIds" validate:"required,minlen=1,maxlen=100,unique"`
+
+	// How followers are combined, either 'sum' or 'max'. Defaults to 'sum'.
+	FollowersStrategy string `json:"followersStrategy"`
+}
+
+// Description:
+//
+//	The response body for the merge artists endpoint.
+type MergeArtistsResponseBody struct {
+
+	// The target artist, as it is after the merge.
+	Artist models.ArtistInfo `json:"artist"`
+
+	// The ids of the artists merged into the target artist.
+	MergedIDs []string `json:"mergedIds"`
+
+	// Whether the merge was only previewed, without changing any artist.
+	DryRun bool `json:"dryRun"`
+}
+
+// Description:
+//
+//	Gets and validates the id path parameter.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The id path parameter.
+//	A violation if the id is not a valid uuid.
+func GetAndValidateID(request *api.APIRequest) (string, *api.Violation) {
+	id := request.PathParameters["id"]
+
+	_, err := uuid.Parse(id)
+	if err != nil {
+		return "", &api.Violation{
+			Parameter: "id",
+			Message:   "must be a valid uuid",
+		}
+	}
+
+	return id, nil
+}
+
+// Description:
+//
+//	Gets and validates the dry run query parameter.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	Whether the merge is only previewed.
+//	A violation if the parameter is not a boolean.
+func GetAndValidateDryRun(request *api.APIRequest) (bool, *api.Violation) {
+	value, ok := request.QueryParameter("dryRun")
+	if !ok {
+		return false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &api.Violation{
+			Parameter: "dryRun",
+			Message:   "must be a boolean",
+		}
+	}
+
+	return dryRun, nil
+}
+
+// Description:
+//
+//	Unmarshals and validates the request body for this endpoint.
+//
+// Parameters:
+//
+//	request The original request.
+//	id 		The id of the target artist.
+//
+// Returns:
+//
+//	The unmarshalled request body, or an error when unmarshalling or validation fails.
+//	Validation failures are reported as *api.ValidationError.
+func ExtractRequestBody(request *api.APIRequest, id string) (*MergeArtistsRequestBody, error) {
+	body := &MergeArtistsRequestBody{}
+
+	err := api.Bind(request, body)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]api.Violation, 0)
+
+	for index, sourceID := range body.SourceIDs {
+		pointer := fmt.Sprintf("/sourceIds/%d", index)
+
+		_, err := uuid.Parse(sourceID)
+		if err != nil {
+			violations = append(violations, api.Violation{
+				Pointer: pointer,
+				Message: "must be a valid uuid",
+			})
+
+			continue
+		}
+
+		if sourceID == id {
+			violations = append(violations, api.Violation{
+				Pointer: pointer,
+				Message: "must not be the target artist",
+			})
+		}
+	}
+
+	if body.FollowersStrategy == "" {
+		body.FollowersStrategy = FollowersSum
+	}
+
+	if body.FollowersStrategy != FollowersSum && body.FollowersStrategy != FollowersMax {
+		violations = append(violations, api.Violation{
+			Pointer: "/followersStrategy",
+			Message: fmt.Sprintf("must be one of '%s', '%s'", FollowersSum, FollowersMax),
+		})
+	}
+
+	if len(violations) > 0 {
+		return nil, &api.ValidationError{
+			Violations: violations,
+		}
+	}
+
+	return body, nil
+}
+
+// Description:
+//
+//	Searches the artists with the given ids in the database.
+//
+// Parameters:
+//
+//	ctx 	The operation context.
+//	store 	The store to search through.
+//	ids 	The ids to search for.
+//
+// Returns:
+//
+//	The found artists, in the order of the given ids. Ids of artists which do not exist or are soft-deleted are skipped.
+//	An error if the query fails.
+func FindArtistsByID(ctx context.Context, store store.Store[models.ArtistInfo], ids []string) ([]models.ArtistInfo, error) {
+	filter := query.Filter{
+		Root: models.NotDeleted(query.FilterOperatorIn{
+			Key: "_id",
+			Values: arrays.Map[string](ids, func(id string) interface{} {
+				return id
+			}),
+		}),
+	}
+
+	items, err := store.FindItems(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]models.ArtistInfo, len(items))
+	for _, item := range items {
+		found[item.ID] = item
+	}
+
+	artists := make([]models.ArtistInfo, 0, len(items))
+
+	for _, id := range ids {
+		if artist, ok := found[id]; ok {
+			artists = append(artists, artist)
+		}
+	}
+
+	return artists, nil
+}
+
+// Description:
+//
+//	Merges artists into a target artist.
+//	Genres are united, keeping the order of first occurrence. Followers are combined according to the
+//	strategy, the popularity is the maximum. The name is kept.
+//
+// Parameters:
+//
+//	target 		The target artist.
+//	sources 	The artists to merge into the target artist.
+//	strategy 	How followers are combined, either 'sum' or 'max'.
+//
+// Returns:
+//
+//	The merged target artist.
+func MergeArtists(target models.ArtistInfo, sources []models.ArtistInfo, strategy string) models.ArtistInfo {
+	merged := target
+	merged.Genres = make([]string, 0, len(target.Genres))
+
+	seen := make(map[string]bool)
+	followers := uint64(target.Followers)
+
+	for _, artist := range append([]models.ArtistInfo{target}, sources...) {
+		for _, genre := range artist.Genres {
+			if !seen[genre] {
+				seen[genre] = true
+				merged.Genres = append(merged.Genres, genre)
+			}
+		}
+
+		if artist.Stats.Popularity > merged.Stats.Popularity {
+			merged.Stats.Popularity = artist.Stats.Popularity
+		}
+	}
+
+	for _, artist := range sources {
+		switch strategy {
+		case FollowersMax:
+			followers = max(followers, uint64(artist.Followers))
+		default:
+			followers += uint64(artist.Followers)
+		}
+	}
+
+	merged.Followers = uint32(min(followers, math.MaxUint32))
+	return merged
+}
+
+// Description:
+//
+//	Merges the source artists into the target artist within the current transaction.
+//	Updates the target artist, deletes the source artists, redirects their ids to the target artist
+//	and records the changes in the audit trail.
+//
+// Parameters:
+//
+//	ctx 		The transaction context.
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//	id 			The id of the target artist.
+//	body 		The request body.
+//	dryRun 		Whether the merge is only previewed, without changing any artist.
+//
+// Returns:
+//
+//	The merged target artist.
+//	A problem if the merge is rejected.
+//	An error if the operation fails.
+func merge(ctx context.Context, request *api.APIRequest, injector *inject.Injector, id string, body *MergeArtistsRequestBody, dryRun bool) (*models.ArtistInfo, *api.Problem, error) {
+	artistStore := injector.ArtistStore
+
+	targets, err := FindArtistsByID(ctx, artistStore, []string{id})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(targets) == 0 {
+		return nil, api.NewProblem(http.StatusNotFound, "artist not found"), nil
+	}
+
+	target := targets[0]
+
+	problem := api.CheckIfMatch(request, api.FormatETag(target.Version), injector.RequireIfMatch)
+	if problem != nil {
+		return nil, problem, nil
+	}
+
+	sources, err := FindArtistsByID(ctx, artistStore, body.SourceIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(sources) != len(body.SourceIDs) {
+		problem := api.NewProblem(http.StatusUnprocessableEntity, "source artist not found")
+
+		found := make(map[string]bool, len(sources))
+		for _, source := range sources {
+			found[source.ID] = true
+		}
+
+		for index, sourceID := range body.SourceIDs {
+			if !found[sourceID] {
+				problem.WithErrors(api.Violation{
+					Pointer: fmt.Sprintf("/sourceIds/%d", index),
+					Message: "artist does not exist",
+				})
+			}
+		}
+
+		return nil, problem, nil
+	}
+
+	merged := MergeArtists(target, sources, body.FollowersStrategy)
+
+	if len(merged.Genres) > MaxGenres {
+		return nil, api.NewProblem(http.StatusUnprocessableEntity, fmt.Sprintf("merged artist would have more than %d genres", MaxGenres)), nil
+	}
+
+	if dryRun {
+		return &merged, nil, nil
+	}
+
+	updateFilter := query.Filter{
+		Root: query.FilterOperatorEq{
+			Key:   "_id",
+			Value: id,
+		},
+	}
+
+	updateOperator := query.Update{
+		Root: query.UpdateOperatorSet{
+			Set: map[string]interface{}{
+				"genres":           merged.Genres,
+				"followers":        merged.Followers,
+				"stats.popularity": merged.Stats.Popularity,
+			},
+		},
+	}
+
+	err = artistStore.UpdateItemVersion(ctx, &updateFilter, target.Version, &updateOperator)
+	if errors.Is(err, store.ErrVersionMismatch) || errors.Is(err, store.ErrNotFound) {
+		return nil, api.NewProblem(http.StatusConflict, "the artists have been modified concurrently"), nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged.Version++
+	now := time.Now().UTC()
+
+	changes := []audit.Change{{Action: audit.ActionMerge, Before: &target, After: &merged}}
+
+	for _, source := range sources {
+		err = artistStore.DeleteItemVersion(ctx, source.ID, source.Version)
+		if errors.Is(err, store.ErrVersionMismatch) || errors.Is(err, store.ErrNotFound) {
+			return nil, api.NewProblem(http.StatusConflict, "the artists have been modified concurrently"), nil
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = injector.RedirectStore.CreateItem(ctx, models.ArtistRedirect{
+			ID:        source.ID,
+			TargetID:  id,
+			CreatedAt: now,
+		})
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		changes = append(changes, audit.Change{Action: audit.ActionMerge, Before: &source})
+	}
+
+	err = injector.Auditor.Record(ctx, audit.OriginOf(request), changes...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &merged, nil, nil
+}
+
+// Description:
+//
+//	Merges the source artists into the target artist atomically.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//	id 			The id of the target artist.
+//	body 		The request body.
+//	dryRun 		Whether the merge is only previewed, without changing any artist.
+//
+// Returns:
+//
+//	The merged target artist.
+//	A problem if the merge is rejected. The transaction is rolled back.
+//	An error if the operation fails. The transaction is rolled back.
+func mergeInTransaction(request *api.APIRequest, injector *inject.Injector, id string, body *MergeArtistsRequestBody, dryRun bool) (*models.ArtistInfo, *api.Problem, error) {
+	var merged *models.ArtistInfo
+	var problem *api.Problem
+
+	err := injector.Transactor.WithTransaction(request.Context, func(ctx context.Context) error {
+		var err error
+
+		merged, problem, err = merge(ctx, request, injector, id, body, dryRun)
+		if problem != nil {
+			return errRejected
+		}
+
+		return err
+	})
+
+	if problem != nil {
+		return nil, problem, nil
+	}
+
+	return merged, nil, err
+}
+
+// Description:
+//
+//	The router handler for merging artists.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object.
+func Handler(request *api.APIRequest, injector *inject.Injector) *api.APIResponse {
+	context := request.Parallel
+
+	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
+	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
+
+	id, validationErr := GetAndValidateID(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed path parameter validation: %s", context.ID, validationErr.Message)
+		return api.NewProblem(http.StatusBadRequest, "invalid artist id").
+			WithRequest(request).
+			WithErrors(*validationErr).
+			Response()
+	}
+
+	dryRun, validationErr := GetAndValidateDryRun(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed query parameter validation: %s", context.ID, validationErr.Message)
+		return api.NewProblem(http.StatusBadRequest, "invalid query parameters").
+			WithRequest(request).
+			WithErrors(*validationErr).
+			Response()
+	}
+
+	requestBody, err := ExtractRequestBody(request, id)
+	if err != nil {
+		log.Warnf("[%s] failed to extract request body: %s", context.ID, err)
+
+		problem := api.NewProblem(http.StatusBadRequest, "invalid request body").WithRequest(request)
+
+		var bindErr *api.ValidationError
+		if errors.As(err, &bindErr) {
+			problem.WithErrors(bindErr.Violations...)
+		}
+
+		return problem.Response()
+	}
+
+	log.Tracef("[%s] attempting to merge database items ...", context.ID)
+	merged, problem, err := mergeInTransaction(request, injector, id, requestBody, dryRun)
+
+	if problem != nil {
+		log.Warnf("[%s] merge rejected: %s", context.ID, problem.Detail)
+		return problem.WithRequest(request).Response()
+	}
+
+	if err != nil {
+		log.Errorf("[%s] failed to merge database items: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, "")
+	}
+
+	if dryRun {
+		log.Infof("[%s] previewed merge of %d artists into %s", context.ID, len(requestBody.SourceIDs), id)
+	} else {
+		log.Infof("[%s] merged %d artists into %s", context.ID, len(requestBody.SourceIDs), id)
+	}
+
+	log.Tracef("[%s] successfully completed request", context.ID)
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"ETag": api.FormatETag(merged.Version),
+		},
+		Body: MergeArtistsResponseBody{
+			Artist:    *merged,
+			MergedIDs: requestBody.SourceIDs,
+			DryRun:    dryRun,
+		},
+	}
+}
@@ -0,0 +1,485 @@
+package patchartist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/gostream-official/artists/impl/audit"
+	"github.com/gostream-official/artists/impl/duplicates"
+	"github.com/gostream-official/artists/impl/inject"
+	"github.com/gostream-official/artists/impl/models"
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/marshal"
+	"github.com/gostream-official/artists/pkg/patch"
+	"github.com/gostream-official/artists/pkg/store"
+	"github.com/gostream-official/artists/pkg/store/query"
+	"github.com/revx-official/output/log"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/google/uuid"
+)
+
+// Description:
+//
+//	The maximum number of attempts to patch an artist without precondition,
+//	while it is modified concurrently.
+const MaxAttempts = 5
+
+// Description:
+//
+//	The patchable representation of an artist.
+//	Patches are applied to this representation, the result must be valid.
+type PatchArtistDocument struct {
+
+	// The name of the artist.
+	Name string `json:"name" bson:"name" validate:"required,notblank,maxlen=256"`
+
+	// The normalized name key of the artist. Derived from the name, not patchable.
+	NameKey string `json:"-" bson:"nameKey,omitempty"`
+
+	// The genres an artist is active in.
+	Genres []string `json:"genres" bson:"genres" validate:"maxlen=32,unique,dive,notblank,maxlen=64"`
+
+	// The amount of followers the artist has.
+	Followers uint32 `json:"followers" bson:"followers"`
+
+	// Some artist statistics.
+	Stats PatchArtistStatsDocument `json:"stats" bson:"stats"`
+}
+
+// Description:
+//
+//	The patchable representation of the artist statistics.
+type PatchArtistStatsDocument struct {
+
+	// The popularity factor of the artist.
+	Popularity float32 `json:"popularity" bson:"popularity,truncate" validate:"min=0,max=1"`
+}
+
+// Description:
+//
+//	Gets and validates the id path parameter.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The id path parameter.
+//	A violation if the id is not a valid uuid.
+func GetAndValidateID(request *api.APIRequest) (string, *api.Violation) {
+	id := request.PathParameters["id"]
+
+	_, err := uuid.Parse(id)
+	if err != nil {
+		return "", &api.Violation{
+			Parameter: "id",
+			Message:   "must be a valid uuid",
+		}
+	}
+
+	return id, nil
+}
+
+// Description:
+//
+//	Searches an artist with the given id in the database.
+//
+// Parameters:
+//
+//	ctx 	The operation context.
+//	store 	The store to search through.
+//	id 		The id to search for.
+//
+// Returns:
+//
+//	The artist, or nil if the artist does not exist.
+//	An error if the query fails.
+func FindArtistByID(ctx context.Context, store store.Store[models.ArtistInfo], id string) (*models.ArtistInfo, error) {
+	filter := query.Filter{
+		Root: models.NotDeleted(query.FilterOperatorEq{
+			Key:   "_id",
+			Value: id,
+		}),
+		Limit: 1,
+	}
+
+	items, err := store.FindItems(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	return &items[0], nil
+}
+
+// Description:
+//
+//	Creates the patchable representation of an artist.
+//
+// Parameters:
+//
+//	artist The artist.
+//
+// Returns:
+//
+//	The patchable representation.
+func NewDocument(artist *models.ArtistInfo) PatchArtistDocument {
+	genres := artist.Genres
+	if genres == nil {
+		genres = make([]string, 0)
+	}
+
+	return PatchArtistDocument{
+		Name:      artist.Name,
+		NameKey:   artist.NameKey,
+		Genres:    genres,
+		Followers: artist.Followers,
+		Stats: PatchArtistStatsDocument{
+			Popularity: artist.Stats.Popularity,
+		},
+	}
+}
+
+// Description:
+//
+//	Applies the request body to the patchable representation of an artist.
+//	The patch format is selected by the request content type, either JSON Merge Patch or JSON Patch.
+//
+// Parameters:
+//
+//	request 	The request carrying the patch.
+//	document 	The patchable representation of the artist.
+//
+// Returns:
+//
+//	The patched and validated representation, or a problem describing why the patch cannot be applied.
+func ApplyPatch(request *api.APIRequest, document PatchArtistDocument) (*PatchArtistDocument, *api.Problem) {
+	mediaType, _, err := mime.ParseMediaType(request.Header("Content-Type"))
+	if err != nil || (mediaType != patch.MergePatchContentType && mediaType != patch.JSONPatchContentType) {
+		return nil, api.NewProblem(http.StatusUnsupportedMediaType, "content type must be "+patch.MergePatchContentType+" or "+patch.JSONPatchContentType)
+	}
+
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, api.NewProblem(http.StatusInternalServerError, "")
+	}
+
+	var current interface{}
+	_ = json.Unmarshal(encoded, &current)
+
+	var patched interface{}
+
+	if mediaType == patch.MergePatchContentType {
+		var mergePatch interface{}
+
+		err = json.Unmarshal([]byte(request.Body), &mergePatch)
+		if err != nil {
+			return nil, api.NewProblem(http.StatusBadRequest, "invalid patch document")
+		}
+
+		patched = patch.MergePatch(current, mergePatch)
+	} else {
+		operations, err := patch.DecodeJSONPatch([]byte(request.Body))
+		if err != nil {
+			return nil, api.NewProblem(http.StatusBadRequest, err.Error())
+		}
+
+		patched, err = patch.ApplyJSONPatch(current, operations)
+
+		if errors.Is(err, patch.ErrTestFailed) {
+			return nil, api.NewProblem(http.StatusConflict, err.Error())
+		}
+
+		if err != nil {
+			return nil, api.NewProblem(http.StatusUnprocessableEntity, err.Error())
+		}
+	}
+
+	encoded, err = json.Marshal(patched)
+	if err != nil {
+		return nil, api.NewProblem(http.StatusInternalServerError, "")
+	}
+
+	result := &PatchArtistDocument{}
+
+	err = api.BindJSON(encoded, result)
+	if err != nil {
+		problem := api.NewProblem(http.StatusUnprocessableEntity, "patched artist is invalid")
+
+		var bindErr *api.ValidationError
+		if errors.As(err, &bindErr) {
+			problem.WithErrors(bindErr.Violations...)
+		}
+
+		return nil, problem
+	}
+
+	if result.Genres == nil {
+		result.Genres = make([]string, 0)
+	}
+
+	result.NameKey = models.NameKey(result.Name)
+	return result, nil
+}
+
+// Description:
+//
+//	Creates the update operators transforming the current into the patched representation.
+//
+// Parameters:
+//
+//	before 	The current representation.
+//	after 	The patched representation.
+//
+// Returns:
+//
+//	The update, without root if nothing changed, or an error if a representation cannot be encoded.
+func CreateUpdate(before PatchArtistDocument, after PatchArtistDocument) (query.Update, error) {
+	beforeDocument, err := toDocument(before)
+	if err != nil {
+		return query.Update{}, err
+	}
+
+	afterDocument, err := toDocument(after)
+	if err != nil {
+		return query.Update{}, err
+	}
+
+	return patch.ToUpdate(beforeDocument, afterDocument), nil
+}
+
+// Description:
+//
+//	Converts a representation into a BSON document, as stored in the database.
+//
+// Parameters:
+//
+//	document The representation to convert.
+//
+// Returns:
+//
+//	The BSON document, or an error if the representation cannot be encoded.
+func toDocument(document PatchArtistDocument) (bson.M, error) {
+	encoded, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	result := bson.M{}
+
+	err = bson.Unmarshal(encoded, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Description:
+//
+//	Updates the artist and records the update in the audit trail atomically.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//	filter 		The filter selecting the artist.
+//	update 		The update to apply.
+//	before 		The artist before the update.
+//	after 		The artist after the update.
+//
+// Returns:
+//
+//	An error if the artist cannot be updated, see UpdateItemVersion. Nothing is recorded.
+func updateInTransaction(request *api.APIRequest, injector *inject.Injector, filter *query.Filter, update *query.Update, before *models.ArtistInfo, after *models.ArtistInfo) error {
+	return injector.Auditor.WithTransaction(request.Context, audit.OriginOf(request), func(ctx context.Context) ([]audit.Change, error) {
+		err := injector.ArtistStore.UpdateItemVersion(ctx, filter, before.Version, update)
+		if err != nil {
+			return nil, err
+		}
+
+		return []audit.Change{{Action: audit.ActionUpdate, Before: before, After: after}}, nil
+	})
+}
+
+// Description:
+//
+//	The router handler for partial artist updates.
+//	Without 'If-Match' header, the artist is read again and the patch applied again if it is modified concurrently.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object.
+func Handler(request *api.APIRequest, injector *inject.Injector) *api.APIResponse {
+	context := request.Parallel
+
+	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
+	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
+
+	id, validationErr := GetAndValidateID(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed path parameter validation: %s", context.ID, validationErr.Message)
+		return api.NewProblem(http.StatusBadRequest, "invalid artist id").
+			WithRequest(request).
+			WithErrors(*validationErr).
+			Response()
+	}
+
+	conditional := request.Header("If-Match") != ""
+
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		response, err := attemptPatch(request, injector, id)
+		if err == nil {
+			return response
+		}
+
+		if conditional {
+			log.Warnf("[%s] artist was modified concurrently", context.ID)
+			return api.ProblemResponse(request, http.StatusPreconditionFailed, "the resource has been modified")
+		}
+
+		log.Warnf("[%s] artist was modified concurrently, retrying", context.ID)
+	}
+
+	log.Errorf("[%s] artist was modified concurrently %d times, giving up", context.ID, MaxAttempts)
+	return api.ProblemResponse(request, http.StatusServiceUnavailable, "the artist is modified too frequently, try again later")
+}
+
+// Description:
+//
+//	Reads the artist and applies the patch to it, if it has not been modified in the meantime.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//	id 			The id of the artist to patch.
+//
+// Returns:
+//
+//	An API response object, or store.ErrVersionMismatch if the artist was modified concurrently.
+func attemptPatch(request *api.APIRequest, injector *inject.Injector, id string) (*api.APIResponse, error) {
+	context := request.Parallel
+	artistStore := injector.ArtistStore
+
+	artist, err := FindArtistByID(request.Context, artistStore, id)
+	if err != nil {
+		log.Errorf("[%s] failed to retrieve database item: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, ""), nil
+	}
+
+	if artist == nil {
+		log.Warnf("[%s] could not find artist: %s", context.ID, id)
+		return api.ProblemResponse(request, http.StatusNotFound, "artist not found"), nil
+	}
+
+	problem := api.CheckIfMatch(request, api.FormatETag(artist.Version), injector.RequireIfMatch)
+	if problem != nil {
+		log.Warnf("[%s] failed precondition: %s", context.ID, problem.Detail)
+		return problem.Response(), nil
+	}
+
+	before := NewDocument(artist)
+
+	after, problem := ApplyPatch(request, before)
+	if problem != nil {
+		log.Warnf("[%s] failed to apply patch: %s", context.ID, problem.Detail)
+		response := problem.WithRequest(request).Response()
+
+		if problem.Status == http.StatusUnsupportedMediaType {
+			response.Headers["Accept-Patch"] = patch.MergePatchContentType + ", " + patch.JSONPatchContentType
+		}
+
+		return response, nil
+	}
+
+	headers := map[string]string{}
+
+	if after.NameKey != before.NameKey {
+		conflict, err := duplicates.FindConflict(request.Context, artistStore, id, after.NameKey)
+		if err != nil {
+			log.Errorf("[%s] failed to search duplicate artists: %s", context.ID, err)
+			return api.ProblemResponse(request, http.StatusInternalServerError, ""), nil
+		}
+
+		if conflict != nil {
+			if injector.DuplicateNamePolicy == duplicates.PolicyReject {
+				log.Warnf("[%s] artist name duplicates artist %s", context.ID, conflict.ID)
+				return duplicates.ConflictProblem(request, conflict).Response(), nil
+			}
+
+			log.Warnf("[%s] accepting artist name duplicating artist %s", context.ID, conflict.ID)
+			headers["Warning"] = duplicates.Warning(conflict)
+		}
+	}
+
+	updateOperator, err := CreateUpdate(before, *after)
+	if err != nil {
+		log.Errorf("[%s] failed to create update: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, ""), nil
+	}
+
+	patched := *artist
+	patched.Name = after.Name
+	patched.NameKey = after.NameKey
+	patched.Genres = after.Genres
+	patched.Followers = after.Followers
+	patched.Stats.Popularity = after.Stats.Popularity
+
+	if updateOperator.Root != nil {
+		patched.Version++
+
+		updateFilter := query.Filter{
+			Root: query.FilterOperatorEq{
+				Key:   "_id",
+				Value: id,
+			},
+		}
+
+		log.Tracef("[%s] attempting to update database item ...", context.ID)
+		err = updateInTransaction(request, injector, &updateFilter, &updateOperator, artist, &patched)
+
+		if errors.Is(err, store.ErrVersionMismatch) {
+			return nil, err
+		}
+
+		if errors.Is(err, store.ErrNotFound) {
+			log.Warnf("[%s] artist was deleted concurrently", context.ID)
+			return api.ProblemResponse(request, http.StatusNotFound, "artist not found"), nil
+		}
+
+		if errors.Is(err, store.ErrDuplicateKey) {
+			log.Warnf("[%s] artist name was taken concurrently", context.ID)
+
+			conflict, _ := duplicates.FindConflict(request.Context, artistStore, id, after.NameKey)
+			return duplicates.ConflictProblem(request, conflict).Response(), nil
+		}
+
+		if err != nil {
+			log.Errorf("[%s] failed to update database item: %s", context.ID, err)
+			return api.ProblemResponse(request, http.StatusInternalServerError, ""), nil
+		}
+	}
+
+	headers["ETag"] = api.FormatETag(patched.Version)
+
+	log.Tracef("[%s] successfully completed request", context.ID)
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers:    headers,
+		Body:       patched,
+	}, nil
+}
@@ -0,0 +1,278 @@
+package restoreartist
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gostream-official/artists/impl/audit"
+	"github.com/gostream-official/artists/impl/duplicates"
+	"github.com/gostream-official/artists/impl/inject"
+	"github.com/gostream-official/artists/impl/models"
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/marshal"
+	"github.com/gostream-official/artists/pkg/store"
+	"github.com/gostream-official/artists/pkg/store/query"
+	"github.com/revx-official/output/log"
+
+	"github.com/google/uuid"
+)
+
+// Description:
+//
+//	The maximum number of attempts to restore an artist without precondition,
+//	while it is modified concurrently.
+const MaxAttempts = 5
+
+// Description:
+//
+//	Gets and validates the id path parameter.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The id path parameter.
+//	A violation if the id is not a valid uuid.
+func GetAndValidateID(request *api.APIRequest) (string, *api.Violation) {
+	id := request.PathParameters["id"]
+
+	_, err := uuid.Parse(id)
+	if err != nil {
+		return "", &api.Violation{
+			Parameter: "id",
+			Message:   "must be a valid uuid",
+		}
+	}
+
+	return id, nil
+}
+
+// Description:
+//
+//	Searches an artist with the given id in the database, including soft-deleted artists.
+//
+// Parameters:
+//
+//	ctx 	The operation context.
+//	store 	The store to search through.
+//	id 		The id to search for.
+//
+// Returns:
+//
+//	The artist, or nil if the artist does not exist.
+//	An error if the query fails.
+func FindArtistByID(ctx context.Context, store store.Store[models.ArtistInfo], id string) (*models.ArtistInfo, error) {
+	filter := query.Filter{
+		Root: query.FilterOperatorEq{
+			Key:   "_id",
+			Value: id,
+		},
+		Limit: 1,
+	}
+
+	items, err := store.FindItems(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	return &items[0], nil
+}
+
+// Description:
+//
+//	Restores the artist in its current version and records the restoration in the audit trail atomically.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//	before 		The deleted artist.
+//	after 		The restored artist.
+//
+// Returns:
+//
+//	An error if the artist cannot be restored, see UpdateItemVersion. Nothing is recorded.
+func restoreInTransaction(request *api.APIRequest, injector *inject.Injector, before *models.ArtistInfo, after *models.ArtistInfo) error {
+	updateFilter := query.Filter{
+		Root: query.FilterOperatorEq{
+			Key:   "_id",
+			Value: before.ID,
+		},
+	}
+
+	updateOperator := query.Update{
+		Root: query.UpdateOperatorCombine{
+			Combine: []query.IQuery{
+				query.UpdateOperatorSet{
+					Set: map[string]interface{}{
+						"nameKey": after.NameKey,
+					},
+				},
+				query.UpdateOperatorUnset{
+					Unset: []string{"deletedAt"},
+				},
+			},
+		},
+	}
+
+	return injector.Auditor.WithTransaction(request.Context, audit.OriginOf(request), func(ctx context.Context) ([]audit.Change, error) {
+		err := injector.ArtistStore.UpdateItemVersion(ctx, &updateFilter, before.Version, &updateOperator)
+		if err != nil {
+			return nil, err
+		}
+
+		return []audit.Change{{Action: audit.ActionRestore, Before: before, After: after}}, nil
+	})
+}
+
+// Description:
+//
+//	The router handler for restoring soft-deleted artists.
+//	Without 'If-Match' header, the artist is read again and the restore retried if it is modified concurrently.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object.
+func Handler(request *api.APIRequest, injector *inject.Injector) *api.APIResponse {
+	context := request.Parallel
+
+	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
+	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
+
+	id, validationErr := GetAndValidateID(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed path parameter validation: %s", context.ID, validationErr.Message)
+		return api.NewProblem(http.StatusBadRequest, "invalid artist id").
+			WithRequest(request).
+			WithErrors(*validationErr).
+			Response()
+	}
+
+	conditional := request.Header("If-Match") != ""
+
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		response, err := attemptRestore(request, injector, id)
+		if err == nil {
+			return response
+		}
+
+		if conditional {
+			log.Warnf("[%s] artist was modified concurrently", context.ID)
+			return api.ProblemResponse(request, http.StatusPreconditionFailed, "the resource has been modified")
+		}
+
+		log.Warnf("[%s] artist was modified concurrently, retrying", context.ID)
+	}
+
+	log.Errorf("[%s] artist was modified concurrently %d times, giving up", context.ID, MaxAttempts)
+	return api.ProblemResponse(request, http.StatusServiceUnavailable, "the artist is modified too frequently, try again later")
+}
+
+// Description:
+//
+//	Reads the artist and restores it, if it has not been modified in the meantime.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//	id 			The id of the artist to restore.
+//
+// Returns:
+//
+//	An API response object, or store.ErrVersionMismatch if the artist was modified concurrently.
+func attemptRestore(request *api.APIRequest, injector *inject.Injector, id string) (*api.APIResponse, error) {
+	context := request.Parallel
+	artistStore := injector.ArtistStore
+
+	artist, err := FindArtistByID(request.Context, artistStore, id)
+	if err != nil {
+		log.Errorf("[%s] failed to retrieve database item: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, ""), nil
+	}
+
+	if artist == nil {
+		log.Warnf("[%s] could not find artist: %s", context.ID, id)
+		return api.ProblemResponse(request, http.StatusNotFound, "artist not found"), nil
+	}
+
+	if artist.DeletedAt == nil {
+		log.Warnf("[%s] artist is not deleted: %s", context.ID, id)
+		return api.ProblemResponse(request, http.StatusConflict, "artist is not deleted"), nil
+	}
+
+	problem := api.CheckIfMatch(request, api.FormatETag(artist.Version), injector.RequireIfMatch)
+	if problem != nil {
+		log.Warnf("[%s] failed precondition: %s", context.ID, problem.Detail)
+		return problem.Response(), nil
+	}
+
+	headers := map[string]string{}
+	nameKey := models.NameKey(artist.Name)
+
+	conflict, err := duplicates.FindConflict(request.Context, artistStore, id, nameKey)
+	if err != nil {
+		log.Errorf("[%s] failed to search duplicate artists: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, ""), nil
+	}
+
+	if conflict != nil {
+		if injector.DuplicateNamePolicy == duplicates.PolicyReject {
+			log.Warnf("[%s] artist name duplicates artist %s", context.ID, conflict.ID)
+			return duplicates.ConflictProblem(request, conflict).Response(), nil
+		}
+
+		log.Warnf("[%s] accepting artist name duplicating artist %s", context.ID, conflict.ID)
+		headers["Warning"] = duplicates.Warning(conflict)
+	}
+
+	restored := *artist
+	restored.NameKey = nameKey
+	restored.DeletedAt = nil
+	restored.Version++
+
+	log.Tracef("[%s] attempting to update database item ...", context.ID)
+	err = restoreInTransaction(request, injector, artist, &restored)
+
+	if errors.Is(err, store.ErrVersionMismatch) {
+		return nil, err
+	}
+
+	if errors.Is(err, store.ErrNotFound) {
+		log.Warnf("[%s] artist was purged concurrently", context.ID)
+		return api.ProblemResponse(request, http.StatusNotFound, "artist not found"), nil
+	}
+
+	if errors.Is(err, store.ErrDuplicateKey) {
+		log.Warnf("[%s] artist name was taken concurrently", context.ID)
+
+		conflict, _ := duplicates.FindConflict(request.Context, artistStore, id, nameKey)
+		return duplicates.ConflictProblem(request, conflict).Response(), nil
+	}
+
+	if err != nil {
+		log.Errorf("[%s] failed to update database item: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, ""), nil
+	}
+
+	headers["ETag"] = api.FormatETag(restored.Version)
+
+	log.Tracef("[%s] successfully completed request", context.ID)
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers:    headers,
+		Body:       restored,
+	}, nil
+}
@@ -0,0 +1,252 @@
+package searchartists
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gostream-official/artists/impl/inject"
+	"github.com/gostream-official/artists/impl/models"
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/marshal"
+	"github.com/gostream-official/artists/pkg/paging"
+	"github.com/gostream-official/artists/pkg/store/query"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	The response body for the search artists endpoint.
+type SearchArtistsResponseBody struct {
+
+	// The artists of the requested page.
+	Items interface{} `json:"items"`
+
+	// The continuation token for the next page. Omitted, if this is the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Description:
+//
+//	Unmarshals the request body for this endpoint.
+//	The request body is a JSON encoded query filter.
+//
+// Parameters:
+//
+//	request The original request.
+//
+// Returns:
+//
+//	The unmarshalled query filter, or an error when unmarshalling fails.
+func ExtractRequestBody(request *api.APIRequest) (*query.Filter, error) {
+	filter := &query.Filter{}
+
+	bytes := []byte(request.Body)
+	err := json.Unmarshal(bytes, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// Description:
+//
+//	Validates the query filter for this endpoint.
+//	Logical operators must not be empty and regular expressions are restricted like for RSQL expressions.
+//	Only artist fields may be referred to, sorting and projection are restricted like for listing artists.
+//
+// Parameters:
+//
+//	filter The query filter.
+//
+// Returns:
+//
+//	An error if the validation fails.
+func ValidateFilter(filter *query.Filter) error {
+	err := filter.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = models.ArtistSchema.Validate(filter.Root)
+	if err != nil {
+		return err
+	}
+
+	for _, sortKey := range filter.Sort {
+		if _, ok := FieldOf(models.ArtistSortableFields, sortKey.Key); !ok {
+			return fmt.Errorf("key is not sortable: %s", sortKey.Key)
+		}
+
+		if sortKey.Order != query.SortAscending && sortKey.Order != query.SortDescending {
+			return fmt.Errorf("invalid sort order for key: %s", sortKey.Key)
+		}
+	}
+
+	for _, key := range filter.Projection {
+		if _, ok := FieldOf(models.ArtistProjectableFields, key); !ok {
+			return fmt.Errorf("key cannot be selected: %s", key)
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Looks up the artist field which is mapped to the given document key.
+//
+// Parameters:
+//
+//	fields 	The artist fields, mapped to their document keys.
+//	key 	The document key.
+//
+// Returns:
+//
+//	The artist field and whether it exists.
+func FieldOf(fields map[string]string, key string) (string, bool) {
+	for field, fieldKey := range fields {
+		if fieldKey == key {
+			return field, true
+		}
+	}
+
+	return "", false
+}
+
+// Description:
+//
+//	Reduces the given artists to the selected document keys.
+//	The artist id is always included.
+//
+// Parameters:
+//
+//	items 	The artists to reduce.
+//	keys 	The selected document keys.
+//
+// Returns:
+//
+//	The reduced artists, or an error if an artist cannot be converted.
+func ProjectItems(items []models.ArtistInfo, keys []string) ([]map[string]interface{}, error) {
+	fields := []string{"id"}
+
+	for _, key := range keys {
+		field, _ := FieldOf(models.ArtistProjectableFields, key)
+		fields = append(fields, field)
+	}
+
+	result := make([]map[string]interface{}, 0, len(items))
+
+	for _, item := range items {
+		projected, err := marshal.Project(item, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, projected)
+	}
+
+	return result, nil
+}
+
+// Description:
+//
+//	The router handler for searching artists by a JSON encoded query filter.
+//
+// Parameters:
+//
+//	request The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object.
+func Handler(request *api.APIRequest, injector *inject.Injector) *api.APIResponse {
+	context := request.Parallel
+
+	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
+	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
+
+	artistStore := injector.ArtistStore
+
+	filter, err := ExtractRequestBody(request)
+	if err != nil {
+		log.Warnf("[%s] failed to extract request body: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusBadRequest, fmt.Sprintf("invalid filter: %s", err))
+	}
+
+	err = ValidateFilter(filter)
+	if err != nil {
+		log.Warnf("[%s] received invalid filter: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusBadRequest, fmt.Sprintf("invalid filter: %s", err))
+	}
+
+	deletedMode := models.DeletedExclude
+
+	deleted, deletedOk := request.QueryParameter("deleted")
+	if deletedOk {
+		deletedMode, err = models.ParseDeletedMode(deleted)
+		if err != nil {
+			log.Warnf("[%s] received invalid deleted mode: %s", context.ID, deleted)
+			return api.NewProblem(http.StatusBadRequest, err.Error()).
+				WithRequest(request).
+				WithErrors(api.Violation{
+					Parameter: "deleted",
+					Message:   "must be one of 'exclude', 'include', 'only'",
+				}).
+				Response()
+		}
+	}
+
+	filter.Root = models.RestrictDeleted(filter.Root, deletedMode)
+
+	log.Debugf("[%s] filter: %s", context.ID, marshal.Quick(filter))
+
+	cursor, _ := request.QueryParameter("cursor")
+
+	if cursor != "" && filter.Skip > 0 {
+		log.Warnf("[%s] received both cursor and skip", context.ID)
+		return api.ProblemResponse(request, http.StatusBadRequest, "cursor and skip cannot be combined")
+	}
+
+	page, err := paging.FindPage(request.Context, artistStore, *filter, cursor, &injector.Paginator)
+
+	if errors.Is(err, paging.ErrInvalidCursor) {
+		log.Warnf("[%s] received invalid cursor: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusBadRequest, "invalid cursor")
+	}
+
+	if err != nil {
+		log.Errorf("[%s] failed to retrieve database items: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, "")
+	}
+
+	headers := make(map[string]string)
+
+	if page.NextCursor != "" {
+		headers["Link"] = paging.NextLink(request, page.NextCursor)
+	}
+
+	responseBody := SearchArtistsResponseBody{
+		Items:      page.Items,
+		NextCursor: page.NextCursor,
+	}
+
+	if len(filter.Projection) > 0 {
+		responseBody.Items, err = ProjectItems(page.Items, filter.Projection)
+
+		if err != nil {
+			log.Errorf("[%s] failed to project items: %s", context.ID, err)
+			return api.ProblemResponse(request, http.StatusInternalServerError, "")
+		}
+	}
+
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers:    headers,
+		Body:       responseBody,
+	}
+}
@@ -1,17 +1,17 @@
 package updateartist
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 
+	"github.com/gostream-official/artists/impl/audit"
+	"github.com/gostream-official/artists/impl/duplicates"
 	"github.com/gostream-official/artists/impl/inject"
 	"github.com/gostream-official/artists/impl/models"
 	"github.com/gostream-official/artists/pkg/api"
-	"github.com/gostream-official/artists/pkg/arrays"
 	"github.com/gostream-official/artists/pkg/marshal"
-	"github.com/gostream-official/artists/pkg/parallel"
 	"github.com/gostream-official/artists/pkg/store"
 	"github.com/gostream-official/artists/pkg/store/query"
 	"github.com/revx-official/output/log"
@@ -19,22 +19,29 @@ import (
 	"github.com/google/uuid"
 )
 
+// Description:
+//
+//	The maximum number of attempts to update an artist without precondition,
+//	while it is modified concurrently.
+const MaxAttempts = 5
+
 // Description:
 //
 //	The request body for the update artist endpoint.
+//	Replaces the artist as a whole. Omitted fields are reset to their zero values.
 type UpdateArtistRequestBody struct {
 
 	// The name of the artist.
-	Name string `json:"name,omitempty" bson:"name"`
+	Name string `json:"name" bson:"name" validate:"required,notblank,maxlen=256"`
 
 	// The genres an artist is active in.
-	Genres []string `json:"genres,omitempty" bson:"genres"`
+	Genres []string `json:"genres" bson:"genres" validate:"maxlen=32,unique,dive,notblank,maxlen=64"`
 
 	// The amount of followers the artist has.
-	Followers uint32 `json:"followers,omitempty" bson:"followers"`
+	Followers uint32 `json:"followers" bson:"followers"`
 
 	// Some artist statistics.
-	Stats UpdateArtistStatsRequestBody `json:"stats,omitempty" bson:"stats"`
+	Stats UpdateArtistStatsRequestBody `json:"stats" bson:"stats"`
 }
 
 // Description:
@@ -43,67 +50,12 @@ type UpdateArtistRequestBody struct {
 type UpdateArtistStatsRequestBody struct {
 
 	// The popularity factor of the artist.
-	Popularity float32 `json:"popularity,omitempty" bson:"popularity"`
-}
-
-// Description:
-//
-//	The error response body for the update artist endpoint.
-type UpdateArtistErrorResponseBody struct {
-
-	// The error message.
-	Message string `json:"message"`
-}
-
-// Description:
-//
-//	Describes a validation error.
-type UpdateArtistValidationError struct {
-
-	// The JSON field which is referenced by the error message.
-	FieldRef string `json:"ref"`
-
-	// The error message.
-	ErrorMessage string `json:"error"`
-}
-
-// Description:
-//
-//	Describes a path parameter validation error.
-type UpdateArtistPathValidationError struct {
-
-	// The JSON field which is referenced by the error message.
-	PathRef string `json:"pathRef"`
-
-	// The error message.
-	ErrorMessage string `json:"error"`
-}
-
-// Description:
-//
-//	Attempts to cast the input object to the endpoint injector.
-//	If this cast fails, we cannot proceed to process this request.
-//
-// Parameters:
-//
-//	object 	The injector object.
-//
-// Returns:
-//
-//	The injector if the cast is successful, an error otherwise.
-func GetSafeInjector(object interface{}) (*inject.Injector, error) {
-	injector, ok := object.(inject.Injector)
-
-	if !ok {
-		return nil, fmt.Errorf("updateartist: failed to deduce injector")
-	}
-
-	return &injector, nil
+	Popularity float32 `json:"popularity" bson:"popularity" validate:"min=0,max=1"`
 }
 
 // Description:
 //
-//	Unmarshals the request body for this endpoint.
+//	Unmarshals and validates the request body for this endpoint.
 //
 // Parameters:
 //
@@ -111,13 +63,12 @@ func GetSafeInjector(object interface{}) (*inject.Injector, error) {
 //
 // Returns:
 //
-//	The unmarshalled request body, or an error when unmarshalling fails.
+//	The unmarshalled request body, or an error when unmarshalling or validation fails.
+//	Validation failures are reported as *api.ValidationError.
 func ExtractRequestBody(request *api.APIRequest) (*UpdateArtistRequestBody, error) {
 	body := &UpdateArtistRequestBody{}
 
-	bytes := []byte(request.Body)
-	err := json.Unmarshal(bytes, body)
-
+	err := api.Bind(request, body)
 	if err != nil {
 		return nil, err
 	}
@@ -136,107 +87,51 @@ func ExtractRequestBody(request *api.APIRequest) (*UpdateArtistRequestBody, erro
 // Returns:
 //
 //	The id path parameter.
-//	A validatior error if the id is not a valid uuid.
-func GetAndValidateID(request *api.APIRequest) (string, *UpdateArtistPathValidationError) {
+//	A violation if the id is not a valid uuid.
+func GetAndValidateID(request *api.APIRequest) (string, *api.Violation) {
 	id := request.PathParameters["id"]
 
 	_, err := uuid.Parse(id)
 	if err != nil {
-		return "", &UpdateArtistPathValidationError{
-			PathRef:      ":id",
-			ErrorMessage: "value is not a valid uuid",
+		return "", &api.Violation{
+			Parameter: "id",
+			Message:   "must be a valid uuid",
 		}
 	}
 
 	return id, nil
 }
 
-// Description:
-//
-//	Validates the request body for this endpoint.
-//
-// Parameters:
-//
-//	request The request body.
-//
-// Returns:
-//
-//	An error if the validation fails.
-func ValidateRequestBody(request *UpdateArtistRequestBody) *UpdateArtistValidationError {
-	if request.Name != "" {
-		artistName := strings.TrimSpace(request.Name)
-
-		if len(artistName) == 0 {
-			return &UpdateArtistValidationError{
-				FieldRef:     "name",
-				ErrorMessage: "value must not be empty",
-			}
-		}
-	}
-
-	if len(request.Genres) > 0 {
-		artistGenres := arrays.Map[string](request.Genres, func(genre string) string {
-			return strings.TrimSpace(genre)
-		})
-
-		for _, genre := range artistGenres {
-			if len(genre) == 0 {
-				return &UpdateArtistValidationError{
-					FieldRef:     "genres",
-					ErrorMessage: "array value must not be empty",
-				}
-			}
-		}
-
-	}
-
-	return ValidateArtistStatsRequestBody(&request.Stats)
-}
-
-// Description:
-//
-//	Validates the statistics request body for this endpoint.
-//
-// Parameters:
-//
-//	request The statistics request body.
-//
-// Returns:
-//
-//	An error if the validation fails.
-func ValidateArtistStatsRequestBody(request *UpdateArtistStatsRequestBody) *UpdateArtistValidationError {
-	return nil
-}
-
 // Description:
 //
 //	Searches an artist with the given id in the database.
 //
 // Parameters:
 //
+//	ctx 	The operation context.
 //	store 	The store to search through.
 //	id 		The id to search for.
 //
 // Returns:
 //
-//	The first matched track.
+//	The artist, or nil if the artist does not exist.
 //	An error if the query fails.
-func FindArtistByID(store *store.MongoStore[models.ArtistInfo], id string) (*models.ArtistInfo, error) {
+func FindArtistByID(ctx context.Context, store store.Store[models.ArtistInfo], id string) (*models.ArtistInfo, error) {
 	filter := query.Filter{
-		Root: query.FilterOperatorEq{
+		Root: models.NotDeleted(query.FilterOperatorEq{
 			Key:   "_id",
 			Value: id,
-		},
+		}),
 		Limit: 1,
 	}
 
-	items, err := store.FindItems(&filter)
+	items, err := store.FindItems(ctx, &filter)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(items) == 0 {
-		return nil, fmt.Errorf("update: track not found")
+		return nil, nil
 	}
 
 	return &items[0], nil
@@ -248,6 +143,7 @@ func FindArtistByID(store *store.MongoStore[models.ArtistInfo], id string) (*mod
 //
 // Parameters:
 //
+//	ctx 		The operation context.
 //	store 		The mongo store to search.
 //	artistID 	The artist id to search.
 //
@@ -255,16 +151,16 @@ func FindArtistByID(store *store.MongoStore[models.ArtistInfo], id string) (*mod
 //
 //	An error, if the artist could not be found or an error,
 //	if the database request failed, nothing if successful.
-func CheckIfArtistExists(store *store.MongoStore[models.ArtistInfo], artistID string) error {
+func CheckIfArtistExists(ctx context.Context, store store.Store[models.ArtistInfo], artistID string) error {
 	filter := query.Filter{
-		Root: query.FilterOperatorEq{
+		Root: models.NotDeleted(query.FilterOperatorEq{
 			Key:   "_id",
 			Value: artistID,
-		},
+		}),
 		Limit: 1,
 	}
 
-	items, err := store.FindItems(&filter)
+	items, err := store.FindItems(ctx, &filter)
 	if err != nil {
 		return err
 	}
@@ -276,9 +172,37 @@ func CheckIfArtistExists(store *store.MongoStore[models.ArtistInfo], artistID st
 	return nil
 }
 
+// Description:
+//
+//	Updates the artist and records the update in the audit trail atomically.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//	filter 		The filter selecting the artist.
+//	update 		The update to apply.
+//	before 		The artist before the update.
+//	after 		The artist after the update.
+//
+// Returns:
+//
+//	An error if the artist cannot be updated, see UpdateItemVersion. Nothing is recorded.
+func updateInTransaction(request *api.APIRequest, injector *inject.Injector, filter *query.Filter, update *query.Update, before *models.ArtistInfo, after *models.ArtistInfo) error {
+	return injector.Auditor.WithTransaction(request.Context, audit.OriginOf(request), func(ctx context.Context) ([]audit.Change, error) {
+		err := injector.ArtistStore.UpdateItemVersion(ctx, filter, before.Version, update)
+		if err != nil {
+			return nil, err
+		}
+
+		return []audit.Change{{Action: audit.ActionUpdate, Before: before, After: after}}, nil
+	})
+}
+
 // Description:
 //
 //	The router handler for track creation.
+//	Without 'If-Match' header, the artist is read again and the update retried if it is modified concurrently.
 //
 // Parameters:
 //
@@ -288,73 +212,113 @@ func CheckIfArtistExists(store *store.MongoStore[models.ArtistInfo], artistID st
 // Returns:
 //
 //	An API response object.
-func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
-	context := parallel.NewContext()
+func Handler(request *api.APIRequest, injector *inject.Injector) *api.APIResponse {
+	context := request.Parallel
 
 	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
 	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
 
-	injector, err := GetSafeInjector(object)
-	if err != nil {
-		log.Warnf("[%s] failed to get endpoint injector: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
-	}
-
 	id, validationErr := GetAndValidateID(request)
 	if validationErr != nil {
-		log.Warnf("[%s] failed path parameter validation: %s", context.ID, validationErr.ErrorMessage)
-		return &api.APIResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       validationErr,
+		log.Warnf("[%s] failed path parameter validation: %s", context.ID, validationErr.Message)
+		return api.NewProblem(http.StatusBadRequest, "invalid artist id").
+			WithRequest(request).
+			WithErrors(*validationErr).
+			Response()
+	}
+
+	conditional := request.Header("If-Match") != ""
+
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		response, err := attemptUpdate(request, injector, id)
+		if err == nil {
+			return response
+		}
+
+		if conditional {
+			log.Warnf("[%s] artist was modified concurrently", context.ID)
+			return api.ProblemResponse(request, http.StatusPreconditionFailed, "the resource has been modified")
 		}
+
+		log.Warnf("[%s] artist was modified concurrently, retrying", context.ID)
 	}
 
-	artistStore := store.NewMongoStore[models.ArtistInfo](injector.MongoInstance, "gostream", "artists")
+	log.Errorf("[%s] artist was modified concurrently %d times, giving up", context.ID, MaxAttempts)
+	return api.ProblemResponse(request, http.StatusServiceUnavailable, "the artist is modified too frequently, try again later")
+}
 
-	artistInfo, err := FindArtistByID(artistStore, id)
+// Description:
+//
+//	Reads the artist and replaces it, if it has not been modified in the meantime.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//	id 			The id of the artist to update.
+//
+// Returns:
+//
+//	An API response object, or store.ErrVersionMismatch if the artist was modified concurrently.
+func attemptUpdate(request *api.APIRequest, injector *inject.Injector, id string) (*api.APIResponse, error) {
+	context := request.Parallel
+	artistStore := injector.ArtistStore
+
+	artistInfo, err := FindArtistByID(request.Context, artistStore, id)
 	if err != nil {
-		log.Warnf("[%s] could not find artist: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusNotFound,
-		}
+		log.Errorf("[%s] failed to retrieve database item: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, ""), nil
+	}
+
+	if artistInfo == nil {
+		log.Warnf("[%s] could not find artist: %s", context.ID, id)
+		return api.ProblemResponse(request, http.StatusNotFound, "artist not found"), nil
+	}
+
+	problem := api.CheckIfMatch(request, api.FormatETag(artistInfo.Version), injector.RequireIfMatch)
+	if problem != nil {
+		log.Warnf("[%s] failed precondition: %s", context.ID, problem.Detail)
+		return problem.Response(), nil
 	}
 
 	requestBody, err := ExtractRequestBody(request)
 	if err != nil {
 		log.Warnf("[%s] failed to extract request body: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusBadRequest,
-			Body: UpdateArtistErrorResponseBody{
-				Message: "invalid request body",
-			},
-		}
-	}
 
-	validationError := ValidateRequestBody(requestBody)
-	if validationError != nil {
-		log.Warnf("[%s] failed request body validation: %s", context.ID, validationError.ErrorMessage)
-		return &api.APIResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       validationError,
+		problem := api.NewProblem(http.StatusBadRequest, "invalid request body").WithRequest(request)
+
+		var bindErr *api.ValidationError
+		if errors.As(err, &bindErr) {
+			problem.WithErrors(bindErr.Violations...)
 		}
-	}
 
-	if requestBody.Name != "" {
-		artistInfo.Name = requestBody.Name
+		return problem.Response(), nil
 	}
 
-	if len(requestBody.Genres) > 0 {
-		artistInfo.Genres = requestBody.Genres
+	genres := requestBody.Genres
+	if genres == nil {
+		genres = make([]string, 0)
 	}
 
-	if requestBody.Followers != 0 {
-		artistInfo.Followers = requestBody.Followers
-	}
+	headers := map[string]string{}
+	nameKey := models.NameKey(requestBody.Name)
+
+	if nameKey != artistInfo.NameKey {
+		conflict, err := duplicates.FindConflict(request.Context, artistStore, id, nameKey)
+		if err != nil {
+			log.Errorf("[%s] failed to search duplicate artists: %s", context.ID, err)
+			return api.ProblemResponse(request, http.StatusInternalServerError, ""), nil
+		}
 
-	if requestBody.Stats.Popularity != 0 {
-		artistInfo.Stats.Popularity = requestBody.Stats.Popularity
+		if conflict != nil {
+			if injector.DuplicateNamePolicy == duplicates.PolicyReject {
+				log.Warnf("[%s] artist name duplicates artist %s", context.ID, conflict.ID)
+				return duplicates.ConflictProblem(request, conflict).Response(), nil
+			}
+
+			log.Warnf("[%s] accepting artist name duplicating artist %s", context.ID, conflict.ID)
+			headers["Warning"] = duplicates.Warning(conflict)
+		}
 	}
 
 	updateFilter := query.Filter{
@@ -367,33 +331,52 @@ func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
 	updateOperator := query.Update{
 		Root: query.UpdateOperatorSet{
 			Set: map[string]interface{}{
-				"name":             artistInfo.Name,
-				"genres":           artistInfo.Genres,
-				"followers":        artistInfo.Followers,
-				"stats.popularity": artistInfo.Stats.Popularity,
+				"name":             requestBody.Name,
+				"nameKey":          nameKey,
+				"genres":           genres,
+				"followers":        requestBody.Followers,
+				"stats.popularity": requestBody.Stats.Popularity,
 			},
 		},
 	}
 
+	updated := *artistInfo
+	updated.Name = requestBody.Name
+	updated.NameKey = nameKey
+	updated.Genres = genres
+	updated.Followers = requestBody.Followers
+	updated.Stats.Popularity = requestBody.Stats.Popularity
+	updated.Version++
+
 	log.Tracef("[%s] attempting to update database item ...", context.ID)
-	count, err := artistStore.UpdateItem(&updateFilter, &updateOperator)
+	err = updateInTransaction(request, injector, &updateFilter, &updateOperator, artistInfo, &updated)
+
+	if errors.Is(err, store.ErrVersionMismatch) {
+		return nil, err
+	}
+
+	if errors.Is(err, store.ErrNotFound) {
+		log.Warnf("[%s] artist was deleted concurrently", context.ID)
+		return api.ProblemResponse(request, http.StatusNotFound, "artist not found"), nil
+	}
+
+	if errors.Is(err, store.ErrDuplicateKey) {
+		log.Warnf("[%s] artist name was taken concurrently", context.ID)
+
+		conflict, _ := duplicates.FindConflict(request.Context, artistStore, id, nameKey)
+		return duplicates.ConflictProblem(request, conflict).Response(), nil
+	}
 
 	if err != nil {
 		log.Errorf("[%s] failed to update database item: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
+		return api.ProblemResponse(request, http.StatusInternalServerError, ""), nil
 	}
 
-	if count == 0 {
-		log.Warnf("[%s] zero modified items", context.ID)
-		return &api.APIResponse{
-			StatusCode: http.StatusNoContent,
-		}
-	}
+	headers["ETag"] = api.FormatETag(updated.Version)
 
 	log.Tracef("[%s] successfully completed request", context.ID)
 	return &api.APIResponse{
 		StatusCode: http.StatusNoContent,
-	}
+		Headers:    headers,
+	}, nil
 }
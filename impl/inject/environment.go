@@ -0,0 +1,261 @@
+package inject
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gostream-official/artists/impl/audit"
+	"github.com/gostream-official/artists/impl/duplicates"
+	"github.com/gostream-official/artists/impl/models"
+	"github.com/gostream-official/artists/pkg/env"
+	"github.com/gostream-official/artists/pkg/idempotency"
+	"github.com/gostream-official/artists/pkg/paging"
+	"github.com/gostream-official/artists/pkg/store"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	Creates the injector from the configuration given via environment variables.
+//	Terminates the application if the configuration is invalid.
+//
+// Returns:
+//
+//	The created injector.
+//	The connected mongo instance, or nil if the in-memory store is used.
+func NewFromEnvironment() (*Injector, *store.MongoInstance) {
+	storeBackend := env.GetEnvironmentVariableWithFallback("STORE_BACKEND", "mongo")
+
+	var artistStore store.Store[models.ArtistInfo]
+	var redirectStore store.Store[models.ArtistRedirect]
+	var auditStore store.Store[models.ArtistAuditEntry]
+	var transactor store.Transactor
+	var idempotencyStore store.Store[idempotency.Record]
+	var mongoInstance *store.MongoInstance
+
+	switch storeBackend {
+	case "mongo":
+		mongoInstance = connectMongoInstance()
+
+		timeout := mongoOperationTimeout()
+
+		mongoStore := store.NewMongoStore[models.ArtistInfo](mongoInstance, "gostream", "artists")
+		mongoStore.Timeout = timeout
+
+		mongoRedirectStore := store.NewMongoStore[models.ArtistRedirect](mongoInstance, "gostream", "artist_redirects")
+		mongoRedirectStore.Timeout = timeout
+
+		mongoAuditStore := store.NewMongoStore[models.ArtistAuditEntry](mongoInstance, "gostream", "artist_audit")
+		mongoAuditStore.Timeout = timeout
+
+		mongoIdempotencyStore := store.NewMongoStore[idempotency.Record](mongoInstance, "gostream", "idempotency_keys")
+		mongoIdempotencyStore.Timeout = timeout
+
+		artistStore = mongoStore
+		redirectStore = mongoRedirectStore
+		auditStore = mongoAuditStore
+		idempotencyStore = mongoIdempotencyStore
+		transactor = mongoInstance
+	case "memory":
+		log.Warnf("using in-memory store, data will not be persisted")
+		instance := store.NewMemoryInstance()
+		artistStore = store.NewMemoryStore[models.ArtistInfo](instance, "gostream", "artists")
+		redirectStore = store.NewMemoryStore[models.ArtistRedirect](instance, "gostream", "artist_redirects")
+		auditStore = store.NewMemoryStore[models.ArtistAuditEntry](instance, "gostream", "artist_audit")
+		idempotencyStore = store.NewMemoryStore[idempotency.Record](instance, "gostream", "idempotency_keys")
+		transactor = instance
+	default:
+		log.Fatalf("Received invalid store backend: %s", storeBackend)
+	}
+
+	cursorSecret, err := env.GetEnvironmentVariable("CURSOR_SECRET")
+	if err != nil {
+		log.Warnf("no cursor secret configured, cursors will not survive a restart")
+		cursorSecret = generateSecret()
+	}
+
+	requireIfMatchEnvVar := env.GetEnvironmentVariableWithFallback("REQUIRE_IF_MATCH", "false")
+
+	requireIfMatch, err := strconv.ParseBool(requireIfMatchEnvVar)
+	if err != nil {
+		log.Fatalf("Received invalid If-Match requirement: %s", requireIfMatchEnvVar)
+	}
+
+	idempotencyKeyTTL := idempotencyKeyTTL()
+
+	err = idempotency.CreateIndexes(context.Background(), idempotencyStore, idempotencyKeyTTL)
+	if err != nil {
+		log.Fatalf("failed to create idempotency key indexes: %s", err)
+	}
+
+	duplicateNamePolicyEnvVar := env.GetEnvironmentVariableWithFallback("DUPLICATE_NAME_POLICY", string(duplicates.PolicyReject))
+
+	duplicateNamePolicy, err := duplicates.ParsePolicy(duplicateNamePolicyEnvVar)
+	if err != nil {
+		log.Fatalf("Received invalid duplicate name policy: %s", duplicateNamePolicyEnvVar)
+	}
+
+	duplicateNamePolicy = createArtistNameIndexes(artistStore, duplicateNamePolicy)
+
+	err = audit.CreateIndexes(context.Background(), auditStore)
+	if err != nil {
+		log.Fatalf("failed to create artist audit indexes: %s", err)
+	}
+
+	injector := &Injector{
+		ArtistStore:      artistStore,
+		RedirectStore:    redirectStore,
+		Transactor:       transactor,
+		IdempotencyStore: idempotencyStore,
+		Auditor: audit.Auditor{
+			Store:      auditStore,
+			Transactor: transactor,
+		},
+		Paginator: paging.Paginator{
+			Secret: []byte(cursorSecret),
+		},
+		RequireIfMatch:      requireIfMatch,
+		DuplicateNamePolicy: duplicateNamePolicy,
+	}
+
+	return injector, mongoInstance
+}
+
+// Description:
+//
+//	Creates the artist name indexes for the given duplicate name policy.
+//	Falls back to the warn policy and reports the stored duplicates, if duplicates cannot be rejected.
+//	Terminates the application if the indexes cannot be created.
+//
+// Parameters:
+//
+//	artistStore The artist store.
+//	policy 		The configured duplicate name policy.
+//
+// Returns:
+//
+//	The effective duplicate name policy.
+func createArtistNameIndexes(artistStore store.Store[models.ArtistInfo], policy duplicates.Policy) duplicates.Policy {
+	effectivePolicy, err := duplicates.CreateIndexes(context.Background(), artistStore, policy)
+	if err != nil {
+		log.Fatalf("failed to create artist name index: %s", err)
+	}
+
+	if effectivePolicy == policy {
+		return policy
+	}
+
+	groups, err := duplicates.FindGroups(context.Background(), artistStore)
+	if err != nil {
+		log.Errorf("failed to find duplicate artists: %s", err)
+	}
+
+	log.Errorf("cannot reject duplicate artist names, as %d groups of stored artists share a name, see GET /artists/duplicates", len(groups))
+	log.Errorf("falling back to duplicate name policy '%s' until the duplicates are merged or renamed", effectivePolicy)
+
+	return effectivePolicy
+}
+
+// Description:
+//
+//	Connects to the MongoDB instance configured via environment variables.
+//	Terminates the application if the connection cannot be established,
+//	or if the instance does not support transactions.
+//
+// Returns:
+//
+//	The connected mongo instance.
+func connectMongoInstance() *store.MongoInstance {
+	mongoUsername, err := env.GetEnvironmentVariable("MONGO_USERNAME")
+	if err != nil {
+		log.Fatalf("Cannot retrieve mongo username via environment variable")
+	}
+
+	mongoPassword, err := env.GetEnvironmentVariable("MONGO_PASSWORD")
+	if err != nil {
+		log.Fatalf("Cannot retrieve mongo password via environment variable")
+	}
+
+	mongoHost := env.GetEnvironmentVariableWithFallback("MONGO_HOST", "127.0.0.1:27017")
+
+	connectionURI := fmt.Sprintf("mongodb://%s:%s@%s", mongoUsername, mongoPassword, mongoHost)
+	instance, err := store.NewMongoInstance(connectionURI)
+
+	log.Infof("establishing database connection ...")
+	if err != nil {
+		log.Fatalf("failed to connect to mongo instance: %s", err)
+	}
+
+	transactional, err := instance.SupportsTransactions(context.Background())
+	if err != nil {
+		log.Fatalf("failed to query mongo deployment: %s", err)
+	}
+
+	if !transactional {
+		log.Fatalf("mongo instance at %s is neither a replica set nor a sharded cluster, which is required for transactions; start mongod with '--replSet' and initiate the replica set", mongoHost)
+	}
+
+	log.Infof("successfully established database connection")
+	return instance
+}
+
+// Description:
+//
+//	Reads the maximum duration of a single MongoDB operation from the environment.
+//	Terminates the application if the configured duration is invalid.
+//
+// Returns:
+//
+//	The operation timeout. Zero disables the timeout.
+func mongoOperationTimeout() time.Duration {
+	timeoutEnvVar := env.GetEnvironmentVariableWithFallback("MONGO_OPERATION_TIMEOUT", "10s")
+
+	timeout, err := time.ParseDuration(timeoutEnvVar)
+	if err != nil || timeout < 0 {
+		log.Fatalf("Received invalid mongo operation timeout: %s", timeoutEnvVar)
+	}
+
+	return timeout
+}
+
+// Description:
+//
+//	Reads the retention of idempotency keys from the environment.
+//	Terminates the application if the configured duration is invalid.
+//
+// Returns:
+//
+//	The time after which idempotency keys expire.
+func idempotencyKeyTTL() time.Duration {
+	ttlEnvVar := env.GetEnvironmentVariableWithFallback("IDEMPOTENCY_KEY_TTL", "24h")
+
+	ttl, err := time.ParseDuration(ttlEnvVar)
+	if err != nil || ttl < time.Second {
+		log.Fatalf("Received invalid idempotency key TTL: %s", ttlEnvVar)
+	}
+
+	return ttl
+}
+
+// Description:
+//
+//	Generates a random secret.
+//	Terminates the application if no randomness is available.
+//
+// Returns:
+//
+//	The generated secret.
+func generateSecret() string {
+	bytes := make([]byte, 32)
+
+	_, err := rand.Read(bytes)
+	if err != nil {
+		log.Fatalf("failed to generate secret: %s", err)
+	}
+
+	return hex.EncodeToString(bytes)
+}
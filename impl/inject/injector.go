@@ -1,6 +1,13 @@
 package inject
 
-import "github.com/gostream-official/artists/pkg/store"
+import (
+	"github.com/gostream-official/artists/impl/audit"
+	"github.com/gostream-official/artists/impl/duplicates"
+	"github.com/gostream-official/artists/impl/models"
+	"github.com/gostream-official/artists/pkg/idempotency"
+	"github.com/gostream-official/artists/pkg/paging"
+	"github.com/gostream-official/artists/pkg/store"
+)
 
 // Description:
 //
@@ -8,6 +15,27 @@ import "github.com/gostream-official/artists/pkg/store"
 //	This object is used for endpoint dependency injection.
 type Injector struct {
 
-	// The MongoDB store instance.
-	MongoInstance *store.MongoInstance
+	// The artist store.
+	ArtistStore store.Store[models.ArtistInfo]
+
+	// The artist redirect store.
+	RedirectStore store.Store[models.ArtistRedirect]
+
+	// The transactor of the artist, redirect and audit entry stores.
+	Transactor store.Transactor
+
+	// The auditor recording artist changes.
+	Auditor audit.Auditor
+
+	// The idempotency key store.
+	IdempotencyStore store.Store[idempotency.Record]
+
+	// The paginator configuration.
+	Paginator paging.Paginator
+
+	// Whether updates and deletions must carry an 'If-Match' header.
+	RequireIfMatch bool
+
+	// The policy for artist names which duplicate the name of another artist.
+	DuplicateNamePolicy duplicates.Policy
 }
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Description:
 //
 //	The data model definition for an artist.
@@ -12,6 +14,9 @@ type ArtistInfo struct {
 	// The name of the artist.
 	Name string `json:"name" bson:"name"`
 
+	// The normalized name key of the artist, see NameKey. Used for duplicate detection.
+	NameKey string `json:"-" bson:"nameKey,omitempty"`
+
 	// The genres an artist is active in.
 	Genres []string `json:"genres" bson:"genres"`
 
@@ -20,6 +25,12 @@ type ArtistInfo struct {
 
 	// Some artist statistics.
 	Stats ArtistStats `json:"stats" bson:"stats"`
+
+	// The version of the artist. Incremented by every update.
+	Version int64 `json:"version" bson:"version"`
+
+	// The time the artist was deleted. Nil, unless the artist is soft-deleted.
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
 }
 
 // Description:
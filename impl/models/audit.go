@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Description:
+//
+//	The data model definition for an artist audit entry.
+//	An audit entry is written for every change of an artist, within the same transaction as the change.
+type ArtistAuditEntry struct {
+
+	// The id of the audit entry (primary key).
+	ID string `json:"id" bson:"_id"`
+
+	// The id of the changed artist.
+	ArtistID string `json:"artistId" bson:"artistId"`
+
+	// The kind of change, e.g. 'create' or 'delete'.
+	Action string `json:"action" bson:"action"`
+
+	// The version of the artist after the change. Orders the audit entries of an artist.
+	Version int64 `json:"version" bson:"version"`
+
+	// The identity of the actor who made the change, as claimed by the client. Not verified,
+	// so it must not be relied upon for accountability or authorization.
+	ClaimedActor string `json:"claimedActor" bson:"claimedActor"`
+
+	// The correlation id of the request which made the change.
+	CorrelationID string `json:"correlationId" bson:"correlationId"`
+
+	// The time of the change.
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+
+	// The changed fields.
+	Changes []ArtistFieldChange `json:"changes" bson:"changes"`
+}
+
+// Description:
+//
+//	The change of a single artist field.
+type ArtistFieldChange struct {
+
+	// The changed field, e.g. 'stats.popularity'.
+	Field string `json:"field" bson:"field"`
+
+	// The value before the change. Nil, if the field was not set.
+	Before interface{} `json:"before,omitempty" bson:"before,omitempty"`
+
+	// The value after the change. Nil, if the field was removed.
+	After interface{} `json:"after,omitempty" bson:"after,omitempty"`
+}
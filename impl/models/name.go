@@ -0,0 +1,40 @@
+package models
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Description:
+//
+//	The article which is ignored at the beginning of artist names, after normalization.
+const nameKeyArticle = "the "
+
+// Description:
+//
+//	Derives the normalized name key of an artist name.
+//	Names are equal, if their name keys are equal, e.g. 'The Beatles', 'the  beatles ' and 'Beatles'.
+//
+//	The name is normalized to Unicode NFKC, case-folded and its whitespace collapsed.
+//	A leading 'The' is removed, unless it is the whole name.
+//
+// Parameters:
+//
+//	name The artist name.
+//
+// Returns:
+//
+//	The name key.
+func NameKey(name string) string {
+	key := norm.NFKC.String(name)
+	key = norm.NFKC.String(cases.Fold().String(key))
+	key = strings.Join(strings.Fields(key), " ")
+
+	if len(key) > len(nameKeyArticle) && strings.HasPrefix(key, nameKeyArticle) {
+		key = key[len(nameKeyArticle):]
+	}
+
+	return key
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/gostream-official/artists/pkg/store/query"
+)
+
+// Description:
+//
+//	The document schema of the artist data model.
+var ArtistSchema = query.NewSchema(ArtistInfo{})
+
+// Description:
+//
+//	The artist fields which can be sorted by, mapped to their document keys.
+var ArtistSortableFields = map[string]string{
+	"id":               "_id",
+	"name":             "name",
+	"followers":        "followers",
+	"stats.popularity": "stats.popularity",
+}
+
+// Description:
+//
+//	The artist fields which can be selected, mapped to their document keys.
+var ArtistProjectableFields = map[string]string{
+	"id":               "_id",
+	"name":             "name",
+	"genres":           "genres",
+	"followers":        "followers",
+	"stats":            "stats",
+	"stats.popularity": "stats.popularity",
+	"version":          "version",
+	"deletedAt":        "deletedAt",
+}
+
+// Description:
+//
+//	Which artists are included with respect to soft deletion.
+type DeletedMode string
+
+const (
+
+	// Includes only artists which are not soft-deleted. The default.
+	DeletedExclude DeletedMode = "exclude"
+
+	// Includes all artists.
+	DeletedInclude DeletedMode = "include"
+
+	// Includes only soft-deleted artists.
+	DeletedOnly DeletedMode = "only"
+)
+
+// Description:
+//
+//	Parses a deleted mode.
+//
+// Parameters:
+//
+//	value The mode name, either 'exclude', 'include' or 'only'.
+//
+// Returns:
+//
+//	The mode, or an error if the mode is unknown.
+func ParseDeletedMode(value string) (DeletedMode, error) {
+	switch mode := DeletedMode(value); mode {
+	case DeletedExclude, DeletedInclude, DeletedOnly:
+		return mode, nil
+	}
+
+	return "", fmt.Errorf("invalid deleted mode: %s", value)
+}
+
+// Description:
+//
+//	Restricts a filter to artists which are not soft-deleted.
+//
+// Parameters:
+//
+//	root The filter to restrict. May be nil.
+//
+// Returns:
+//
+//	The restricted filter.
+func NotDeleted(root query.IQuery) query.IQuery {
+	return RestrictDeleted(root, DeletedExclude)
+}
+
+// Description:
+//
+//	Restricts a filter according to a deleted mode.
+//
+// Parameters:
+//
+//	root The filter to restrict. May be nil.
+//	mode The deleted mode.
+//
+// Returns:
+//
+//	The restricted filter. May be nil, if all artists are included.
+func RestrictDeleted(root query.IQuery, mode DeletedMode) query.IQuery {
+	if mode == DeletedInclude {
+		return root
+	}
+
+	condition := query.FilterOperatorExists{
+		Key:    "deletedAt",
+		Exists: mode == DeletedOnly,
+	}
+
+	if root == nil {
+		return condition
+	}
+
+	return query.FilterOperatorAnd{
+		And: []query.IQuery{root, condition},
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Description:
+//
+//	The data model definition for an artist redirect.
+//	Redirects are kept for artists merged into another artist, so that their ids remain resolvable.
+type ArtistRedirect struct {
+
+	// The id of the merged artist (primary key).
+	ID string `json:"id" bson:"_id"`
+
+	// The id of the artist the merged artist was merged into.
+	TargetID string `json:"targetId" bson:"targetId"`
+
+	// The time the artist was merged.
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
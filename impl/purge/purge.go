@@ -0,0 +1,151 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gostream-official/artists/impl/audit"
+	"github.com/gostream-official/artists/impl/models"
+	"github.com/gostream-official/artists/pkg/parallel"
+	"github.com/gostream-official/artists/pkg/store"
+	"github.com/gostream-official/artists/pkg/store/query"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	The number of artists purged per query.
+const BatchSize = 100
+
+// Description:
+//
+//	Permanently deletes soft-deleted artists, once their retention has elapsed.
+type Purger struct {
+
+	// The artist store.
+	Store store.Store[models.ArtistInfo]
+
+	// The auditor recording purges.
+	Auditor audit.Auditor
+
+	// The time soft-deleted artists are kept before they are purged.
+	Retention time.Duration
+
+	// The time between two purges.
+	Interval time.Duration
+}
+
+// Description:
+//
+//	Purges expired artists periodically, until the context is cancelled.
+//	Failed purges are logged and retried on the next interval.
+//
+// Parameters:
+//
+//	ctx The operation context.
+func (purger *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(purger.Interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := purger.Purge(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Errorf("failed to purge deleted artists: %s", err)
+		}
+
+		if count > 0 {
+			log.Infof("purged %d deleted artists", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Description:
+//
+//	Permanently deletes all artists which were soft-deleted before the retention.
+//	Artists restored while being purged are kept. Every purge is recorded in the audit trail.
+//
+// Parameters:
+//
+//	ctx The operation context.
+//	now The current time.
+//
+// Returns:
+//
+//	The number of purged artists.
+//	An error if a query fails.
+func (purger *Purger) Purge(ctx context.Context, now time.Time) (int, error) {
+	cutoff := now.Add(-purger.Retention)
+	count := 0
+
+	origin := audit.Origin{
+		ClaimedActor:  audit.SystemActor,
+		CorrelationID: parallel.NewContext().ID,
+	}
+
+	filter := query.Filter{
+		Root: query.FilterOperatorLt{
+			Key:   "deletedAt",
+			Value: cutoff,
+		},
+		Limit: BatchSize,
+	}
+
+	for {
+		items, err := purger.Store.FindItems(ctx, &filter)
+		if err != nil {
+			return count, err
+		}
+
+		purged := 0
+
+		for _, item := range items {
+			err := purger.purge(ctx, origin, item)
+			if errors.Is(err, store.ErrNotFound) || errors.Is(err, store.ErrVersionMismatch) {
+				continue
+			}
+
+			if err != nil {
+				return count, err
+			}
+
+			purged++
+		}
+
+		count += purged
+
+		if len(items) < BatchSize || purged == 0 {
+			return count, nil
+		}
+	}
+}
+
+// Description:
+//
+//	Permanently deletes a single artist and records the purge in the audit trail atomically.
+//
+// Parameters:
+//
+//	ctx 	The operation context.
+//	origin 	The origin of the purge.
+//	artist 	The artist to purge.
+//
+// Returns:
+//
+//	An error if the artist cannot be deleted, see DeleteItemVersion. Nothing is recorded.
+func (purger *Purger) purge(ctx context.Context, origin audit.Origin, artist models.ArtistInfo) error {
+	return purger.Auditor.WithTransaction(ctx, origin, func(ctx context.Context) ([]audit.Change, error) {
+		err := purger.Store.DeleteItemVersion(ctx, artist.ID, artist.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		return []audit.Change{{Action: audit.ActionPurge, Before: &artist}}, nil
+	})
+}
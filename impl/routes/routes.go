@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"github.com/gostream-official/artists/impl/funcs/createartist"
+	"github.com/gostream-official/artists/impl/funcs/deleteartist"
+	"github.com/gostream-official/artists/impl/funcs/getartist"
+	"github.com/gostream-official/artists/impl/funcs/getartisthistory"
+	"github.com/gostream-official/artists/impl/funcs/getartists"
+	"github.com/gostream-official/artists/impl/funcs/getduplicates"
+	"github.com/gostream-official/artists/impl/funcs/mergeartists"
+	"github.com/gostream-official/artists/impl/funcs/patchartist"
+	"github.com/gostream-official/artists/impl/funcs/restoreartist"
+	"github.com/gostream-official/artists/impl/funcs/searchartists"
+	"github.com/gostream-official/artists/impl/funcs/updateartist"
+	"github.com/gostream-official/artists/impl/inject"
+	"github.com/gostream-official/artists/pkg/idempotency"
+	"github.com/gostream-official/artists/pkg/router"
+)
+
+// Description:
+//
+//	Registers all endpoints of this service.
+//	Shared by all entry points, so that every deployment serves the same routes.
+//
+// Parameters:
+//
+//	engine 		The router to register the endpoints with.
+//	injector 	The injector. Contains the endpoint dependencies.
+func Register(engine router.Router, injector *inject.Injector) {
+	router.HandleWith(engine, "GET", "/artists", injector, getartists.Handler)
+	router.HandleWith(engine, "GET", "/artists/duplicates", injector, getduplicates.Handler)
+	router.HandleWith(engine, "GET", "/artists/:id", injector, getartist.Handler)
+	router.HandleWith(engine, "GET", "/artists/:id/history", injector, getartisthistory.Handler)
+	router.HandleWith(engine, "POST", "/artists", injector, createartist.Handler).Use(idempotency.Middleware(injector.IdempotencyStore))
+	router.HandleWith(engine, "POST", "/artists/search", injector, searchartists.Handler)
+	router.HandleWith(engine, "PUT", "/artists/:id", injector, updateartist.Handler)
+	router.HandleWith(engine, "PATCH", "/artists/:id", injector, patchartist.Handler)
+	router.HandleWith(engine, "DELETE", "/artists/:id", injector, deleteartist.Handler)
+	router.HandleWith(engine, "POST", "/artists/:id/merge", injector, mergeartists.Handler)
+	router.HandleWith(engine, "POST", "/artists/:id/restore", injector, restoreartist.Handler)
+}
@@ -0,0 +1,1004 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Description:
+//
+//	The error returned when a request body is not a valid JSON document.
+var ErrMalformedBody = errors.New("api: malformed request body")
+
+// Description:
+//
+//	The error returned when a 'validate' struct tag of the target type is invalid.
+//	Indicates a programming error rather than an invalid request body.
+var ErrInvalidRules = errors.New("api: invalid validation rules")
+
+// Description:
+//
+//	The type of values which decode themselves from JSON.
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// Description:
+//
+//	Describes a single invalid field of a request body, or an invalid request parameter.
+type Violation struct {
+
+	// The JSON pointer (RFC 6901) referencing the invalid body field, e.g. '/stats/popularity'.
+	Pointer string `json:"pointer,omitempty"`
+
+	// The name of the invalid path or query parameter, e.g. 'id'.
+	Parameter string `json:"parameter,omitempty"`
+
+	// The error message.
+	Message string `json:"message"`
+}
+
+// Description:
+//
+//	The error returned when a request body violates the rules of its target type.
+//	Contains all violations, not only the first one.
+type ValidationError struct {
+
+	// The violations, in document order.
+	Violations []Violation
+}
+
+// Description:
+//
+//	A single validation rule, parsed from a 'validate' struct tag.
+type rule struct {
+
+	// The rule name, e.g. 'max'.
+	name string
+
+	// The rule parameter, e.g. '1' for 'max=1'. Empty, if the rule has no parameter.
+	parameter string
+
+	// The numeric value of the rule parameter. Zero, if the rule has no parameter.
+	value float64
+}
+
+// Description:
+//
+//	Returns the error message.
+//
+// Returns:
+//
+//	The error message, naming the first violation.
+func (err *ValidationError) Error() string {
+	if len(err.Violations) == 0 {
+		return "api: invalid request body"
+	}
+
+	first := err.Violations[0]
+	message := fmt.Sprintf("api: invalid request body: %s %s", first.Pointer, first.Message)
+
+	if len(err.Violations) > 1 {
+		message += fmt.Sprintf(" (and %d more)", len(err.Violations)-1)
+	}
+
+	return message
+}
+
+// Description:
+//
+//	Decodes the JSON request body into the given target and validates it.
+//	Unknown fields are rejected. Fields are validated by the rules of their 'validate' struct tag,
+//	a comma-separated list of:
+//
+//	omitempty 	Skips all other rules if the value is the zero value.
+//	required 	The value must not be the zero value.
+//	notblank 	The string must contain non-whitespace characters.
+//	minlen=N 	The string must have at least N characters, or the array at least N items.
+//	maxlen=N 	The string must have at most N characters, or the array at most N items.
+//	min=X 		The number must be greater than or equal to X.
+//	max=X 		The number must be less than or equal to X.
+//	unique 		The array items must be unique. Duplicates are reported per item.
+//	dive 		The following rules apply to every array item.
+//
+//	Nested structs are validated recursively.
+//
+// Parameters:
+//
+//	request The request to bind.
+//	target 	A pointer to the value to decode into.
+//
+// Returns:
+//
+//	An error wrapping ErrMalformedBody if the body is not valid JSON,
+//	a *ValidationError listing all violations if the body is invalid,
+//	an error wrapping ErrInvalidRules if a 'validate' struct tag is invalid, nil otherwise.
+func Bind(request *APIRequest, target interface{}) error {
+	return BindJSON([]byte(request.Body), target)
+}
+
+// Description:
+//
+//	Decodes a JSON document into the given target and validates it, like Bind does for request bodies.
+//
+// Parameters:
+//
+//	body 	The JSON document.
+//	target 	A pointer to the value to decode into.
+//
+// Returns:
+//
+//	An error wrapping ErrMalformedBody if the document is not valid JSON,
+//	a *ValidationError listing all violations if the document is invalid,
+//	an error wrapping ErrInvalidRules if a 'validate' struct tag is invalid, nil otherwise.
+func BindJSON(body []byte, target interface{}) error {
+	if !json.Valid(body) {
+		return fmt.Errorf("%w: invalid JSON", ErrMalformedBody)
+	}
+
+	violations := make([]Violation, 0)
+
+	// The decoder reports only the first unknown field or type mismatch. On failure, unknown fields
+	// are collected separately, and the body is decoded again to find type mismatches.
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(target)
+
+	if err != nil {
+		var document interface{}
+		_ = json.Unmarshal(body, &document)
+
+		violations = append(violations, unknownFields(document, reflect.TypeOf(target), "")...)
+
+		if len(violations) > 0 {
+			err = json.Unmarshal(body, target)
+		}
+	}
+
+	// The decoder also reports only the first type mismatch, so all fields are type checked separately.
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		mismatches := typeMismatches(body, reflect.TypeOf(target), "")
+
+		if len(mismatches) == 0 {
+			mismatches = append(mismatches, Violation{
+				Pointer: fieldPointer(typeErr.Field),
+				Message: fmt.Sprintf("must be %s", typeName(typeErr.Type)),
+			})
+		}
+
+		violations = append(violations, mismatches...)
+	} else if err != nil {
+		return fmt.Errorf("%w: %s", ErrMalformedBody, err)
+	}
+
+	invalid := make(map[string]bool)
+	for _, violation := range violations {
+		invalid[violation.Pointer] = true
+	}
+
+	validated, err := Validate(target)
+	if err != nil {
+		return err
+	}
+
+	for _, violation := range validated {
+		if !invalid[violation.Pointer] {
+			violations = append(violations, violation)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{
+			Violations: violations,
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Validates the given value by the rules of its 'validate' struct tags.
+//	See Bind for the supported rules.
+//
+// Parameters:
+//
+//	object The value to validate, a struct or a pointer to a struct.
+//
+// Returns:
+//
+//	All violations, in field order. Empty, if the value is valid.
+//	An error wrapping ErrInvalidRules if a 'validate' struct tag is invalid.
+func Validate(object interface{}) ([]Violation, error) {
+	err := checkRules(reflect.TypeOf(object), make(map[reflect.Type]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]Violation, 0)
+	validateStruct(reflect.ValueOf(object), "", &violations)
+
+	return violations, nil
+}
+
+// Description:
+//
+//	Checks the 'validate' struct tags of a type and all types nested in it.
+//
+// Parameters:
+//
+//	target 	The type to check.
+//	checked The struct types already checked, to stop at recursive types.
+//
+// Returns:
+//
+//	An error wrapping ErrInvalidRules naming the first invalid struct tag, nil otherwise.
+func checkRules(target reflect.Type, checked map[reflect.Type]bool) error {
+	if target == nil {
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return checkRules(target.Elem(), checked)
+
+	case reflect.Struct:
+		if checked[target] {
+			return nil
+		}
+
+		checked[target] = true
+
+		for index := 0; index < target.NumField(); index++ {
+			field := target.Field(index)
+			if !field.IsExported() {
+				continue
+			}
+
+			_, err := parseRules(field.Tag.Get("validate"))
+			if err != nil {
+				return fmt.Errorf("%w: %s.%s: %s", ErrInvalidRules, target.Name(), field.Name, err)
+			}
+
+			err = checkRules(field.Type, checked)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Validates all fields of a struct recursively.
+//
+// Parameters:
+//
+//	value 		The struct value, or a pointer to it.
+//	pointer 	The JSON pointer of the struct.
+//	violations 	The violations to append to.
+func validateStruct(value reflect.Value, pointer string, violations *[]Violation) {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return
+		}
+
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return
+	}
+
+	for index := 0; index < value.NumField(); index++ {
+		field := value.Type().Field(index)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			validateStruct(value.Field(index), pointer, violations)
+			continue
+		}
+
+		// The rules have been checked before validation, see checkRules.
+		rules, _ := parseRules(field.Tag.Get("validate"))
+		validateField(value.Field(index), pointer+"/"+escapePointer(name), rules, violations)
+	}
+}
+
+// Description:
+//
+//	Validates a single field, its array items and nested struct fields.
+//
+// Parameters:
+//
+//	value 		The field value.
+//	pointer 	The JSON pointer of the field.
+//	rules 		The rules of the field.
+//	violations 	The violations to append to.
+func validateField(value reflect.Value, pointer string, rules []rule, violations *[]Violation) {
+	var itemRules []rule
+
+	for index, rule := range rules {
+		if rule.name == "dive" {
+			itemRules = rules[index+1:]
+			rules = rules[:index]
+			break
+		}
+	}
+
+	message, skip := applyRules(value, rules)
+	if message != "" {
+		*violations = append(*violations, Violation{
+			Pointer: pointer,
+			Message: message,
+		})
+
+		return
+	}
+
+	if skip {
+		return
+	}
+
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return
+		}
+
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		validateStruct(value, pointer, violations)
+
+	case reflect.Slice, reflect.Array:
+		if hasRule(rules, "unique") {
+			*violations = append(*violations, duplicates(value, pointer)...)
+		}
+
+		for index := 0; index < value.Len(); index++ {
+			validateField(value.Index(index), pointer+"/"+strconv.Itoa(index), itemRules, violations)
+		}
+	}
+}
+
+// Description:
+//
+//	Applies the rules to a value. Rules other than 'omitempty' and 'required' apply to the pointed-to value.
+//
+// Parameters:
+//
+//	value The value to validate.
+//	rules The rules to apply.
+//
+// Returns:
+//
+//	The message of the first violated rule, or an empty string if all rules hold.
+//	Whether further validation of the value should be skipped.
+func applyRules(value reflect.Value, rules []rule) (string, bool) {
+	target := reflect.Indirect(value)
+
+	for _, rule := range rules {
+		switch rule.name {
+		case "omitempty":
+			if value.IsZero() {
+				return "", true
+			}
+
+		case "required":
+			if value.IsZero() {
+				return "is required", false
+			}
+
+		case "notblank":
+			if target.Kind() == reflect.String && strings.TrimSpace(target.String()) == "" {
+				return "must not be blank", false
+			}
+
+		case "minlen":
+			if float64(length(target)) < rule.value {
+				return fmt.Sprintf("must contain at least %s %s", rule.parameter, lengthUnit(target)), false
+			}
+
+		case "maxlen":
+			if float64(length(target)) > rule.value {
+				return fmt.Sprintf("must contain at most %s %s", rule.parameter, lengthUnit(target)), false
+			}
+
+		case "min":
+			if number(target) < rule.value {
+				return fmt.Sprintf("must be at least %s", rule.parameter), false
+			}
+
+		case "max":
+			if number(target) > rule.value {
+				return fmt.Sprintf("must be at most %s", rule.parameter), false
+			}
+
+		case "unique":
+			// Duplicates are reported per item, see validateField.
+		}
+	}
+
+	return "", false
+}
+
+// Description:
+//
+//	Parses a 'validate' struct tag.
+//
+// Parameters:
+//
+//	tag The struct tag value, e.g. 'required,max=1'.
+//
+// Returns:
+//
+//	The parsed rules.
+//	An error if a rule is unknown, or its parameter is missing or invalid.
+func parseRules(tag string) ([]rule, error) {
+	rules := make([]rule, 0)
+
+	for _, definition := range strings.Split(tag, ",") {
+		definition = strings.TrimSpace(definition)
+		if definition == "" {
+			continue
+		}
+
+		name, parameter, hasParameter := strings.Cut(definition, "=")
+
+		parsed := rule{
+			name:      name,
+			parameter: parameter,
+		}
+
+		switch name {
+		case "omitempty", "required", "notblank", "unique", "dive":
+			if hasParameter {
+				return nil, fmt.Errorf("rule '%s' takes no parameter", name)
+			}
+
+		case "minlen", "maxlen":
+			value, err := strconv.Atoi(parameter)
+			if err != nil || value < 0 {
+				return nil, fmt.Errorf("rule '%s' requires a non-negative integer parameter, got '%s'", name, parameter)
+			}
+
+			parsed.value = float64(value)
+
+		case "min", "max":
+			value, err := strconv.ParseFloat(parameter, 64)
+			if err != nil {
+				return nil, fmt.Errorf("rule '%s' requires a numeric parameter, got '%s'", name, parameter)
+			}
+
+			parsed.value = value
+
+		default:
+			return nil, fmt.Errorf("unknown rule '%s'", name)
+		}
+
+		rules = append(rules, parsed)
+	}
+
+	return rules, nil
+}
+
+// Description:
+//
+//	Returns the length of a string in characters, or the length of an array, slice or map.
+//
+// Parameters:
+//
+//	value The value to measure.
+//
+// Returns:
+//
+//	The length. Zero for other kinds.
+func length(value reflect.Value) int {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String())
+
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len()
+	}
+
+	return 0
+}
+
+// Description:
+//
+//	Returns the unit of the length of a value, for use in messages.
+//
+// Parameters:
+//
+//	value The measured value.
+//
+// Returns:
+//
+//	'characters' for strings, 'items' otherwise.
+func lengthUnit(value reflect.Value) string {
+	if value.Kind() == reflect.String {
+		return "characters"
+	}
+
+	return "items"
+}
+
+// Description:
+//
+//	Returns a numeric value as floating point number.
+//
+// Parameters:
+//
+//	value The numeric value.
+//
+// Returns:
+//
+//	The number. Zero for non-numeric kinds.
+func number(value reflect.Value) float64 {
+	switch {
+	case value.CanInt():
+		return float64(value.Int())
+
+	case value.CanUint():
+		return float64(value.Uint())
+
+	case value.CanFloat():
+		return value.Float()
+	}
+
+	return 0
+}
+
+// Description:
+//
+//	Finds the duplicate items of an array or slice.
+//	Items which are not comparable are considered unique.
+//
+// Parameters:
+//
+//	value 	The array or slice.
+//	pointer The JSON pointer of the array.
+//
+// Returns:
+//
+//	A violation for every item which equals a preceding item.
+func duplicates(value reflect.Value, pointer string) []Violation {
+	violations := make([]Violation, 0)
+	seen := make(map[interface{}]int)
+
+	for index := 0; index < value.Len(); index++ {
+		item := value.Index(index)
+		if !item.Comparable() {
+			return violations
+		}
+
+		first, ok := seen[item.Interface()]
+		if ok {
+			violations = append(violations, Violation{
+				Pointer: pointer + "/" + strconv.Itoa(index),
+				Message: fmt.Sprintf("must be unique, but equals %s/%d", pointer, first),
+			})
+
+			continue
+		}
+
+		seen[item.Interface()] = index
+	}
+
+	return violations
+}
+
+// Description:
+//
+//	Checks whether a rule is contained in the given rules.
+//
+// Parameters:
+//
+//	rules 	The rules to search.
+//	name 	The rule name.
+//
+// Returns:
+//
+//	Whether the rule is contained.
+func hasRule(rules []rule, name string) bool {
+	for _, rule := range rules {
+		if rule.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Finds all fields of a JSON document which do not exist in the target type.
+//	Field names are matched case-insensitively, like encoding/json does.
+//
+// Parameters:
+//
+//	document 	The generically decoded JSON document.
+//	target 		The target type.
+//	pointer 	The JSON pointer of the document.
+//
+// Returns:
+//
+//	A violation for every unknown field, sorted by field name per object.
+func unknownFields(document interface{}, target reflect.Type, pointer string) []Violation {
+	violations := make([]Violation, 0)
+
+	for target.Kind() == reflect.Pointer {
+		target = target.Elem()
+	}
+
+	switch target.Kind() {
+	case reflect.Struct:
+		object, ok := document.(map[string]interface{})
+		if !ok {
+			return violations
+		}
+
+		fields := jsonFields(target)
+
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fieldPointer := pointer + "/" + escapePointer(key)
+
+			fieldType, ok := lookupField(fields, key)
+			if !ok {
+				violations = append(violations, Violation{
+					Pointer: fieldPointer,
+					Message: "is not a known field",
+				})
+
+				continue
+			}
+
+			violations = append(violations, unknownFields(object[key], fieldType, fieldPointer)...)
+		}
+
+	case reflect.Slice, reflect.Array:
+		items, ok := document.([]interface{})
+		if !ok {
+			return violations
+		}
+
+		for index, item := range items {
+			violations = append(violations, unknownFields(item, target.Elem(), pointer+"/"+strconv.Itoa(index))...)
+		}
+	}
+
+	return violations
+}
+
+// Description:
+//
+//	Collects a violation for every value of a JSON document which does not match the type of its target.
+//	Unknown fields are skipped, see unknownFields. Types which decode themselves are checked as a whole.
+//
+// Parameters:
+//
+//	document 	The JSON document.
+//	target 		The target type.
+//	pointer 	The JSON pointer of the document.
+//
+// Returns:
+//
+//	A violation for every type mismatch, sorted by field name per object.
+func typeMismatches(document json.RawMessage, target reflect.Type, pointer string) []Violation {
+	violations := make([]Violation, 0)
+
+	for target.Kind() == reflect.Pointer {
+		target = target.Elem()
+	}
+
+	if bytes.Equal(document, []byte("null")) {
+		return violations
+	}
+
+	mismatch := Violation{
+		Pointer: pointer,
+		Message: fmt.Sprintf("must be %s", typeName(target)),
+	}
+
+	if reflect.PointerTo(target).Implements(unmarshalerType) {
+		return append(violations, leafMismatches(document, target, mismatch)...)
+	}
+
+	switch target.Kind() {
+	case reflect.Struct:
+		object := make(map[string]json.RawMessage)
+
+		err := json.Unmarshal(document, &object)
+		if err != nil {
+			return append(violations, mismatch)
+		}
+
+		fields := jsonFields(target)
+
+		for _, key := range sortedKeys(object) {
+			fieldType, ok := lookupField(fields, key)
+			if ok {
+				violations = append(violations, typeMismatches(object[key], fieldType, pointer+"/"+escapePointer(key))...)
+			}
+		}
+
+		return violations
+
+	case reflect.Map:
+		object := make(map[string]json.RawMessage)
+
+		err := json.Unmarshal(document, &object)
+		if err != nil {
+			return append(violations, mismatch)
+		}
+
+		for _, key := range sortedKeys(object) {
+			violations = append(violations, typeMismatches(object[key], target.Elem(), pointer+"/"+escapePointer(key))...)
+		}
+
+		return violations
+
+	case reflect.Slice, reflect.Array:
+		if target.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+
+		items := make([]json.RawMessage, 0)
+
+		err := json.Unmarshal(document, &items)
+		if err != nil {
+			return append(violations, mismatch)
+		}
+
+		for index, item := range items {
+			violations = append(violations, typeMismatches(item, target.Elem(), pointer+"/"+strconv.Itoa(index))...)
+		}
+
+		return violations
+	}
+
+	return append(violations, leafMismatches(document, target, mismatch)...)
+}
+
+// Description:
+//
+//	Checks whether a JSON value, which is not checked field by field, matches its target type.
+//
+// Parameters:
+//
+//	document 	The JSON value.
+//	target 		The target type.
+//	mismatch 	The violation to report on a type mismatch.
+//
+// Returns:
+//
+//	The violation on a type mismatch, nothing otherwise.
+func leafMismatches(document json.RawMessage, target reflect.Type, mismatch Violation) []Violation {
+	err := json.Unmarshal(document, reflect.New(target).Interface())
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []Violation{mismatch}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Returns the keys of a JSON object in sorted order.
+//
+// Parameters:
+//
+//	object The JSON object.
+//
+// Returns:
+//
+//	The sorted keys.
+func sortedKeys(object map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Description:
+//
+//	Collects the JSON field names of a struct type, including fields of embedded structs.
+//
+// Parameters:
+//
+//	target The struct type.
+//
+// Returns:
+//
+//	The field types, indexed by JSON field name.
+func jsonFields(target reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	for index := 0; index < target.NumField(); index++ {
+		field := target.Field(index)
+
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				for embeddedName, embeddedType := range jsonFields(embedded) {
+					fields[embeddedName] = embeddedType
+				}
+
+				continue
+			}
+		}
+
+		if field.IsExported() {
+			fields[name] = field.Type
+		}
+	}
+
+	return fields
+}
+
+// Description:
+//
+//	Looks up a JSON field name, preferring an exact match over a case-insensitive match.
+//
+// Parameters:
+//
+//	fields 	The field types, indexed by JSON field name.
+//	key 	The field name to look up.
+//
+// Returns:
+//
+//	The field type and whether the field exists.
+func lookupField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	fieldType, ok := fields[key]
+	if ok {
+		return fieldType, true
+	}
+
+	for name, fieldType := range fields {
+		if strings.EqualFold(name, key) {
+			return fieldType, true
+		}
+	}
+
+	return nil, false
+}
+
+// Description:
+//
+//	Returns the JSON field name of a struct field.
+//
+// Parameters:
+//
+//	field The struct field.
+//
+// Returns:
+//
+//	The JSON field name, and false if the field is excluded from JSON.
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+
+	return name, true
+}
+
+// Description:
+//
+//	Converts a dotted field path, as reported by encoding/json, into a JSON pointer.
+//
+// Parameters:
+//
+//	field The dotted field path, e.g. 'stats.popularity'.
+//
+// Returns:
+//
+//	The JSON pointer, e.g. '/stats/popularity'.
+func fieldPointer(field string) string {
+	if field == "" {
+		return ""
+	}
+
+	segments := strings.Split(field, ".")
+	for index, segment := range segments {
+		segments[index] = escapePointer(segment)
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// Description:
+//
+//	Escapes a JSON pointer segment.
+//
+// Parameters:
+//
+//	segment The segment to escape.
+//
+// Returns:
+//
+//	The escaped segment.
+func escapePointer(segment string) string {
+	segment = strings.ReplaceAll(segment, "~", "~0")
+	return strings.ReplaceAll(segment, "/", "~1")
+}
+
+// Description:
+//
+//	Describes the JSON type expected for a Go type, for use in messages.
+//
+// Parameters:
+//
+//	target The Go type.
+//
+// Returns:
+//
+//	The JSON type description, e.g. 'a number'.
+func typeName(target reflect.Type) string {
+	switch target.Kind() {
+	case reflect.String:
+		return "a string"
+
+	case reflect.Bool:
+		return "a boolean"
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a non-negative integer"
+
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+
+	return "an object"
+}
@@ -0,0 +1,378 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Description:
+//
+//	The statistics of a bind test artist.
+type bindTestStats struct {
+
+	// A factor between 0 and 1.
+	Popularity float32 `json:"popularity" validate:"min=0,max=1"`
+
+	// An optional rank.
+	Rank *int `json:"rank" validate:"omitempty,min=1"`
+}
+
+// Description:
+//
+//	A release of a bind test artist.
+type bindTestRelease struct {
+
+	// The release title.
+	Title string `json:"title" validate:"required,notblank"`
+}
+
+// Description:
+//
+//	The target type of the bind tests.
+type bindTestArtist struct {
+
+	// A required, bounded name.
+	Name string `json:"name" validate:"required,notblank,minlen=2,maxlen=8"`
+
+	// Unique genres, each bounded.
+	Genres []string `json:"genres" validate:"maxlen=3,unique,dive,notblank,maxlen=5"`
+
+	// A plain number.
+	Followers uint32 `json:"followers"`
+
+	// A nested struct.
+	Stats bindTestStats `json:"stats"`
+
+	// An array of nested structs.
+	Releases []bindTestRelease `json:"releases,omitempty"`
+
+	// A field with an escaped JSON name.
+	Label string `json:"a/b" validate:"maxlen=3"`
+}
+
+// Description:
+//
+//	Binds a JSON document to a new bind test artist.
+//
+// Parameters:
+//
+//	t 		The test context.
+//	body 	The JSON document.
+//
+// Returns:
+//
+//	The violations. Fails the test on other errors.
+func bindViolations(t *testing.T, body string) []Violation {
+	t.Helper()
+
+	err := BindJSON([]byte(body), &bindTestArtist{})
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got '%s'", err)
+	}
+
+	return validationErr.Violations
+}
+
+// Description:
+//
+//	Tests that valid documents are decoded without violations.
+//
+// Parameters:
+//
+//	t The test context.
+func TestBindJSONValid(t *testing.T) {
+	body := `{"name": "Daft", "genres": ["house", "disco"], "followers": 7, "stats": {"popularity": 1, "rank": 3}, "releases": [{"title": "Homework"}], "a/b": "abc"}`
+
+	target := &bindTestArtist{}
+
+	err := BindJSON([]byte(body), target)
+	if err != nil {
+		t.Fatalf("expected no error, got '%s'", err)
+	}
+
+	rank := 3
+	expected := &bindTestArtist{
+		Name:      "Daft",
+		Genres:    []string{"house", "disco"},
+		Followers: 7,
+		Stats:     bindTestStats{Popularity: 1, Rank: &rank},
+		Releases:  []bindTestRelease{{Title: "Homework"}},
+		Label:     "abc",
+	}
+
+	if !reflect.DeepEqual(target, expected) {
+		t.Errorf("expected %+v, got %+v", expected, target)
+	}
+}
+
+// Description:
+//
+//	Tests the violations reported for invalid documents.
+//
+// Parameters:
+//
+//	t The test context.
+func TestBindJSONViolations(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []Violation
+	}{
+		{
+			name:     "required",
+			body:     `{}`,
+			expected: []Violation{{Pointer: "/name", Message: "is required"}},
+		},
+		{
+			name:     "blank",
+			body:     `{"name": "   "}`,
+			expected: []Violation{{Pointer: "/name", Message: "must not be blank"}},
+		},
+		{
+			name:     "string too short",
+			body:     `{"name": "D"}`,
+			expected: []Violation{{Pointer: "/name", Message: "must contain at least 2 characters"}},
+		},
+		{
+			name:     "string too long counts characters",
+			body:     `{"name": "ÄÖÜäöüßéè"}`,
+			expected: []Violation{{Pointer: "/name", Message: "must contain at most 8 characters"}},
+		},
+		{
+			name:     "string length in bounds counts characters",
+			body:     `{"name": "ÄÖÜäöüßé"}`,
+			expected: nil,
+		},
+		{
+			name:     "array too long",
+			body:     `{"name": "Daft", "genres": ["a", "b", "c", "d"]}`,
+			expected: []Violation{{Pointer: "/genres", Message: "must contain at most 3 items"}},
+		},
+		{
+			name: "number out of bounds",
+			body: `{"name": "Daft", "stats": {"popularity": 1.5, "rank": 0}}`,
+			expected: []Violation{
+				{Pointer: "/stats/popularity", Message: "must be at most 1"},
+				{Pointer: "/stats/rank", Message: "must be at least 1"},
+			},
+		},
+		{
+			name:     "number below minimum",
+			body:     `{"name": "Daft", "stats": {"popularity": -0.1}}`,
+			expected: []Violation{{Pointer: "/stats/popularity", Message: "must be at least 0"}},
+		},
+		{
+			name: "dive applies to every item",
+			body: `{"name": "Daft", "genres": ["house", " ", "electronic"]}`,
+			expected: []Violation{
+				{Pointer: "/genres/1", Message: "must not be blank"},
+				{Pointer: "/genres/2", Message: "must contain at most 5 characters"},
+			},
+		},
+		{
+			name: "unique reports every duplicate",
+			body: `{"name": "Daft", "genres": ["house", "disco", "house"]}`,
+			expected: []Violation{
+				{Pointer: "/genres/2", Message: "must be unique, but equals /genres/0"},
+			},
+		},
+		{
+			name:     "nested array of structs",
+			body:     `{"name": "Daft", "releases": [{"title": "Homework"}, {"title": ""}]}`,
+			expected: []Violation{{Pointer: "/releases/1/title", Message: "is required"}},
+		},
+		{
+			name:     "escaped field name",
+			body:     `{"name": "Daft", "a/b": "abcd"}`,
+			expected: []Violation{{Pointer: "/a~1b", Message: "must contain at most 3 characters"}},
+		},
+		{
+			name: "unknown fields",
+			body: `{"name": "Daft", "label": "Virgin", "stats": {"rank": 1, "views": 3}, "releases": [{"title": "Homework", "year": 1997}]}`,
+			expected: []Violation{
+				{Pointer: "/label", Message: "is not a known field"},
+				{Pointer: "/releases/0/year", Message: "is not a known field"},
+				{Pointer: "/stats/views", Message: "is not a known field"},
+			},
+		},
+		{
+			name: "type mismatches",
+			body: `{"name": 1, "genres": "house", "followers": -1, "stats": {"popularity": "high"}, "releases": [{"title": true}]}`,
+			expected: []Violation{
+				{Pointer: "/followers", Message: "must be a non-negative integer"},
+				{Pointer: "/genres", Message: "must be an array"},
+				{Pointer: "/name", Message: "must be a string"},
+				{Pointer: "/releases/0/title", Message: "must be a string"},
+				{Pointer: "/stats/popularity", Message: "must be a number"},
+			},
+		},
+		{
+			name:     "type mismatch of array item",
+			body:     `{"name": "Daft", "genres": ["house", 7]}`,
+			expected: []Violation{{Pointer: "/genres/1", Message: "must be a string"}},
+		},
+		{
+			name:     "type mismatch of nested struct",
+			body:     `{"name": "Daft", "stats": [1]}`,
+			expected: []Violation{{Pointer: "/stats", Message: "must be an object"}},
+		},
+		{
+			name: "all violations are collected",
+			body: `{"name": "", "genres": ["house", "house"], "followers": "many", "stats": {"popularity": 2}, "label": "Virgin"}`,
+			expected: []Violation{
+				{Pointer: "/label", Message: "is not a known field"},
+				{Pointer: "/followers", Message: "must be a non-negative integer"},
+				{Pointer: "/name", Message: "is required"},
+				{Pointer: "/genres/1", Message: "must be unique, but equals /genres/0"},
+				{Pointer: "/stats/popularity", Message: "must be at most 1"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := bindViolations(t, test.body)
+
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, actual)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests that documents which are not valid JSON are reported as malformed.
+//
+// Parameters:
+//
+//	t The test context.
+func TestBindJSONMalformed(t *testing.T) {
+	bodies := []string{
+		``,
+		`{"name": "Daft"`,
+		`{"name": "Daft"} {}`,
+		`null`,
+	}
+
+	for _, body := range bodies {
+		t.Run(body, func(t *testing.T) {
+			err := BindJSON([]byte(body), &bindTestArtist{})
+
+			if body == `null` {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Errorf("expected a validation error, got '%v'", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, ErrMalformedBody) {
+				t.Errorf("expected ErrMalformedBody, got '%v'", err)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests that invalid 'validate' struct tags are reported as errors instead of panicking.
+//
+// Parameters:
+//
+//	t The test context.
+func TestBindJSONInvalidRules(t *testing.T) {
+	type unknownRule struct {
+		Name string `json:"name" validate:"required,uppercase"`
+	}
+
+	type invalidInteger struct {
+		Name string `json:"name" validate:"maxlen=ten"`
+	}
+
+	type negativeInteger struct {
+		Name string `json:"name" validate:"minlen=-1"`
+	}
+
+	type invalidNumber struct {
+		Popularity float32 `json:"popularity" validate:"max="`
+	}
+
+	type unexpectedParameter struct {
+		Name string `json:"name" validate:"required=true"`
+	}
+
+	type nestedItem struct {
+		Title string `json:"title" validate:"dive,unknown"`
+	}
+
+	type nestedRule struct {
+		Items []*nestedItem `json:"items"`
+	}
+
+	tests := []struct {
+		name    string
+		target  interface{}
+		message string
+	}{
+		{"unknown rule", &unknownRule{}, "unknownRule.Name: unknown rule 'uppercase'"},
+		{"invalid integer", &invalidInteger{}, "rule 'maxlen' requires a non-negative integer parameter, got 'ten'"},
+		{"negative integer", &negativeInteger{}, "rule 'minlen' requires a non-negative integer parameter, got '-1'"},
+		{"invalid number", &invalidNumber{}, "rule 'max' requires a numeric parameter, got ''"},
+		{"unexpected parameter", &unexpectedParameter{}, "rule 'required' takes no parameter"},
+		{"nested rule", &nestedRule{}, "nestedItem.Title: unknown rule 'unknown'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := BindJSON([]byte(`{}`), test.target)
+
+			if !errors.Is(err, ErrInvalidRules) {
+				t.Fatalf("expected ErrInvalidRules, got '%v'", err)
+			}
+
+			if !strings.Contains(err.Error(), test.message) {
+				t.Errorf("expected error containing '%s', got '%s'", test.message, err)
+			}
+
+			_, err = Validate(test.target)
+			if !errors.Is(err, ErrInvalidRules) {
+				t.Errorf("expected ErrInvalidRules from Validate, got '%v'", err)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests the error message of validation errors.
+//
+// Parameters:
+//
+//	t The test context.
+func TestValidationErrorMessage(t *testing.T) {
+	tests := []struct {
+		violations []Violation
+		expected   string
+	}{
+		{nil, "api: invalid request body"},
+		{[]Violation{{Pointer: "/name", Message: "is required"}}, "api: invalid request body: /name is required"},
+		{[]Violation{{Pointer: "/name", Message: "is required"}, {Pointer: "/genres/1", Message: "must not be blank"}}, "api: invalid request body: /name is required (and 1 more)"},
+	}
+
+	for _, test := range tests {
+		err := &ValidationError{Violations: test.violations}
+
+		if err.Error() != test.expected {
+			t.Errorf("expected '%s', got '%s'", test.expected, err.Error())
+		}
+	}
+}
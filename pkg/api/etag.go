@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Description:
+//
+//	Formats a version as strong entity tag, e.g. '"3"'.
+//
+// Parameters:
+//
+//	version The version.
+//
+// Returns:
+//
+//	The entity tag.
+func FormatETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// Description:
+//
+//	Checks whether an 'If-Match' header matches the given entity tag.
+//	Uses the strong comparison, so weak entity tags never match. '*' matches any entity tag.
+//
+// Parameters:
+//
+//	header 	The 'If-Match' header value, a comma-separated list of entity tags.
+//	etag 	The current entity tag.
+//
+// Returns:
+//
+//	Whether any of the listed entity tags matches.
+func MatchETag(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Evaluates the 'If-Match' precondition of a request.
+//
+// Parameters:
+//
+//	request 	The request.
+//	etag 		The entity tag of the current representation, or an empty string if there is none.
+//	required 	Whether requests without 'If-Match' header are rejected.
+//
+// Returns:
+//
+//	A precondition required problem if the header is required but missing,
+//	a precondition failed problem if the header does not match, nil otherwise.
+func CheckIfMatch(request *APIRequest, etag string, required bool) *Problem {
+	header := request.Header("If-Match")
+
+	if header == "" {
+		if required {
+			return NewProblem(http.StatusPreconditionRequired, "the If-Match header is required").WithRequest(request)
+		}
+
+		return nil
+	}
+
+	if etag == "" || !MatchETag(header, etag) {
+		return NewProblem(http.StatusPreconditionFailed, "the resource has been modified").WithRequest(request)
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// Description:
+//
+//	The content type of problem details responses.
+const ProblemContentType = "application/problem+json"
+
+// Description:
+//
+//	The problem type of problems, which are sufficiently described by their status code.
+const ProblemTypeBlank = "about:blank"
+
+// Description:
+//
+//	A problem details object (RFC 7807).
+//	The single error response body of all endpoints.
+type Problem struct {
+
+	// A URI reference identifying the problem type.
+	Type string `json:"type"`
+
+	// A short summary of the problem type.
+	Title string `json:"title"`
+
+	// The HTTP status code.
+	Status int `json:"status"`
+
+	// An explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// A URI reference identifying this occurrence of the problem, i.e. the request path.
+	Instance string `json:"instance,omitempty"`
+
+	// The parallel context id of the failed request. Correlates the problem with the logs.
+	CorrelationID string `json:"correlationId,omitempty"`
+
+	// The invalid request fields and parameters.
+	Errors []Violation `json:"errors,omitempty"`
+
+	// Further extension members, specific to the problem type.
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// Description:
+//
+//	Creates a new problem of the blank problem type.
+//	The title is derived from the status code.
+//
+// Parameters:
+//
+//	status 	The HTTP status code.
+//	detail 	An explanation specific to this occurrence. May be empty.
+//
+// Returns:
+//
+//	The created problem.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   ProblemTypeBlank,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Description:
+//
+//	Creates a problem response for the given request.
+//	A shorthand for NewProblem(status, detail).WithRequest(request).Response().
+//
+// Parameters:
+//
+//	request The failed request.
+//	status 	The HTTP status code.
+//	detail 	An explanation specific to this occurrence. May be empty.
+//
+// Returns:
+//
+//	The problem response.
+func ProblemResponse(request *APIRequest, status int, detail string) *APIResponse {
+	return NewProblem(status, detail).WithRequest(request).Response()
+}
+
+// Description:
+//
+//	Sets the instance and the correlation id of the problem from the failed request.
+//
+// Parameters:
+//
+//	request The failed request.
+//
+// Returns:
+//
+//	The problem, for chaining.
+func (problem *Problem) WithRequest(request *APIRequest) *Problem {
+	problem.Instance = request.Path
+
+	if request.Parallel != nil {
+		problem.CorrelationID = request.Parallel.ID
+	}
+
+	return problem
+}
+
+// Description:
+//
+//	Adds invalid request fields and parameters to the problem.
+//
+// Parameters:
+//
+//	violations The violations to add.
+//
+// Returns:
+//
+//	The problem, for chaining.
+func (problem *Problem) WithErrors(violations ...Violation) *Problem {
+	problem.Errors = append(problem.Errors, violations...)
+	return problem
+}
+
+// Description:
+//
+//	Adds an extension member to the problem.
+//	Extension members cannot override the standard members.
+//
+// Parameters:
+//
+//	key 	The member name.
+//	value 	The member value.
+//
+// Returns:
+//
+//	The problem, for chaining.
+func (problem *Problem) With(key string, value interface{}) *Problem {
+	if problem.Extensions == nil {
+		problem.Extensions = make(map[string]interface{})
+	}
+
+	problem.Extensions[key] = value
+	return problem
+}
+
+// Description:
+//
+//	Creates the response carrying the problem.
+//
+// Returns:
+//
+//	The problem response, served as 'application/problem+json'.
+func (problem *Problem) Response() *APIResponse {
+	return &APIResponse{
+		StatusCode: problem.Status,
+		Headers: map[string]string{
+			"Content-Type": ProblemContentType,
+		},
+		Body: problem,
+	}
+}
+
+// Description:
+//
+//	Marshals the problem to JSON, including its extension members.
+//	Extension members follow the standard members, sorted by name.
+//
+// Returns:
+//
+//	The JSON encoded problem, or an error if an extension member cannot be encoded.
+func (problem Problem) MarshalJSON() ([]byte, error) {
+	type plain Problem
+
+	standard, err := json.Marshal(plain(problem))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(problem.Extensions) == 0 {
+		return standard, nil
+	}
+
+	standardMembers := make(map[string]json.RawMessage)
+
+	err = json.Unmarshal(standard, &standardMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(problem.Extensions))
+	for key := range problem.Extensions {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	result := bytes.NewBuffer(standard[:len(standard)-1])
+
+	for _, key := range keys {
+		if _, ok := standardMembers[key]; ok {
+			continue
+		}
+
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		encodedValue, err := json.Marshal(problem.Extensions[key])
+		if err != nil {
+			return nil, err
+		}
+
+		result.WriteByte(',')
+		result.Write(encodedKey)
+		result.WriteByte(':')
+		result.Write(encodedValue)
+	}
+
+	result.WriteByte('}')
+	return result.Bytes(), nil
+}
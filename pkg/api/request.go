@@ -1,5 +1,14 @@
 package api
 
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gostream-official/artists/pkg/parallel"
+)
+
 // Description:
 //
 //	The representation of a HTTP request.
@@ -16,14 +25,190 @@ type APIRequest struct {
 	Method string
 
 	// The request headers
+	// Values of repeated headers are comma-separated.
 	Headers map[string]string `json:"headers"`
 
+	// All values of the request headers, keyed by canonical header name.
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+
 	// A key-value mapping of path parameters.
 	PathParameters map[string]string `json:"pathParameters"`
 
 	// A key-value mapping of query parameters.
+	// Values of repeated parameters are comma-separated.
 	QueryParameters map[string]string `json:"queryParameters"`
 
+	// All values of the query parameters, in request order.
+	MultiValueQueryParameters map[string][]string `json:"multiValueQueryParameters"`
+
 	// The request body.
 	Body string `json:"body"`
+
+	// The request context.
+	// Cancelled when the client disconnects or the server shuts down.
+	Context context.Context `json:"-"`
+
+	// The parallel context of the request. Identifies the request in logs.
+	Parallel *parallel.Context `json:"-"`
+}
+
+// Description:
+//
+//	Returns the first value of a request header.
+//	Header names are case-insensitive.
+//
+// Parameters:
+//
+//	name The header name.
+//
+// Returns:
+//
+//	The first header value, or an empty string if the header is not present.
+func (request *APIRequest) Header(name string) string {
+	values := request.HeaderValues(name)
+
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// Description:
+//
+//	Returns all values of a request header.
+//	Header names are case-insensitive.
+//
+// Parameters:
+//
+//	name The header name.
+//
+// Returns:
+//
+//	The header values, or nil if the header is not present.
+func (request *APIRequest) HeaderValues(name string) []string {
+	values, ok := request.MultiValueHeaders[http.CanonicalHeaderKey(name)]
+	if ok {
+		return values
+	}
+
+	for key, values := range request.MultiValueHeaders {
+		if strings.EqualFold(key, name) {
+			return values
+		}
+	}
+
+	for key, value := range request.Headers {
+		if strings.EqualFold(key, name) {
+			return []string{value}
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Returns the first value of a query parameter.
+//
+// Parameters:
+//
+//	name The query parameter name.
+//
+// Returns:
+//
+//	The first query parameter value and whether the query parameter is present.
+func (request *APIRequest) QueryParameter(name string) (string, bool) {
+	values := request.QueryParameterValues(name)
+
+	if len(values) == 0 {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+// Description:
+//
+//	Returns all values of a query parameter, in request order.
+//
+// Parameters:
+//
+//	name The query parameter name.
+//
+// Returns:
+//
+//	The query parameter values, or nil if the query parameter is not present.
+func (request *APIRequest) QueryParameterValues(name string) []string {
+	values, ok := request.MultiValueQueryParameters[name]
+	if ok {
+		return values
+	}
+
+	value, ok := request.QueryParameters[name]
+	if ok {
+		return []string{value}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Parses a raw query string.
+//	Only '&' separates parameters, so that values may contain unescaped semicolons,
+//	as used by filter expressions. Parameters which cannot be unescaped are skipped.
+//
+// Parameters:
+//
+//	rawQuery The raw query string, without leading '?'.
+//
+// Returns:
+//
+//	All values of the query parameters, in request order.
+func ParseQuery(rawQuery string) map[string][]string {
+	query := make(map[string][]string)
+
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+
+		rawKey, rawValue, _ := strings.Cut(pair, "=")
+
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			continue
+		}
+
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			continue
+		}
+
+		query[key] = append(query[key], value)
+	}
+
+	return query
+}
+
+// Description:
+//
+//	Joins multi-valued parameters into comma-separated values.
+//
+// Parameters:
+//
+//	values The multi-valued parameters.
+//
+// Returns:
+//
+//	The comma-separated parameters.
+func JoinValues(values map[string][]string) map[string]string {
+	result := make(map[string]string)
+
+	for key, keyValues := range values {
+		result[key] = strings.Join(keyValues, ",")
+	}
+
+	return result
 }
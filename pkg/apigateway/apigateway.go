@@ -0,0 +1,281 @@
+package apigateway
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/router"
+)
+
+// Description:
+//
+//	Handles a single API Gateway proxy event.
+//	The event is dispatched to the handler registered for its method and path.
+//
+// Parameters:
+//
+//	ctx 	The invocation context.
+//	engine 	The lambda router to dispatch the event to.
+//	event 	The JSON encoded proxy request event, in payload format version 1.0 or 2.0.
+//
+// Returns:
+//
+//	The JSON encoded proxy response, or an error if the event or the response is invalid.
+func Handle(ctx context.Context, engine *router.LambdaRouter, event []byte) ([]byte, error) {
+	request, err := DecodeRequest(event)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Context = ctx
+	response := engine.Dispatch(request)
+
+	return EncodeResponse(response)
+}
+
+// Description:
+//
+//	Decodes an API Gateway proxy request event into a router request.
+//	The payload format version is detected from the event.
+//	Path parameters are not taken from the event, but extracted by the router.
+//
+// Parameters:
+//
+//	event The JSON encoded proxy request event.
+//
+// Returns:
+//
+//	The router request, or an error if the event is invalid.
+func DecodeRequest(event []byte) (*api.APIRequest, error) {
+	probe := struct {
+		Version string `json:"version"`
+	}{}
+
+	err := json.Unmarshal(event, &probe)
+	if err != nil {
+		return nil, fmt.Errorf("apigateway: invalid event: %w", err)
+	}
+
+	if probe.Version == "2.0" {
+		request := ProxyRequestV2{}
+
+		err = json.Unmarshal(event, &request)
+		if err != nil {
+			return nil, fmt.Errorf("apigateway: invalid event: %w", err)
+		}
+
+		return FromRequestV2(&request)
+	}
+
+	request := ProxyRequestV1{}
+
+	err = json.Unmarshal(event, &request)
+	if err != nil {
+		return nil, fmt.Errorf("apigateway: invalid event: %w", err)
+	}
+
+	return FromRequestV1(&request)
+}
+
+// Description:
+//
+//	Converts an API Gateway REST API proxy request event into a router request.
+//	Values of repeated headers and query parameters are comma-separated.
+//
+// Parameters:
+//
+//	event The proxy request event.
+//
+// Returns:
+//
+//	The router request, or an error if the request body cannot be decoded.
+func FromRequestV1(event *ProxyRequestV1) (*api.APIRequest, error) {
+	headers := make(map[string][]string)
+	for key, value := range event.Headers {
+		headers[key] = []string{value}
+	}
+
+	for key, values := range event.MultiValueHeaders {
+		headers[key] = values
+	}
+
+	query := make(url.Values)
+	for key, value := range event.QueryStringParameters {
+		query[key] = []string{value}
+	}
+
+	for key, values := range event.MultiValueQueryStringParameters {
+		query[key] = values
+	}
+
+	body, err := decodeBody(event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	request := newRequest(event.HTTPMethod, event.Path, query.Encode(), body)
+
+	for key, values := range headers {
+		request.Headers[http.CanonicalHeaderKey(key)] = strings.Join(values, ",")
+		request.MultiValueHeaders[http.CanonicalHeaderKey(key)] = values
+	}
+
+	for key, values := range query {
+		request.QueryParameters[key] = strings.Join(values, ",")
+		request.MultiValueQueryParameters[key] = values
+	}
+
+	return request, nil
+}
+
+// Description:
+//
+//	Converts an API Gateway HTTP API proxy request event into a router request.
+//	Cookies are merged into the 'Cookie' header.
+//	Repeated query parameters are taken from the raw query string, as the event only contains their comma-separated values.
+//
+// Parameters:
+//
+//	event The proxy request event.
+//
+// Returns:
+//
+//	The router request, or an error if the request body cannot be decoded.
+func FromRequestV2(event *ProxyRequestV2) (*api.APIRequest, error) {
+	body, err := decodeBody(event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	path := event.RequestContext.HTTP.Path
+	if path == "" {
+		path = event.RawPath
+	}
+
+	request := newRequest(event.RequestContext.HTTP.Method, path, event.RawQueryString, body)
+
+	for key, value := range event.Headers {
+		request.Headers[http.CanonicalHeaderKey(key)] = value
+		request.MultiValueHeaders[http.CanonicalHeaderKey(key)] = []string{value}
+	}
+
+	if len(event.Cookies) > 0 {
+		request.Headers["Cookie"] = strings.Join(event.Cookies, "; ")
+		request.MultiValueHeaders["Cookie"] = []string{request.Headers["Cookie"]}
+	}
+
+	for key, value := range event.QueryStringParameters {
+		request.QueryParameters[key] = value
+	}
+
+	for key, values := range api.ParseQuery(event.RawQueryString) {
+		request.MultiValueQueryParameters[key] = values
+	}
+
+	for key, value := range request.QueryParameters {
+		if _, ok := request.MultiValueQueryParameters[key]; !ok {
+			request.MultiValueQueryParameters[key] = []string{value}
+		}
+	}
+
+	return request, nil
+}
+
+// Description:
+//
+//	Encodes a router response as API Gateway proxy response.
+//	Response bodies are encoded as JSON, like the HTTP routers do.
+//
+// Parameters:
+//
+//	response The router response.
+//
+// Returns:
+//
+//	The JSON encoded proxy response, or an error if the response body cannot be encoded.
+func EncodeResponse(response *api.APIResponse) ([]byte, error) {
+	body, err := router.EncodeResponseBody(response)
+	if err != nil {
+		return nil, fmt.Errorf("apigateway: cannot encode response body: %w", err)
+	}
+
+	result := ProxyResponse{
+		StatusCode: response.StatusCode,
+		Headers:    make(map[string]string),
+		Body:       string(body),
+	}
+
+	for key, value := range response.Headers {
+		result.Headers[http.CanonicalHeaderKey(key)] = value
+	}
+
+	if _, ok := result.Headers["Content-Type"]; body != nil && !ok {
+		result.Headers["Content-Type"] = "application/json; charset=utf-8"
+	}
+
+	return json.Marshal(result)
+}
+
+// Description:
+//
+//	Creates a router request with empty headers and parameters.
+//
+// Parameters:
+//
+//	method 		The request method.
+//	path 		The request path.
+//	rawQuery 	The raw query string.
+//	body 		The request body.
+//
+// Returns:
+//
+//	The created router request.
+func newRequest(method string, path string, rawQuery string, body string) *api.APIRequest {
+	requestURL := path
+	if rawQuery != "" {
+		requestURL = path + "?" + rawQuery
+	}
+
+	return &api.APIRequest{
+		Url:                       requestURL,
+		Path:                      path,
+		Method:                    method,
+		Headers:                   make(map[string]string),
+		MultiValueHeaders:         make(map[string][]string),
+		PathParameters:            make(map[string]string),
+		QueryParameters:           make(map[string]string),
+		MultiValueQueryParameters: make(map[string][]string),
+		Body:                      body,
+	}
+}
+
+// Description:
+//
+//	Decodes the body of a proxy request event.
+//
+// Parameters:
+//
+//	body 			The event body.
+//	isBase64Encoded Whether the event body is base64 encoded.
+//
+// Returns:
+//
+//	The decoded body, or an error if the body is not valid base64.
+func decodeBody(body string, isBase64Encoded bool) (string, error) {
+	if !isBase64Encoded {
+		return body, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return "", fmt.Errorf("apigateway: invalid base64 body: %w", err)
+	}
+
+	return string(decoded), nil
+}
@@ -0,0 +1,133 @@
+package apigateway
+
+// Description:
+//
+//	An API Gateway REST API (payload format version 1.0) proxy request event.
+type ProxyRequestV1 struct {
+
+	// The payload format version. Empty or '1.0'.
+	Version string `json:"version"`
+
+	// The resource path template, e.g. '/artists/{id}'.
+	Resource string `json:"resource"`
+
+	// The request path.
+	Path string `json:"path"`
+
+	// The request method.
+	HTTPMethod string `json:"httpMethod"`
+
+	// The request headers. Contains the last value of repeated headers.
+	Headers map[string]string `json:"headers"`
+
+	// All values of the request headers.
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+
+	// The query parameters. Contains the last value of repeated parameters.
+	QueryStringParameters map[string]string `json:"queryStringParameters"`
+
+	// All values of the query parameters.
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+
+	// The path parameters, as extracted by API Gateway.
+	PathParameters map[string]string `json:"pathParameters"`
+
+	// The request body.
+	Body string `json:"body"`
+
+	// Whether the request body is base64 encoded.
+	IsBase64Encoded bool `json:"isBase64Encoded"`
+
+	// The request context.
+	RequestContext ProxyRequestContextV1 `json:"requestContext"`
+}
+
+// Description:
+//
+//	The request context of an API Gateway REST API proxy request event.
+type ProxyRequestContextV1 struct {
+
+	// The API Gateway request id.
+	RequestID string `json:"requestId"`
+}
+
+// Description:
+//
+//	An API Gateway HTTP API (payload format version 2.0) proxy request event.
+type ProxyRequestV2 struct {
+
+	// The payload format version. Always '2.0'.
+	Version string `json:"version"`
+
+	// The route key, e.g. 'GET /artists/{id}'.
+	RouteKey string `json:"routeKey"`
+
+	// The raw request path.
+	RawPath string `json:"rawPath"`
+
+	// The raw query string, without leading '?'.
+	RawQueryString string `json:"rawQueryString"`
+
+	// The request cookies.
+	Cookies []string `json:"cookies"`
+
+	// The request headers. Values of repeated headers are comma-separated.
+	Headers map[string]string `json:"headers"`
+
+	// The query parameters. Values of repeated parameters are comma-separated.
+	QueryStringParameters map[string]string `json:"queryStringParameters"`
+
+	// The path parameters, as extracted by API Gateway.
+	PathParameters map[string]string `json:"pathParameters"`
+
+	// The request body.
+	Body string `json:"body"`
+
+	// Whether the request body is base64 encoded.
+	IsBase64Encoded bool `json:"isBase64Encoded"`
+
+	// The request context.
+	RequestContext ProxyRequestContextV2 `json:"requestContext"`
+}
+
+// Description:
+//
+//	The request context of an API Gateway HTTP API proxy request event.
+type ProxyRequestContextV2 struct {
+
+	// The API Gateway request id.
+	RequestID string `json:"requestId"`
+
+	// The HTTP details of the request.
+	HTTP ProxyRequestContextHTTP `json:"http"`
+}
+
+// Description:
+//
+//	The HTTP details of an API Gateway HTTP API proxy request event.
+type ProxyRequestContextHTTP struct {
+
+	// The request method.
+	Method string `json:"method"`
+
+	// The decoded request path.
+	Path string `json:"path"`
+}
+
+// Description:
+//
+//	An API Gateway proxy response. Valid for both payload format versions.
+type ProxyResponse struct {
+
+	// The response status code.
+	StatusCode int `json:"statusCode"`
+
+	// The response headers.
+	Headers map[string]string `json:"headers"`
+
+	// The response body.
+	Body string `json:"body"`
+
+	// Whether the response body is base64 encoded.
+	IsBase64Encoded bool `json:"isBase64Encoded"`
+}
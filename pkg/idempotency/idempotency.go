@@ -0,0 +1,298 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/router"
+	"github.com/gostream-official/artists/pkg/store"
+	"github.com/gostream-official/artists/pkg/store/query"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	The request header carrying the idempotency key.
+const KeyHeader = "Idempotency-Key"
+
+// Description:
+//
+//	The response header marking replayed responses.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// Description:
+//
+//	The maximum length of an idempotency key.
+const MaxKeyLength = 255
+
+// Description:
+//
+//	The time granted to store a response or release a key.
+//	Bounds these writes, as they are not cancelled with the request, e.g. when the client disconnects.
+const WriteTimeout = 5 * time.Second
+
+// Description:
+//
+//	A stored idempotency key.
+//	Holds the request fingerprint and, once the request completed, the response to replay.
+type Record struct {
+
+	// The scoped key (primary key), consisting of the request method, path and idempotency key.
+	Key string `bson:"_id"`
+
+	// The hex encoded SHA-256 hash of the request body.
+	RequestHash string `bson:"requestHash"`
+
+	// Whether the request completed and the response is stored.
+	Completed bool `bson:"completed"`
+
+	// The response status code.
+	StatusCode int `bson:"statusCode"`
+
+	// The response headers.
+	Headers map[string]string `bson:"headers"`
+
+	// The JSON encoded response body. Empty, if the response has no body.
+	Body string `bson:"body"`
+
+	// The time the key was first used. Records expire relative to this time.
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+// Description:
+//
+//	Creates the indexes of the idempotency key collection.
+//
+// Parameters:
+//
+//	ctx 		The operation context.
+//	records 	The idempotency key store.
+//	retention 	The time after which keys expire and may be reused.
+//
+// Returns:
+//
+//	An error if an index cannot be created.
+func CreateIndexes(ctx context.Context, records store.Store[Record], retention time.Duration) error {
+	return records.CreateIndex(ctx, store.Index{
+		Name:        "createdAt_ttl",
+		Keys:        []string{"createdAt"},
+		ExpireAfter: retention,
+	})
+}
+
+// Description:
+//
+//	Creates a middleware which makes requests carrying an 'Idempotency-Key' header idempotent.
+//
+//	The first request with a key is processed and its response stored. Repeated requests with the
+//	same key and body receive the stored response, marked by the 'Idempotent-Replayed' header.
+//	Reusing a key with another body is rejected with 422, a repeat while the first request is still
+//	in progress with 409. Server errors are not stored, so that the request can be retried.
+//
+// Parameters:
+//
+//	records The idempotency key store.
+//
+// Returns:
+//
+//	The idempotency middleware.
+func Middleware(records store.Store[Record]) router.Middleware {
+	return func(request *api.APIRequest, next router.RouterHandlerFunc) *api.APIResponse {
+		context := request.Parallel
+
+		key := request.Header(KeyHeader)
+		if key == "" {
+			return next(request)
+		}
+
+		if len(key) > MaxKeyLength {
+			log.Warnf("[%s] received invalid idempotency key", context.ID)
+			return api.NewProblem(http.StatusBadRequest, "invalid idempotency key").
+				WithRequest(request).
+				WithErrors(api.Violation{
+					Parameter: KeyHeader,
+					Message:   fmt.Sprintf("must be at most %d characters long", MaxKeyLength),
+				}).
+				Response()
+		}
+
+		hash := sha256.Sum256([]byte(request.Body))
+
+		record := Record{
+			Key:         fmt.Sprintf("%s %s %s", request.Method, request.Path, key),
+			RequestHash: hex.EncodeToString(hash[:]),
+			CreatedAt:   time.Now().UTC(),
+		}
+
+		err := records.CreateItem(request.Context, record)
+
+		if errors.Is(err, store.ErrDuplicateKey) {
+			return replay(request, records, &record)
+		}
+
+		if err != nil {
+			log.Errorf("[%s] failed to store idempotency key: %s", context.ID, err)
+			return api.ProblemResponse(request, http.StatusInternalServerError, "")
+		}
+
+		defer func() {
+			recovered := recover()
+			if recovered != nil {
+				release(request, records, record.Key)
+				panic(recovered)
+			}
+		}()
+
+		response := next(request)
+
+		if response.StatusCode >= http.StatusInternalServerError {
+			release(request, records, record.Key)
+			return response
+		}
+
+		body, err := router.EncodeResponseBody(response)
+		if err != nil {
+			log.Errorf("[%s] cannot encode response body: %s", context.ID, err)
+			return response
+		}
+
+		filter := query.Filter{
+			Root: query.FilterOperatorEq{
+				Key:   "_id",
+				Value: record.Key,
+			},
+		}
+
+		update := query.Update{
+			Root: query.UpdateOperatorSet{
+				Set: map[string]interface{}{
+					"completed":  true,
+					"statusCode": response.StatusCode,
+					"headers":    response.Headers,
+					"body":       string(body),
+				},
+			},
+		}
+
+		ctx, cancel := detach(request)
+		defer cancel()
+
+		_, err = records.UpdateItem(ctx, &filter, &update)
+		if err != nil {
+			log.Errorf("[%s] failed to store idempotent response: %s", context.ID, err)
+		}
+
+		return response
+	}
+}
+
+// Description:
+//
+//	Releases an idempotency key, so that the request can be retried.
+//
+// Parameters:
+//
+//	request 	The request which used the key.
+//	records 	The idempotency key store.
+//	key 		The scoped key to release.
+func release(request *api.APIRequest, records store.Store[Record], key string) {
+	ctx, cancel := detach(request)
+	defer cancel()
+
+	_, err := records.DeleteItem(ctx, key)
+	if err != nil {
+		log.Errorf("[%s] failed to release idempotency key: %s", request.Parallel.ID, err)
+	}
+}
+
+// Description:
+//
+//	Creates a context for writes which must complete even if the request is cancelled,
+//	as otherwise a key remains in progress until it expires.
+//
+// Parameters:
+//
+//	request The request.
+//
+// Returns:
+//
+//	The context, which keeps the values of the request context and expires after WriteTimeout.
+//	The function releasing the context.
+func detach(request *api.APIRequest) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(request.Context), WriteTimeout)
+}
+
+// Description:
+//
+//	Answers a request whose idempotency key has been used before.
+//
+// Parameters:
+//
+//	request 	The repeated request.
+//	records 	The idempotency key store.
+//	record 		The record of the repeated request.
+//
+// Returns:
+//
+//	The stored response, or a problem if the key cannot be replayed.
+func replay(request *api.APIRequest, records store.Store[Record], record *Record) *api.APIResponse {
+	context := request.Parallel
+
+	filter := query.Filter{
+		Root: query.FilterOperatorEq{
+			Key:   "_id",
+			Value: record.Key,
+		},
+		Limit: 1,
+	}
+
+	items, err := records.FindItems(request.Context, &filter)
+	if err != nil {
+		log.Errorf("[%s] failed to retrieve idempotency key: %s", context.ID, err)
+		return api.ProblemResponse(request, http.StatusInternalServerError, "")
+	}
+
+	if len(items) == 0 {
+		log.Warnf("[%s] idempotency key was released concurrently", context.ID)
+		return api.ProblemResponse(request, http.StatusConflict, "a request with this idempotency key is in progress")
+	}
+
+	stored := items[0]
+
+	if stored.RequestHash != record.RequestHash {
+		log.Warnf("[%s] idempotency key reused with different request body", context.ID)
+		return api.ProblemResponse(request, http.StatusUnprocessableEntity, "the idempotency key has already been used with a different request body")
+	}
+
+	if !stored.Completed {
+		log.Warnf("[%s] idempotency key is in use", context.ID)
+		return api.ProblemResponse(request, http.StatusConflict, "a request with this idempotency key is in progress")
+	}
+
+	log.Infof("[%s] replaying idempotent response", context.ID)
+
+	headers := make(map[string]string, len(stored.Headers)+1)
+	for key, value := range stored.Headers {
+		headers[key] = value
+	}
+
+	headers[ReplayedHeader] = "true"
+
+	response := &api.APIResponse{
+		StatusCode: stored.StatusCode,
+		Headers:    headers,
+	}
+
+	if stored.Body != "" {
+		response.Body = json.RawMessage(stored.Body)
+	}
+
+	return response
+}
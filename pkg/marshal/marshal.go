@@ -2,6 +2,7 @@ package marshal
 
 import (
 	"encoding/json"
+	"strings"
 )
 
 const (
@@ -82,3 +83,55 @@ func QuickWithIndent(object interface{}) string {
 
 	return string(bytes)
 }
+
+// Description:
+//
+//	Reduces an object to the given fields of its JSON representation.
+//	Nested fields are selected using dotted paths, e.g. 'stats.popularity'.
+//
+// Parameters:
+//
+//	object 	The object to reduce.
+//	fields 	The fields to select.
+//
+// Returns:
+//
+//	The reduced JSON object, or an error if the object cannot be marshalled into a JSON object.
+func Project(object interface{}, fields []string) (map[string]interface{}, error) {
+	bytes, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	full := make(map[string]interface{})
+	err = json.Unmarshal(bytes, &full)
+
+	if err != nil {
+		return nil, err
+	}
+
+	projected := make(map[string]interface{})
+
+	for _, field := range fields {
+		segments := strings.Split(field, ".")
+
+		source := full
+		target := projected
+
+		for _, segment := range segments[:len(segments)-1] {
+			source, _ = source[segment].(map[string]interface{})
+
+			next, ok := target[segment].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				target[segment] = next
+			}
+
+			target = next
+		}
+
+		target[segments[len(segments)-1]] = source[segments[len(segments)-1]]
+	}
+
+	return projected, nil
+}
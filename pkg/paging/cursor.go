@@ -0,0 +1,201 @@
+package paging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gostream-official/artists/pkg/store/query"
+)
+
+// Description:
+//
+//	The error returned for malformed, tampered or mismatching continuation tokens.
+var ErrInvalidCursor = errors.New("paging: invalid cursor")
+
+// Description:
+//
+//	A continuation cursor.
+//	Describes the position of the last item of a page within a sorted result set.
+type Cursor struct {
+
+	// The sort specification the cursor was created for.
+	Sort []CursorSortKey `json:"s"`
+
+	// The sort values of the last item of the page, one per sort key.
+	Values []interface{} `json:"v"`
+
+	// The fingerprint of the filter the cursor was created for.
+	Fingerprint string `json:"f"`
+}
+
+// Description:
+//
+//	The serialized form of a sort key within a cursor.
+type CursorSortKey struct {
+
+	// The document key to sort by.
+	Key string `json:"k"`
+
+	// The sort order.
+	Order query.SortOrder `json:"o"`
+}
+
+// Description:
+//
+//	Encodes the cursor into an opaque, signed continuation token.
+//
+// Parameters:
+//
+//	secret The secret used for signing the token.
+//
+// Returns:
+//
+//	The continuation token, or an error if the cursor cannot be encoded.
+func (cursor *Cursor) Encode(secret []byte) (string, error) {
+	bytes, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(bytes)
+	signature := base64.RawURLEncoding.EncodeToString(sign(secret, payload))
+
+	return payload + "." + signature, nil
+}
+
+// Description:
+//
+//	Decodes an opaque continuation token and verifies its signature.
+//
+// Parameters:
+//
+//	token 	The continuation token.
+//	secret 	The secret used for signing the token.
+//
+// Returns:
+//
+//	The decoded cursor, or ErrInvalidCursor if the token is malformed or was tampered with.
+func DecodeCursor(token string, secret []byte) (*Cursor, error) {
+	payload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, sign(secret, payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	bytes, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{}
+	err = json.Unmarshal(bytes, cursor)
+
+	if err != nil || len(cursor.Values) != len(cursor.Sort) {
+		return nil, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// Description:
+//
+//	Creates the filter selecting all items after the cursor position.
+//	Equivalent to a lexicographic comparison over all sort keys.
+//	Selects no items if no value can follow the cursor position.
+//
+// Returns:
+//
+//	The filter selecting all items after the cursor position.
+func (cursor *Cursor) After() query.IQuery {
+	or := query.FilterOperatorOr{
+		Or: make([]query.IQuery, 0),
+	}
+
+	for index, sortKey := range cursor.Sort {
+		and := query.FilterOperatorAnd{
+			And: make([]query.IQuery, 0),
+		}
+
+		for previous := 0; previous < index; previous++ {
+			and.And = append(and.And, query.FilterOperatorEq{
+				Key:   cursor.Sort[previous].Key,
+				Value: cursor.Values[previous],
+			})
+		}
+
+		condition := afterValue(sortKey, cursor.Values[index])
+		if condition == nil {
+			continue
+		}
+
+		and.And = append(and.And, condition)
+		or.Or = append(or.Or, and)
+	}
+
+	if len(or.Or) == 0 {
+		return query.FilterOperatorIn{Key: "_id", Values: make([]interface{}, 0)}
+	}
+
+	return or
+}
+
+// Description:
+//
+//	Creates the filter selecting all values after the given value for a single sort key.
+//	Missing values sort before all other values.
+//
+// Parameters:
+//
+//	sortKey The sort key.
+//	value 	The value of the last item.
+//
+// Returns:
+//
+//	The filter, or nil if no value can follow.
+func afterValue(sortKey CursorSortKey, value interface{}) query.IQuery {
+	if sortKey.Order == query.SortDescending {
+		if value == nil {
+			return nil
+		}
+
+		return query.FilterOperatorOr{
+			Or: []query.IQuery{
+				query.FilterOperatorLt{Key: sortKey.Key, Value: value},
+				query.FilterOperatorEq{Key: sortKey.Key, Value: nil},
+			},
+		}
+	}
+
+	if value == nil {
+		return query.FilterOperatorNeq{Key: sortKey.Key, Value: nil}
+	}
+
+	return query.FilterOperatorGt{Key: sortKey.Key, Value: value}
+}
+
+// Description:
+//
+//	Signs a token payload.
+//
+// Parameters:
+//
+//	secret 	The signing secret.
+//	payload The payload to sign.
+//
+// Returns:
+//
+//	The HMAC-SHA256 signature of the payload.
+func sign(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,345 @@
+package paging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/store"
+	"github.com/gostream-official/artists/pkg/store/query"
+)
+
+const (
+
+	// The page size used if no page size is requested.
+	DefaultPageSize = 50
+
+	// The largest page size which can be requested.
+	MaxPageSize = 200
+
+	// The document key used as final sort key, so that the sort order is stable.
+	StableSortKey = "_id"
+)
+
+// Description:
+//
+//	The paginator configuration.
+type Paginator struct {
+
+	// The secret used for signing continuation tokens.
+	Secret []byte
+
+	// The page size used if no page size is requested.
+	// Falls back to DefaultPageSize if zero.
+	DefaultPageSize uint32
+
+	// The largest page size which can be requested.
+	// Falls back to MaxPageSize if zero.
+	MaxPageSize uint32
+}
+
+// Description:
+//
+//	A single page of items.
+//
+// Type Parameters:
+//
+//	T The type of the page items.
+type Page[T interface{}] struct {
+
+	// The items of this page.
+	Items []T `json:"items"`
+
+	// The continuation token for the next page. Empty, if this is the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Description:
+//
+//	Determines the effective page size for a requested page size.
+//
+// Parameters:
+//
+//	requested The requested page size. Zero, if no page size was requested.
+//
+// Returns:
+//
+//	The effective page size.
+func (paginator *Paginator) PageSize(requested uint32) uint32 {
+	defaultPageSize := paginator.DefaultPageSize
+	if defaultPageSize == 0 {
+		defaultPageSize = DefaultPageSize
+	}
+
+	maxPageSize := paginator.MaxPageSize
+	if maxPageSize == 0 {
+		maxPageSize = MaxPageSize
+	}
+
+	if requested == 0 {
+		requested = defaultPageSize
+	}
+
+	if requested > maxPageSize {
+		return maxPageSize
+	}
+
+	return requested
+}
+
+// Description:
+//
+//	Queries a single page of items.
+//	The filter limit is used as requested page size. The sort specification is
+//	completed with the stable sort key, so that continuation is well-defined.
+//
+// Parameters:
+//
+//	ctx 		The operation context.
+//	store 		The store to query.
+//	filter 		The query filter to use.
+//	token 		The continuation token of the previous page. Empty for the first page.
+//	paginator 	The paginator configuration.
+//
+// Type Parameters:
+//
+//	T The type of the items to query.
+//
+// Returns:
+//
+//	The queried page, or an error if the query fails.
+//	ErrInvalidCursor, if the continuation token is invalid or belongs to a different query.
+func FindPage[T interface{}](ctx context.Context, store store.Store[T], filter query.Filter, token string, paginator *Paginator) (*Page[T], error) {
+	pageSize := paginator.PageSize(filter.Limit)
+	sortKeys := stableSort(filter.Sort)
+
+	fingerprint, err := fingerprint(filter.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	root := filter.Root
+
+	if token != "" {
+		cursor, err := DecodeCursor(token, paginator.Secret)
+		if err != nil {
+			return nil, err
+		}
+
+		if cursor.Fingerprint != fingerprint || !sameSort(cursor.Sort, sortKeys) {
+			return nil, ErrInvalidCursor
+		}
+
+		root = query.FilterOperatorAnd{
+			And: withoutNil(filter.Root, cursor.After()),
+		}
+	}
+
+	pageFilter := filter
+	pageFilter.Root = root
+	pageFilter.Sort = sortKeys
+	pageFilter.Limit = pageSize + 1
+
+	if len(filter.Projection) > 0 {
+		pageFilter.Projection = append([]string{}, filter.Projection...)
+
+		for _, sortKey := range sortKeys {
+			pageFilter.Projection = append(pageFilter.Projection, sortKey.Key)
+		}
+	}
+
+	items, err := store.FindItems(ctx, &pageFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{
+		Items: items,
+	}
+
+	if len(items) <= int(pageSize) {
+		return page, nil
+	}
+
+	page.Items = items[:pageSize]
+	last := page.Items[pageSize-1]
+
+	cursor := &Cursor{
+		Sort:        make([]CursorSortKey, 0, len(sortKeys)),
+		Values:      make([]interface{}, 0, len(sortKeys)),
+		Fingerprint: fingerprint,
+	}
+
+	for _, sortKey := range sortKeys {
+		var value interface{}
+
+		values := query.Lookup(last, sortKey.Key)
+		if len(values) > 0 {
+			value = cursorValue(values[0])
+		}
+
+		cursor.Sort = append(cursor.Sort, CursorSortKey{Key: sortKey.Key, Order: sortKey.Order})
+		cursor.Values = append(cursor.Values, value)
+	}
+
+	page.NextCursor, err = cursor.Encode(paginator.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// Description:
+//
+//	Creates the RFC 8288 'Link' header value referring to the next page.
+//
+// Parameters:
+//
+//	request The request of the current page.
+//	token 	The continuation token of the next page.
+//
+// Returns:
+//
+//	The 'Link' header value.
+func NextLink(request *api.APIRequest, token string) string {
+	parameters := url.Values{}
+
+	for key := range request.QueryParameters {
+		parameters[key] = request.QueryParameterValues(key)
+	}
+
+	parameters.Set("cursor", token)
+
+	return fmt.Sprintf("<%s?%s>; rel=\"next\"", request.Path, parameters.Encode())
+}
+
+// Description:
+//
+//	Completes a sort specification with the stable sort key.
+//
+// Parameters:
+//
+//	sortKeys The sort specification.
+//
+// Returns:
+//
+//	The sort specification, ending with the stable sort key.
+func stableSort(sortKeys []query.SortKey) []query.SortKey {
+	result := make([]query.SortKey, 0, len(sortKeys)+1)
+
+	for _, sortKey := range sortKeys {
+		if sortKey.Order != query.SortDescending {
+			sortKey.Order = query.SortAscending
+		}
+
+		result = append(result, sortKey)
+
+		if sortKey.Key == StableSortKey {
+			return result
+		}
+	}
+
+	return append(result, query.SortKey{
+		Key:   StableSortKey,
+		Order: query.SortAscending,
+	})
+}
+
+// Description:
+//
+//	Checks whether a cursor was created for the given sort specification.
+//
+// Parameters:
+//
+//	cursorSort 	The sort specification of the cursor.
+//	sortKeys 	The sort specification of the query.
+//
+// Returns:
+//
+//	Whether both sort specifications are equal.
+func sameSort(cursorSort []CursorSortKey, sortKeys []query.SortKey) bool {
+	if len(cursorSort) != len(sortKeys) {
+		return false
+	}
+
+	for index, sortKey := range sortKeys {
+		if cursorSort[index].Key != sortKey.Key || cursorSort[index].Order != sortKey.Order {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Description:
+//
+//	Computes the fingerprint of a filter, so that cursors cannot be used with different filters.
+//
+// Parameters:
+//
+//	root The root filter.
+//
+// Returns:
+//
+//	The filter fingerprint, or an error if the filter cannot be serialized.
+func fingerprint(root query.IQuery) (string, error) {
+	if root == nil {
+		return "", nil
+	}
+
+	bytes, err := json.Marshal(root.Compile())
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(bytes)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+// Description:
+//
+//	Collects all given filters which are not nil.
+//
+// Parameters:
+//
+//	filters The filters.
+//
+// Returns:
+//
+//	The filters which are not nil.
+func withoutNil(filters ...query.IQuery) []query.IQuery {
+	result := make([]query.IQuery, 0, len(filters))
+
+	for _, filter := range filters {
+		if filter != nil {
+			result = append(result, filter)
+		}
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Converts a sort value into a value which survives the JSON encoding of cursors unchanged.
+//	Single precision floats are widened, so that their exact value is encoded.
+//
+// Parameters:
+//
+//	value The sort value.
+//
+// Returns:
+//
+//	The converted sort value.
+func cursorValue(value interface{}) interface{} {
+	if number, ok := value.(float32); ok {
+		return float64(number)
+	}
+
+	return value
+}
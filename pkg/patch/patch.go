@@ -0,0 +1,463 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Description:
+//
+//	The content type of JSON Merge Patch documents (RFC 7396).
+const MergePatchContentType = "application/merge-patch+json"
+
+// Description:
+//
+//	The content type of JSON Patch documents (RFC 6902).
+const JSONPatchContentType = "application/json-patch+json"
+
+// Description:
+//
+//	The error returned when a JSON Patch 'test' operation fails.
+var ErrTestFailed = errors.New("patch: test failed")
+
+// Description:
+//
+//	A single JSON Patch operation.
+//	Supported operations are 'add', 'remove', 'replace' and 'test'.
+type Operation struct {
+
+	// The operation, e.g. 'add'.
+	Op string `json:"op"`
+
+	// The JSON pointer to the target location, e.g. '/genres/-'.
+	Path string `json:"path"`
+
+	// The operation value. Nil, if the operation has no value.
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Description:
+//
+//	Decodes a JSON Patch document.
+//
+// Parameters:
+//
+//	body The JSON encoded patch document, an array of operations.
+//
+// Returns:
+//
+//	The decoded operations, or an error if the document or an operation is invalid.
+func DecodeJSONPatch(body []byte) ([]Operation, error) {
+	operations := make([]Operation, 0)
+
+	err := json.Unmarshal(body, &operations)
+	if err != nil {
+		return nil, fmt.Errorf("patch: invalid patch document: %w", err)
+	}
+
+	for index, operation := range operations {
+		switch operation.Op {
+		case "add", "replace", "test":
+			if operation.Value == nil {
+				return nil, fmt.Errorf("patch: operation %d: missing value", index)
+			}
+
+		case "remove":
+
+		default:
+			return nil, fmt.Errorf("patch: operation %d: unsupported operation '%s'", index, operation.Op)
+		}
+
+		_, err := parsePointer(operation.Path)
+		if err != nil {
+			return nil, fmt.Errorf("patch: operation %d: %w", index, err)
+		}
+	}
+
+	return operations, nil
+}
+
+// Description:
+//
+//	Applies a JSON Merge Patch (RFC 7396) to a document.
+//	Null members of the patch remove the corresponding members of the document.
+//	The document is not modified.
+//
+// Parameters:
+//
+//	document 	The generically decoded JSON document.
+//	patch 		The generically decoded merge patch.
+//
+// Returns:
+//
+//	The patched document.
+func MergePatch(document interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return copyValue(patch)
+	}
+
+	result, ok := copyValue(document).(map[string]interface{})
+	if !ok {
+		result = make(map[string]interface{})
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+
+		result[key] = MergePatch(result[key], value)
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Applies JSON Patch (RFC 6902) operations to a document.
+//	Operations are applied in order. If an operation fails, no operation is applied.
+//	The document is not modified.
+//
+// Parameters:
+//
+//	document 	The generically decoded JSON document.
+//	operations 	The operations to apply.
+//
+// Returns:
+//
+//	The patched document, or an error if an operation fails.
+//	Failed 'test' operations are reported as ErrTestFailed.
+func ApplyJSONPatch(document interface{}, operations []Operation) (interface{}, error) {
+	result := copyValue(document)
+
+	for index, operation := range operations {
+		var value interface{}
+
+		if operation.Value != nil {
+			err := json.Unmarshal(operation.Value, &value)
+			if err != nil {
+				return nil, fmt.Errorf("patch: operation %d: invalid value: %w", index, err)
+			}
+		}
+
+		segments, err := parsePointer(operation.Path)
+		if err != nil {
+			return nil, fmt.Errorf("patch: operation %d: %w", index, err)
+		}
+
+		switch operation.Op {
+		case "add":
+			result, err = add(result, segments, value)
+
+		case "remove":
+			result, err = remove(result, segments)
+
+		case "replace":
+			result, err = remove(result, segments)
+			if err == nil {
+				result, err = add(result, segments, value)
+			}
+
+		case "test":
+			current, ok := resolve(result, segments)
+			if !ok || !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("%w: operation %d at '%s'", ErrTestFailed, index, operation.Path)
+			}
+
+		default:
+			err = fmt.Errorf("unsupported operation '%s'", operation.Op)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("patch: operation %d: %w", index, err)
+		}
+	}
+
+	return result, nil
+}
+
+// Description:
+//
+//	Adds a value at the target location.
+//	Object members are added or replaced, array items are inserted. '-' appends to an array.
+//
+// Parameters:
+//
+//	document 	The document to modify.
+//	segments 	The unescaped pointer segments of the target location.
+//	value 		The value to add.
+//
+// Returns:
+//
+//	The modified document, or an error if the target location does not exist.
+func add(document interface{}, segments []string, value interface{}) (interface{}, error) {
+	if len(segments) == 0 {
+		return value, nil
+	}
+
+	parent, ok := resolve(document, segments[:len(segments)-1])
+	if !ok {
+		return nil, fmt.Errorf("path '%s' does not exist", formatPointer(segments[:len(segments)-1]))
+	}
+
+	key := segments[len(segments)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[key] = value
+		return document, nil
+
+	case []interface{}:
+		position := len(container)
+
+		if key != "-" {
+			index, err := arrayIndex(key, len(container)+1)
+			if err != nil {
+				return nil, err
+			}
+
+			position = index
+		}
+
+		array := make([]interface{}, 0, len(container)+1)
+		array = append(array, container[:position]...)
+		array = append(array, value)
+		array = append(array, container[position:]...)
+
+		return replaceParent(document, segments[:len(segments)-1], array), nil
+	}
+
+	return nil, fmt.Errorf("path '%s' is not a container", formatPointer(segments[:len(segments)-1]))
+}
+
+// Description:
+//
+//	Removes the value at the target location.
+//
+// Parameters:
+//
+//	document 	The document to modify.
+//	segments 	The unescaped pointer segments of the target location.
+//
+// Returns:
+//
+//	The modified document, or an error if the target location does not exist.
+func remove(document interface{}, segments []string) (interface{}, error) {
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	_, ok := resolve(document, segments)
+	if !ok {
+		return nil, fmt.Errorf("path '%s' does not exist", formatPointer(segments))
+	}
+
+	parent, _ := resolve(document, segments[:len(segments)-1])
+	key := segments[len(segments)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		delete(container, key)
+		return document, nil
+
+	case []interface{}:
+		position, _ := arrayIndex(key, len(container))
+
+		array := make([]interface{}, 0, len(container)-1)
+		array = append(array, container[:position]...)
+		array = append(array, container[position+1:]...)
+
+		return replaceParent(document, segments[:len(segments)-1], array), nil
+	}
+
+	return document, nil
+}
+
+// Description:
+//
+//	Replaces the array at the given location, as arrays cannot be resized in place.
+//
+// Parameters:
+//
+//	document 	The document to modify.
+//	segments 	The unescaped pointer segments of the array location.
+//	array 		The new array.
+//
+// Returns:
+//
+//	The modified document.
+func replaceParent(document interface{}, segments []string, array []interface{}) interface{} {
+	if len(segments) == 0 {
+		return array
+	}
+
+	parent, _ := resolve(document, segments[:len(segments)-1])
+	key := segments[len(segments)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[key] = array
+
+	case []interface{}:
+		position, _ := arrayIndex(key, len(container))
+		container[position] = array
+	}
+
+	return document
+}
+
+// Description:
+//
+//	Resolves the value at the given location.
+//
+// Parameters:
+//
+//	document 	The document to resolve the location in.
+//	segments 	The unescaped pointer segments of the location.
+//
+// Returns:
+//
+//	The value and whether the location exists.
+func resolve(document interface{}, segments []string) (interface{}, bool) {
+	current := document
+
+	for _, segment := range segments {
+		switch container := current.(type) {
+		case map[string]interface{}:
+			next, ok := container[segment]
+			if !ok {
+				return nil, false
+			}
+
+			current = next
+
+		case []interface{}:
+			position, err := arrayIndex(segment, len(container))
+			if err != nil {
+				return nil, false
+			}
+
+			current = container[position]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// Description:
+//
+//	Parses an array index pointer segment.
+//
+// Parameters:
+//
+//	segment The pointer segment.
+//	limit 	The exclusive upper bound of the index.
+//
+// Returns:
+//
+//	The index, or an error if the segment is not a valid index below the limit.
+func arrayIndex(segment string, limit int) (int, error) {
+	if segment == "" || (len(segment) > 1 && segment[0] == '0') {
+		return 0, fmt.Errorf("invalid array index '%s'", segment)
+	}
+
+	index, err := strconv.Atoi(segment)
+	if err != nil || index < 0 || index >= limit {
+		return 0, fmt.Errorf("invalid array index '%s'", segment)
+	}
+
+	return index, nil
+}
+
+// Description:
+//
+//	Parses a JSON pointer (RFC 6901).
+//
+// Parameters:
+//
+//	pointer The JSON pointer, e.g. '/stats/popularity'.
+//
+// Returns:
+//
+//	The unescaped segments, or an error if the pointer is invalid.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer '%s'", pointer)
+	}
+
+	segments := strings.Split(pointer[1:], "/")
+	for index, segment := range segments {
+		segment = strings.ReplaceAll(segment, "~1", "/")
+		segments[index] = strings.ReplaceAll(segment, "~0", "~")
+	}
+
+	return segments, nil
+}
+
+// Description:
+//
+//	Formats unescaped segments as JSON pointer.
+//
+// Parameters:
+//
+//	segments The unescaped segments.
+//
+// Returns:
+//
+//	The JSON pointer.
+func formatPointer(segments []string) string {
+	var builder strings.Builder
+
+	for _, segment := range segments {
+		segment = strings.ReplaceAll(segment, "~", "~0")
+		builder.WriteString("/" + strings.ReplaceAll(segment, "/", "~1"))
+	}
+
+	return builder.String()
+}
+
+// Description:
+//
+//	Creates a deep copy of a generically decoded JSON value.
+//
+// Parameters:
+//
+//	value The value to copy.
+//
+// Returns:
+//
+//	The copied value.
+func copyValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			result[key] = copyValue(item)
+		}
+
+		return result
+
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for index, item := range typed {
+			result[index] = copyValue(item)
+		}
+
+		return result
+	}
+
+	return value
+}
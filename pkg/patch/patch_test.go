@@ -0,0 +1,437 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gostream-official/artists/pkg/store/query"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Description:
+//
+//	The document the JSON Patch tests are applied to.
+const patchTestDocument = `{
+	"name": "Daft Punk",
+	"genres": ["electronic", "house"],
+	"stats": {"popularity": 0.8},
+	"a/b": 1,
+	"m~n": 2,
+	"~1": 3
+}`
+
+// Description:
+//
+//	Decodes a JSON document generically.
+//
+// Parameters:
+//
+//	t 		The test context.
+//	document The JSON document.
+//
+// Returns:
+//
+//	The decoded document.
+func decode(t *testing.T, document string) interface{} {
+	t.Helper()
+
+	var result interface{}
+
+	err := json.Unmarshal([]byte(document), &result)
+	if err != nil {
+		t.Fatalf("failed to decode '%s': %s", document, err)
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Tests applying JSON Patch (RFC 6902) operations.
+//
+// Parameters:
+//
+//	t The test context.
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		expected string
+		message  string
+	}{
+		{
+			name:     "add appends to array",
+			patch:    `[{"op": "add", "path": "/genres/-", "value": "disco"}]`,
+			expected: `{"name": "Daft Punk", "genres": ["electronic", "house", "disco"], "stats": {"popularity": 0.8}, "a/b": 1, "m~n": 2, "~1": 3}`,
+		},
+		{
+			name:     "add inserts at index",
+			patch:    `[{"op": "add", "path": "/genres/0", "value": "disco"}]`,
+			expected: `{"name": "Daft Punk", "genres": ["disco", "electronic", "house"], "stats": {"popularity": 0.8}, "a/b": 1, "m~n": 2, "~1": 3}`,
+		},
+		{
+			name:     "add inserts at end index",
+			patch:    `[{"op": "add", "path": "/genres/2", "value": "disco"}]`,
+			expected: `{"name": "Daft Punk", "genres": ["electronic", "house", "disco"], "stats": {"popularity": 0.8}, "a/b": 1, "m~n": 2, "~1": 3}`,
+		},
+		{
+			name:     "add member",
+			patch:    `[{"op": "add", "path": "/stats/rank", "value": 7}]`,
+			expected: `{"name": "Daft Punk", "genres": ["electronic", "house"], "stats": {"popularity": 0.8, "rank": 7}, "a/b": 1, "m~n": 2, "~1": 3}`,
+		},
+		{
+			name:     "add replaces existing member",
+			patch:    `[{"op": "add", "path": "/name", "value": "Justice"}]`,
+			expected: `{"name": "Justice", "genres": ["electronic", "house"], "stats": {"popularity": 0.8}, "a/b": 1, "m~n": 2, "~1": 3}`,
+		},
+		{
+			name:    "add beyond array end",
+			patch:   `[{"op": "add", "path": "/genres/3", "value": "disco"}]`,
+			message: "invalid array index '3'",
+		},
+		{
+			name:    "add with leading zero index",
+			patch:   `[{"op": "add", "path": "/genres/01", "value": "disco"}]`,
+			message: "invalid array index '01'",
+		},
+		{
+			name:    "add to missing parent",
+			patch:   `[{"op": "add", "path": "/albums/0/title", "value": "Discovery"}]`,
+			message: "path '/albums/0' does not exist",
+		},
+		{
+			name:    "add to scalar",
+			patch:   `[{"op": "add", "path": "/name/first", "value": "Daft"}]`,
+			message: "path '/name' is not a container",
+		},
+		{
+			name:     "add replaces whole document",
+			patch:    `[{"op": "add", "path": "", "value": {"name": "Justice"}}]`,
+			expected: `{"name": "Justice"}`,
+		},
+		{
+			name:     "remove member",
+			patch:    `[{"op": "remove", "path": "/stats/popularity"}]`,
+			expected: `{"name": "Daft Punk", "genres": ["electronic", "house"], "stats": {}, "a/b": 1, "m~n": 2, "~1": 3}`,
+		},
+		{
+			name:     "remove array item",
+			patch:    `[{"op": "remove", "path": "/genres/0"}]`,
+			expected: `{"name": "Daft Punk", "genres": ["house"], "stats": {"popularity": 0.8}, "a/b": 1, "m~n": 2, "~1": 3}`,
+		},
+		{
+			name:    "remove missing member",
+			patch:   `[{"op": "remove", "path": "/label"}]`,
+			message: "path '/label' does not exist",
+		},
+		{
+			name:    "remove missing array item",
+			patch:   `[{"op": "remove", "path": "/genres/2"}]`,
+			message: "path '/genres/2' does not exist",
+		},
+		{
+			name:    "remove appended array item",
+			patch:   `[{"op": "remove", "path": "/genres/-"}]`,
+			message: "path '/genres/-' does not exist",
+		},
+		{
+			name:     "replace member",
+			patch:    `[{"op": "replace", "path": "/stats/popularity", "value": 0.9}]`,
+			expected: `{"name": "Daft Punk", "genres": ["electronic", "house"], "stats": {"popularity": 0.9}, "a/b": 1, "m~n": 2, "~1": 3}`,
+		},
+		{
+			name:     "replace array item",
+			patch:    `[{"op": "replace", "path": "/genres/1", "value": "disco"}]`,
+			expected: `{"name": "Daft Punk", "genres": ["electronic", "disco"], "stats": {"popularity": 0.8}, "a/b": 1, "m~n": 2, "~1": 3}`,
+		},
+		{
+			name:    "replace missing member",
+			patch:   `[{"op": "replace", "path": "/label", "value": "Virgin"}]`,
+			message: "path '/label' does not exist",
+		},
+		{
+			name:    "replace missing array item",
+			patch:   `[{"op": "replace", "path": "/genres/5", "value": "disco"}]`,
+			message: "path '/genres/5' does not exist",
+		},
+		{
+			name:     "test followed by change",
+			patch:    `[{"op": "test", "path": "/genres", "value": ["electronic", "house"]}, {"op": "replace", "path": "/name", "value": "Justice"}]`,
+			expected: `{"name": "Justice", "genres": ["electronic", "house"], "stats": {"popularity": 0.8}, "a/b": 1, "m~n": 2, "~1": 3}`,
+		},
+		{
+			name:     "escaped slash",
+			patch:    `[{"op": "replace", "path": "/a~1b", "value": 10}]`,
+			expected: `{"name": "Daft Punk", "genres": ["electronic", "house"], "stats": {"popularity": 0.8}, "a/b": 10, "m~n": 2, "~1": 3}`,
+		},
+		{
+			name:     "escaped tilde",
+			patch:    `[{"op": "replace", "path": "/m~0n", "value": 20}]`,
+			expected: `{"name": "Daft Punk", "genres": ["electronic", "house"], "stats": {"popularity": 0.8}, "a/b": 1, "m~n": 20, "~1": 3}`,
+		},
+		{
+			name:     "escaped tilde before one",
+			patch:    `[{"op": "remove", "path": "/~01"}]`,
+			expected: `{"name": "Daft Punk", "genres": ["electronic", "house"], "stats": {"popularity": 0.8}, "a/b": 1, "m~n": 2}`,
+		},
+		{
+			name:    "unescaped slash refers to nested member",
+			patch:   `[{"op": "remove", "path": "/a/b"}]`,
+			message: "path '/a/b' does not exist",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			document := decode(t, patchTestDocument)
+
+			operations, err := DecodeJSONPatch([]byte(test.patch))
+			if err != nil {
+				t.Fatalf("failed to decode patch: %s", err)
+			}
+
+			actual, err := ApplyJSONPatch(document, operations)
+
+			if !reflect.DeepEqual(document, decode(t, patchTestDocument)) {
+				t.Errorf("expected document not to be modified, got %v", document)
+			}
+
+			if test.message != "" {
+				if err == nil {
+					t.Fatalf("expected an error containing '%s', got %v", test.message, actual)
+				}
+
+				if !strings.Contains(err.Error(), test.message) {
+					t.Errorf("expected error containing '%s', got '%s'", test.message, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to apply patch: %s", err)
+			}
+
+			expected := decode(t, test.expected)
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("expected %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests that failing 'test' operations are reported as ErrTestFailed and abort the patch.
+//
+// Parameters:
+//
+//	t The test context.
+func TestApplyJSONPatchTestFailure(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"different value", `[{"op": "test", "path": "/name", "value": "Justice"}]`},
+		{"different type", `[{"op": "test", "path": "/stats/popularity", "value": "0.8"}]`},
+		{"different array order", `[{"op": "test", "path": "/genres", "value": ["house", "electronic"]}]`},
+		{"missing path", `[{"op": "test", "path": "/label", "value": null}]`},
+		{"after applied operation", `[{"op": "replace", "path": "/name", "value": "Justice"}, {"op": "test", "path": "/name", "value": "Daft Punk"}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			operations, err := DecodeJSONPatch([]byte(test.patch))
+			if err != nil {
+				t.Fatalf("failed to decode patch: %s", err)
+			}
+
+			actual, err := ApplyJSONPatch(decode(t, patchTestDocument), operations)
+			if !errors.Is(err, ErrTestFailed) {
+				t.Fatalf("expected ErrTestFailed, got %v (%v)", err, actual)
+			}
+
+			if actual != nil {
+				t.Errorf("expected no document, got %v", actual)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests that invalid JSON Patch documents are rejected when decoding.
+//
+// Parameters:
+//
+//	t The test context.
+func TestDecodeJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		message string
+	}{
+		{"valid", `[{"op": "add", "path": "/genres/-", "value": "disco"}, {"op": "remove", "path": "/name"}]`, ""},
+		{"null value", `[{"op": "replace", "path": "/label", "value": null}]`, ""},
+		{"not an array", `{"op": "add", "path": "/name", "value": "x"}`, "invalid patch document"},
+		{"missing value", `[{"op": "add", "path": "/name"}]`, "operation 0: missing value"},
+		{"missing test value", `[{"op": "remove", "path": "/name"}, {"op": "test", "path": "/name"}]`, "operation 1: missing value"},
+		{"unsupported operation", `[{"op": "move", "from": "/a", "path": "/b"}]`, "unsupported operation 'move'"},
+		{"invalid pointer", `[{"op": "remove", "path": "name"}]`, "invalid pointer 'name'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodeJSONPatch([]byte(test.patch))
+
+			if test.message == "" {
+				if err != nil {
+					t.Errorf("expected patch to be valid, got '%s'", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.message) {
+				t.Errorf("expected error containing '%s', got '%v'", test.message, err)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests applying JSON Merge Patch (RFC 7396) documents.
+//
+// Parameters:
+//
+//	t The test context.
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		expected string
+	}{
+		{"replace member", `{"name": "Daft Punk", "followers": 1}`, `{"name": "Justice"}`, `{"name": "Justice", "followers": 1}`},
+		{"add member", `{"name": "Daft Punk"}`, `{"followers": 1}`, `{"name": "Daft Punk", "followers": 1}`},
+		{"null deletes member", `{"name": "Daft Punk", "label": "Virgin"}`, `{"label": null}`, `{"name": "Daft Punk"}`},
+		{"null deletes missing member", `{"name": "Daft Punk"}`, `{"label": null}`, `{"name": "Daft Punk"}`},
+		{"nested merge", `{"stats": {"popularity": 0.8, "rank": 7}}`, `{"stats": {"popularity": 0.9}}`, `{"stats": {"popularity": 0.9, "rank": 7}}`},
+		{"nested null deletes member", `{"stats": {"popularity": 0.8, "rank": 7}}`, `{"stats": {"rank": null}}`, `{"stats": {"popularity": 0.8}}`},
+		{"null deletes nested document", `{"name": "Daft Punk", "stats": {"rank": 7}}`, `{"stats": null}`, `{"name": "Daft Punk"}`},
+		{"array is replaced", `{"genres": ["electronic", "house"]}`, `{"genres": ["disco"]}`, `{"genres": ["disco"]}`},
+		{"null in array is kept", `{"genres": ["electronic"]}`, `{"genres": [null]}`, `{"genres": [null]}`},
+		{"object replaces scalar", `{"stats": 1}`, `{"stats": {"rank": 7, "label": null}}`, `{"stats": {"rank": 7}}`},
+		{"non-object patch replaces document", `{"name": "Daft Punk"}`, `["x"]`, `["x"]`},
+		{"empty patch", `{"name": "Daft Punk"}`, `{}`, `{"name": "Daft Punk"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			document := decode(t, test.document)
+			actual := MergePatch(document, decode(t, test.patch))
+
+			expected := decode(t, test.expected)
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("expected %v, got %v", expected, actual)
+			}
+
+			if !reflect.DeepEqual(document, decode(t, test.document)) {
+				t.Errorf("expected document not to be modified, got %v", document)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests translating document differences into update operators.
+//
+// Parameters:
+//
+//	t The test context.
+func TestToUpdate(t *testing.T) {
+	before := bson.M{
+		"name":      "Daft Punk",
+		"genres":    bson.A{"electronic", "house"},
+		"followers": int64(1000),
+		"stats":     bson.M{"popularity": 0.8, "rank": int32(7)},
+	}
+
+	tests := []struct {
+		name     string
+		after    bson.M
+		expected query.Update
+		compiled bson.M
+	}{
+		{
+			name:     "equal documents",
+			after:    bson.M{"name": "Daft Punk", "genres": bson.A{"electronic", "house"}, "followers": int64(1000), "stats": bson.M{"popularity": 0.8, "rank": int32(7)}},
+			expected: query.Update{},
+		},
+		{
+			name:     "changed field",
+			after:    bson.M{"name": "Justice", "genres": bson.A{"electronic", "house"}, "followers": int64(1000), "stats": bson.M{"popularity": 0.8, "rank": int32(7)}},
+			expected: query.Update{Root: query.UpdateOperatorSet{Set: map[string]interface{}{"name": "Justice"}}},
+			compiled: bson.M{"$set": map[string]interface{}{"name": "Justice"}},
+		},
+		{
+			name:     "changed array is set as a whole",
+			after:    bson.M{"name": "Daft Punk", "genres": bson.A{"electronic", "house", "disco"}, "followers": int64(1000), "stats": bson.M{"popularity": 0.8, "rank": int32(7)}},
+			expected: query.Update{Root: query.UpdateOperatorSet{Set: map[string]interface{}{"genres": bson.A{"electronic", "house", "disco"}}}},
+		},
+		{
+			name:     "changed nested field uses dotted key",
+			after:    bson.M{"name": "Daft Punk", "genres": bson.A{"electronic", "house"}, "followers": int64(1000), "stats": bson.M{"popularity": 0.9, "rank": int32(7)}},
+			expected: query.Update{Root: query.UpdateOperatorSet{Set: map[string]interface{}{"stats.popularity": 0.9}}},
+		},
+		{
+			name:     "removed fields are unset",
+			after:    bson.M{"name": "Daft Punk", "genres": bson.A{"electronic", "house"}, "stats": bson.M{"popularity": 0.8}},
+			expected: query.Update{Root: query.UpdateOperatorUnset{Unset: []string{"followers", "stats.rank"}}},
+			compiled: bson.M{"$unset": bson.M{"followers": "", "stats.rank": ""}},
+		},
+		{
+			name:  "changed and removed fields",
+			after: bson.M{"name": "Justice", "genres": bson.A{"electronic", "house"}, "followers": int64(1000), "stats": bson.M{"popularity": 0.8}, "label": "Ed Banger"},
+			expected: query.Update{Root: query.UpdateOperatorCombine{Combine: []query.IQuery{
+				query.UpdateOperatorSet{Set: map[string]interface{}{"name": "Justice", "label": "Ed Banger"}},
+				query.UpdateOperatorUnset{Unset: []string{"stats.rank"}},
+			}}},
+			compiled: bson.M{"$set": bson.M{"name": "Justice", "label": "Ed Banger"}, "$unset": bson.M{"stats.rank": ""}},
+		},
+		{
+			name:     "document replacing scalar is set as a whole",
+			after:    bson.M{"name": bson.M{"first": "Daft"}, "genres": bson.A{"electronic", "house"}, "followers": int64(1000), "stats": bson.M{"popularity": 0.8, "rank": int32(7)}},
+			expected: query.Update{Root: query.UpdateOperatorSet{Set: map[string]interface{}{"name": bson.M{"first": "Daft"}}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := ToUpdate(before, test.after)
+
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("expected %#v, got %#v", test.expected, actual)
+			}
+
+			err := actual.Validate()
+			if err != nil {
+				t.Errorf("expected a valid update, got '%s'", err)
+			}
+
+			if test.compiled == nil {
+				return
+			}
+
+			compiled := actual.Root.Compile()
+			if !reflect.DeepEqual(compiled, test.compiled) {
+				t.Errorf("expected %v, got %v", test.compiled, compiled)
+			}
+		})
+	}
+}
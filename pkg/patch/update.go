@@ -0,0 +1,97 @@
+package patch
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/gostream-official/artists/pkg/store/query"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Description:
+//
+//	Translates the difference between two documents into update operators.
+//	Changed fields are set, removed fields are unset. Nested documents are compared
+//	field by field, arrays and other values as a whole.
+//
+// Parameters:
+//
+//	before 	The current document.
+//	after 	The patched document.
+//
+// Returns:
+//
+//	The update transforming the current into the patched document.
+//	The update has no root, if the documents are equal.
+func ToUpdate(before bson.M, after bson.M) query.Update {
+	set := make(map[string]interface{})
+	unset := make([]string, 0)
+
+	diff(before, after, "", set, &unset)
+
+	operators := make([]query.IQuery, 0)
+
+	if len(set) > 0 {
+		operators = append(operators, query.UpdateOperatorSet{
+			Set: set,
+		})
+	}
+
+	if len(unset) > 0 {
+		sort.Strings(unset)
+		operators = append(operators, query.UpdateOperatorUnset{
+			Unset: unset,
+		})
+	}
+
+	switch len(operators) {
+	case 0:
+		return query.Update{}
+
+	case 1:
+		return query.Update{
+			Root: operators[0],
+		}
+	}
+
+	return query.Update{
+		Root: query.UpdateOperatorCombine{
+			Combine: operators,
+		},
+	}
+}
+
+// Description:
+//
+//	Collects the differences between two documents.
+//
+// Parameters:
+//
+//	before 	The current document.
+//	after 	The patched document.
+//	prefix 	The dotted key path of the documents, including a trailing dot.
+//	set 	The dotted key paths to set, with their new values.
+//	unset 	The dotted key paths to unset.
+func diff(before bson.M, after bson.M, prefix string, set map[string]interface{}, unset *[]string) {
+	for key, value := range after {
+		current, ok := before[key]
+
+		currentDocument, currentIsDocument := current.(bson.M)
+		valueDocument, valueIsDocument := value.(bson.M)
+
+		if ok && currentIsDocument && valueIsDocument {
+			diff(currentDocument, valueDocument, prefix+key+".", set, unset)
+			continue
+		}
+
+		if !ok || !reflect.DeepEqual(current, value) {
+			set[prefix+key] = value
+		}
+	}
+
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			*unset = append(*unset, prefix+key)
+		}
+	}
+}
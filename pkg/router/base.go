@@ -0,0 +1,455 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/parallel"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	The time allowed to read the headers of a request.
+//	Keeps slow clients from holding connections open without ever sending a request.
+const readHeaderTimeout = 10 * time.Second
+
+// Description:
+//
+//	The engine-independent part of a router.
+//	Holds middlewares, panic reporters and injections, serves requests and manages the HTTP server,
+//	so that all router implementations share the same request and response semantics.
+type routerBase struct {
+
+	// Guards the HTTP server and the closed flag.
+	mutex sync.Mutex
+
+	// The HTTP server. Nil, until the router is started.
+	server *http.Server
+
+	// Whether the router was shut down. A router shut down before it was started never starts.
+	closed bool
+
+	// The global middlewares, in registration order.
+	middlewares []Middleware
+
+	// The panic reporters, in registration order.
+	reporters []PanicReporter
+
+	// The routes registered with object injection.
+	injections []injectionRoute
+}
+
+// Description:
+//
+//	Registers middlewares for all routes, including routes registered before.
+//	Global middlewares run before route middlewares, in registration order.
+//	Must be called before the router is started.
+//
+// Parameters:
+//
+//	middlewares The middlewares to register.
+func (router *routerBase) Use(middlewares ...Middleware) {
+	router.middlewares = append(router.middlewares, middlewares...)
+}
+
+// Description:
+//
+//	Registers a panic reporter.
+//	Panic reporters are notified about every panic recovered while handling a request.
+//	Must be called before the router is started.
+//
+// Parameters:
+//
+//	reporter The panic reporter to register.
+func (router *routerBase) OnPanic(reporter PanicReporter) {
+	router.reporters = append(router.reporters, reporter)
+}
+
+// Description:
+//
+//	Gracefully shuts down the HTTP server of this router.
+//	Stops accepting new connections and waits for in-flight requests to complete.
+//	If the router has not been started yet, it is prevented from starting.
+//
+// Parameters:
+//
+//	ctx The shutdown context. Bounds the time waited for in-flight requests.
+//
+// Returns:
+//
+//	An error if in-flight requests did not complete in time.
+func (router *routerBase) Shutdown(ctx context.Context) error {
+	router.mutex.Lock()
+	router.closed = true
+	server := router.server
+	router.mutex.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	return server.Shutdown(ctx)
+}
+
+// Description:
+//
+//	Creates the router injector for a route registered with object injection.
+//
+// Parameters:
+//
+//	method 	The http method of the route.
+//	path 	The path of the route.
+//
+// Returns:
+//
+//	The router injector.
+func (router *routerBase) inject(method string, path string) *RouterInjector {
+	injector := &RouterInjector{}
+
+	router.injections = append(router.injections, injectionRoute{
+		method:   method,
+		path:     path,
+		injector: injector,
+	})
+
+	return injector
+}
+
+// Description:
+//
+//	Wraps a route handler with the global and the route middlewares.
+//
+// Parameters:
+//
+//	handler The route handler.
+//	route 	The route.
+//
+// Returns:
+//
+//	The wrapped route handler.
+func (router *routerBase) chain(handler RouterHandlerFunc, route *RouterRoute) RouterHandlerFunc {
+	middlewares := append(append([]Middleware{}, router.middlewares...), route.Middlewares...)
+	return Chain(handler, middlewares...)
+}
+
+// Description:
+//
+//	Starts an HTTP server on the given port.
+//
+//	Fails without serving, if a route registered with object injection has no object injected.
+//
+// Parameters:
+//
+//	port 	The port to listen on.
+//	handler The HTTP handler serving all requests.
+//
+// Returns:
+//
+//	An error if serving fails. Nil, if the server was shut down while serving.
+//	http.ErrServerClosed, if the router was shut down before it was started.
+func (router *routerBase) listen(port uint16, handler http.Handler) error {
+	err := checkInjections(router.injections)
+	if err != nil {
+		return err
+	}
+
+	portFmt := fmt.Sprintf(":%d", port)
+
+	server := &http.Server{
+		Addr:              portFmt,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	// Setting this to false apparently reduces memory usage.
+	// However, setting this to true apparently is the standard and improves performance.
+	server.SetKeepAlivesEnabled(true)
+
+	router.mutex.Lock()
+
+	if router.closed {
+		router.mutex.Unlock()
+		return http.ErrServerClosed
+	}
+
+	router.server = server
+	router.mutex.Unlock()
+
+	err = server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Description:
+//
+//	Serves a single HTTP request matched to a registered route.
+//
+// Parameters:
+//
+//	pathHandle 	The registered path handle.
+//	writer 		The response writer.
+//	request 	The incoming request.
+//	route 		The route.
+//	handler 	The registered handler function.
+func (router *routerBase) serve(pathHandle string, writer http.ResponseWriter, request *http.Request, route *RouterRoute, handler RouterHandlerFunc) {
+	parallelContext := parallel.NewContext()
+
+	internalRequest, err := transformRequest(pathHandle, request)
+
+	if err != nil {
+		log.Warnf("[%s] cannot transform request: %s", parallelContext.ID, err)
+		writeResponse(parallelContext, malformedRequestResponse(parallelContext, request.URL.Path), writer)
+		return
+	}
+
+	internalRequest.Parallel = parallelContext
+
+	internalResponse := router.dispatch(internalRequest, route, handler)
+	writeResponse(parallelContext, internalResponse, writer)
+}
+
+// Description:
+//
+//	Serves a request which does not match any route.
+//	Middlewares are not applied, as no route was matched.
+//
+// Parameters:
+//
+//	writer 		The response writer.
+//	request 	The incoming request.
+//	statusCode 	Either not found or method not allowed.
+func (router *routerBase) serveUnmatched(writer http.ResponseWriter, request *http.Request, statusCode int) {
+	parallelContext := parallel.NewContext()
+
+	log.Debugf("[%s] no route for %s %s: %d", parallelContext.ID, request.Method, request.URL.Path, statusCode)
+	writeResponse(parallelContext, unmatchedRouteResponse(parallelContext, request.URL.Path, statusCode), writer)
+}
+
+// Description:
+//
+//	Dispatches a router request to a route handler, wrapped with the global and the route middlewares.
+//	Recovers from panics, so that every request is answered.
+//
+// Parameters:
+//
+//	request The router request. Must have a parallel context.
+//	route 	The route.
+//	handler The registered handler function.
+//
+// Returns:
+//
+//	The router response.
+func (router *routerBase) dispatch(request *api.APIRequest, route *RouterRoute, handler RouterHandlerFunc) (response *api.APIResponse) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+
+		response = handlePanic(request.Parallel, request, recovered, router.reporters)
+	}()
+
+	response = router.chain(handler, route)(request)
+	if response == nil {
+		panic("router: handler returned no response")
+	}
+
+	return response
+}
+
+// Description:
+//
+//	Transforms an incoming HTTP request to a router request.
+//
+// Parameters:
+//
+//	pathHandle 	The registered path handle.
+//	request		The request to transform.
+//
+// Returns:
+//
+//	The transformed request, or an error, if the request could not be transformed.
+func transformRequest(pathHandle string, request *http.Request) (*api.APIRequest, error) {
+	result := api.APIRequest{
+		Url:               request.URL.String(),
+		Path:              request.URL.Path,
+		Method:            request.Method,
+		Headers:           make(map[string]string),
+		MultiValueHeaders: make(map[string][]string),
+		PathParameters:    make(map[string]string),
+		Context:           request.Context(),
+	}
+
+	for key, values := range request.Header {
+		result.Headers[key] = strings.Join(values, ",")
+		result.MultiValueHeaders[key] = values
+	}
+
+	pathParameters, err := extractPathParameters(pathHandle, request.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	result.PathParameters = pathParameters
+
+	queryParameters, err := extractQueryParameters(request.URL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	result.MultiValueQueryParameters = queryParameters
+	result.QueryParameters = api.JoinValues(queryParameters)
+
+	defer request.Body.Close()
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Body = string(body)
+	return &result, nil
+}
+
+// Description:
+//
+//	Extracts all path parameters using the registered path handle and the actual request path.
+//	A trailing catch-all segment, e.g. '*rest', captures the remaining path without leading slash.
+//
+// Example:
+//   - handle: 	/some/path/:variable/*rest
+//   - path:	/some/path/128/more/segments
+//   - result:	variable=128, rest=more/segments
+//
+// Parameters:
+//
+//	handle The registered path handle.
+//	path The actual request path.
+//
+// Returns:
+//
+//	A key-value map of the extracted path parameters.
+func extractPathParameters(handle string, path string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	path = strings.TrimPrefix(path, "/")
+	handle = strings.TrimPrefix(handle, "/")
+
+	pathSegments := strings.Split(path, "/")
+	handleSegments := strings.Split(handle, "/")
+
+	last := len(handleSegments) - 1
+	catchAll := strings.HasPrefix(handleSegments[last], "*")
+
+	if catchAll && len(pathSegments) > len(handleSegments) {
+		pathSegments = append(pathSegments[:last], strings.Join(pathSegments[last:], "/"))
+	}
+
+	if len(handleSegments) != len(pathSegments) {
+		return nil, fmt.Errorf("router: number of url segments does not match number of path segments")
+	}
+
+	for index, segment := range handleSegments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			result[strings.TrimPrefix(segment, ":")] = pathSegments[index]
+		case index == last && catchAll:
+			result[strings.TrimPrefix(segment, "*")] = pathSegments[index]
+		}
+	}
+
+	return result, nil
+}
+
+// Description:
+//
+//	Extracts all query parameters from the request path.
+//	Only '&' separates parameters, so that values may contain unescaped semicolons,
+//	as used by filter expressions. Parameters which cannot be unescaped are skipped.
+//
+// Parameters:
+//
+//	path The actual request path.
+//
+// Returns:
+//
+//	All values of the extracted query parameters.
+func extractQueryParameters(path string) (map[string][]string, error) {
+	parsedURL, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.ParseQuery(parsedURL.RawQuery), nil
+}
+
+// Description:
+//
+//	Writes a router response.
+//	Response bodies are encoded as JSON. If a body cannot be encoded, an internal server error is written instead.
+//	The content type defaults to JSON, unless set by the response headers, e.g. for problem details.
+//
+// Parameters:
+//
+//	context 	The parallel context of the request.
+//	response 	The response to write.
+//	writer 		The response writer.
+func writeResponse(context *parallel.Context, response *api.APIResponse, writer http.ResponseWriter) {
+	body, err := EncodeResponseBody(response)
+	if err != nil {
+		log.Errorf("[%s] cannot encode response body: %s", context.ID, err)
+
+		response = internalErrorResponse(context, "")
+		body, _ = EncodeResponseBody(response)
+	}
+
+	for key, value := range response.Headers {
+		writer.Header().Set(key, value)
+	}
+
+	if body != nil && writer.Header().Get("Content-Type") == "" {
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	writer.WriteHeader(response.StatusCode)
+
+	if body != nil {
+		writer.Write(body)
+	}
+}
+
+// Description:
+//
+//	Encodes the body of a router response as JSON.
+//
+// Parameters:
+//
+//	response The router response.
+//
+// Returns:
+//
+//	The encoded body, nil if the response has no body, or an error if the body cannot be encoded.
+func EncodeResponseBody(response *api.APIResponse) ([]byte, error) {
+	if response.Body == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(response.Body)
+}
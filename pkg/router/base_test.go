@@ -0,0 +1,117 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gostream-official/artists/pkg/api"
+)
+
+// Description:
+//
+//	Tests that a router shut down before it is started does not start serving.
+//
+// Parameters:
+//
+//	t The test context.
+func TestShutdownBeforeRun(t *testing.T) {
+	for _, engine := range engineNames() {
+		t.Run(engine, func(t *testing.T) {
+			router, err := New(engine)
+			if err != nil {
+				t.Fatalf("failed to create router: %s", err)
+			}
+
+			err = router.Shutdown(context.Background())
+			if err != nil {
+				t.Fatalf("failed to shut down router: %s", err)
+			}
+
+			err = router.Run(0)
+			if !errors.Is(err, http.ErrServerClosed) {
+				t.Errorf("expected %s, got %v", http.ErrServerClosed, err)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests that nil dependencies are rejected when a handler is registered, and other dependencies are accepted.
+//
+// Parameters:
+//
+//	t The test context.
+func TestHandleWithNilDependency(t *testing.T) {
+	type dependency struct{}
+
+	var nilInterface error
+	var nilSlice []string
+
+	tests := []struct {
+		name     string
+		register func(router Router)
+		rejected bool
+	}{
+		{"nil pointer", func(router Router) { registerDependency(router, (*dependency)(nil)) }, true},
+		{"nil map", func(router Router) { registerDependency(router, map[string]string(nil)) }, true},
+		{"nil interface", func(router Router) { registerDependency(router, nilInterface) }, true},
+		{"nil pointer in interface", func(router Router) { registerDependency[interface{}](router, (*dependency)(nil)) }, true},
+		{"nil function", func(router Router) { registerDependency(router, (func())(nil)) }, true},
+		{"nil channel", func(router Router) { registerDependency(router, (chan int)(nil)) }, true},
+		{"pointer", func(router Router) { registerDependency(router, &dependency{}) }, false},
+		{"map", func(router Router) { registerDependency(router, map[string]string{}) }, false},
+		{"struct", func(router Router) { registerDependency(router, dependency{}) }, false},
+		{"nil slice", func(router Router) { registerDependency(router, nilSlice) }, false},
+		{"zero value", func(router Router) { registerDependency(router, "") }, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router, err := New("http")
+			if err != nil {
+				t.Fatalf("failed to create router: %s", err)
+			}
+
+			defer func() {
+				recovered := recover()
+
+				if test.rejected && recovered == nil {
+					t.Errorf("expected registration to panic")
+				}
+
+				if !test.rejected && recovered != nil {
+					t.Errorf("expected registration to succeed, got panic: %v", recovered)
+				}
+
+				if test.rejected && recovered != nil && !strings.Contains(fmt.Sprint(recovered), "router: nil dependency for route GET /dependency") {
+					t.Errorf("unexpected panic: %v", recovered)
+				}
+			}()
+
+			test.register(router)
+		})
+	}
+}
+
+// Description:
+//
+//	Registers a handler receiving the given dependency.
+//
+// Parameters:
+//
+//	router 		The router to register the handler with.
+//	dependency 	The dependency passed to the handler.
+//
+// Type Parameters:
+//
+//	T The type of the dependency.
+func registerDependency[T interface{}](router Router, dependency T) {
+	HandleWith(router, "GET", "/dependency", dependency, func(request *api.APIRequest, dependency T) *api.APIResponse {
+		return &api.APIResponse{StatusCode: http.StatusNoContent}
+	})
+}
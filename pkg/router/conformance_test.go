@@ -0,0 +1,418 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gostream-official/artists/pkg/api"
+)
+
+// Description:
+//
+//	The request as seen by a route handler, echoed in the response body.
+type echo struct {
+
+	// The name of the route which handled the request.
+	Route string `json:"route"`
+
+	// The request method.
+	Method string `json:"method"`
+
+	// The request path.
+	Path string `json:"path"`
+
+	// The extracted path parameters.
+	PathParameters map[string]string `json:"pathParameters"`
+
+	// The extracted query parameters.
+	QueryParameters map[string][]string `json:"queryParameters"`
+
+	// The value of the 'X-Test' request header.
+	Header string `json:"header"`
+
+	// The request body.
+	Body string `json:"body"`
+
+	// The middlewares which ran before the handler, in order.
+	Middlewares []string `json:"middlewares"`
+}
+
+// Description:
+//
+//	Creates a route handler which echoes the request.
+//
+// Parameters:
+//
+//	route The name of the route.
+//
+// Returns:
+//
+//	The route handler.
+func echoHandler(route string) RouterHandlerFunc {
+	return func(request *api.APIRequest) *api.APIResponse {
+		middlewares, _ := request.Context.Value(middlewareKey{}).([]string)
+
+		return &api.APIResponse{
+			StatusCode: http.StatusOK,
+			Body: echo{
+				Route:           route,
+				Method:          request.Method,
+				Path:            request.Path,
+				PathParameters:  request.PathParameters,
+				QueryParameters: request.MultiValueQueryParameters,
+				Header:          request.Header("X-Test"),
+				Body:            request.Body,
+				Middlewares:     middlewares,
+			},
+		}
+	}
+}
+
+// Description:
+//
+//	The context key of the middlewares which ran for a request.
+type middlewareKey struct{}
+
+// Description:
+//
+//	Creates a middleware which records its name in the request context.
+//
+// Parameters:
+//
+//	name The name of the middleware.
+//
+// Returns:
+//
+//	The middleware.
+func recordingMiddleware(name string) Middleware {
+	return func(request *api.APIRequest, next RouterHandlerFunc) *api.APIResponse {
+		middlewares, _ := request.Context.Value(middlewareKey{}).([]string)
+		request.Context = context.WithValue(request.Context, middlewareKey{}, append(append([]string{}, middlewares...), name))
+
+		return next(request)
+	}
+}
+
+// Description:
+//
+//	The name under which the lambda router is tested.
+//	The lambda router is not a registered engine, as it does not serve HTTP.
+const lambdaEngine = "lambda"
+
+// Description:
+//
+//	Serves HTTP requests using a lambda router.
+//	Requests are converted as API Gateway would, i.e. with unescaped path and without path parameters.
+type lambdaHandler struct {
+
+	// The lambda router to dispatch requests to.
+	router *LambdaRouter
+}
+
+// Description:
+//
+//	Converts the request, dispatches it to the lambda router and writes the response.
+//
+// Parameters:
+//
+//	writer 	The response writer.
+//	request The request to serve.
+func (handler lambdaHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	body, _ := io.ReadAll(request.Body)
+	queryParameters, _ := extractQueryParameters(request.URL.String())
+
+	dispatched := &api.APIRequest{
+		Url:                       request.URL.String(),
+		Path:                      request.URL.Path,
+		Method:                    request.Method,
+		Headers:                   make(map[string]string),
+		MultiValueHeaders:         request.Header,
+		QueryParameters:           api.JoinValues(queryParameters),
+		MultiValueQueryParameters: queryParameters,
+		Body:                      string(body),
+		Context:                   request.Context(),
+	}
+
+	for key, values := range request.Header {
+		dispatched.Headers[key] = strings.Join(values, ",")
+	}
+
+	response := handler.router.Dispatch(dispatched)
+	writeResponse(dispatched.Parallel, response, writer)
+}
+
+// Description:
+//
+//	Creates a router of the given engine with the conformance routes registered.
+//
+// Parameters:
+//
+//	t 		The test context.
+//	engine 	The router engine.
+//
+// Returns:
+//
+//	The router, as HTTP handler.
+func newConformanceRouter(t *testing.T, engine string) http.Handler {
+	t.Helper()
+
+	var router Router
+	var lambda *LambdaRouter
+
+	if engine == lambdaEngine {
+		lambda = NewLambdaRouter()
+		router = lambda
+	} else {
+		var err error
+
+		router, err = New(engine)
+		if err != nil {
+			t.Fatalf("failed to create router: %s", err)
+		}
+	}
+
+	router.Use(recordingMiddleware("global"))
+
+	router.Handle("GET", "/artists", echoHandler("list"))
+	router.Handle("POST", "/artists", echoHandler("create"))
+	router.Handle("GET", "/artists/search", echoHandler("search"))
+	router.Handle("GET", "/artists/:id", echoHandler("get")).Use(recordingMiddleware("route"))
+	router.Handle("DELETE", "/artists/:id", echoHandler("delete"))
+	router.Handle("GET", "/artists/:id/albums/:album", echoHandler("album"))
+	router.Handle("GET", "/files/*rest", echoHandler("files"))
+	router.Handle("GET", "/panic", func(request *api.APIRequest) *api.APIResponse {
+		panic("conformance")
+	})
+	router.Handle("GET", "/empty", func(request *api.APIRequest) *api.APIResponse {
+		return nil
+	})
+
+	HandleWith(router, "GET", "/injected", "dependency", func(request *api.APIRequest, dependency string) *api.APIResponse {
+		return &api.APIResponse{StatusCode: http.StatusOK, Body: echo{Route: dependency}}
+	})
+
+	if lambda != nil {
+		return lambdaHandler{router: lambda}
+	}
+
+	handler, ok := router.(http.Handler)
+	if !ok {
+		t.Fatalf("router engine '%s' does not implement http.Handler", engine)
+	}
+
+	return handler
+}
+
+// Description:
+//
+//	Tests that all router engines behave identically, including the lambda router.
+//
+// Parameters:
+//
+//	t The test context.
+func TestRouterConformance(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		header      string
+		body        string
+		status      int
+		contentType string
+		expected    *echo
+	}{
+		{
+			name:        "static route",
+			method:      "GET",
+			target:      "/artists",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			expected:    &echo{Route: "list", Method: "GET", Path: "/artists", PathParameters: map[string]string{}, QueryParameters: map[string][]string{}, Middlewares: []string{"global"}},
+		},
+		{
+			name:     "static route takes precedence over path variable",
+			method:   "GET",
+			target:   "/artists/search",
+			status:   http.StatusOK,
+			expected: &echo{Route: "search", Method: "GET", Path: "/artists/search", PathParameters: map[string]string{}, QueryParameters: map[string][]string{}, Middlewares: []string{"global"}},
+		},
+		{
+			name:     "path variable",
+			method:   "GET",
+			target:   "/artists/42",
+			status:   http.StatusOK,
+			expected: &echo{Route: "get", Method: "GET", Path: "/artists/42", PathParameters: map[string]string{"id": "42"}, QueryParameters: map[string][]string{}, Middlewares: []string{"global", "route"}},
+		},
+		{
+			name:     "escaped path variable",
+			method:   "GET",
+			target:   "/artists/daft%20punk",
+			status:   http.StatusOK,
+			expected: &echo{Route: "get", Method: "GET", Path: "/artists/daft punk", PathParameters: map[string]string{"id": "daft punk"}, QueryParameters: map[string][]string{}, Middlewares: []string{"global", "route"}},
+		},
+		{
+			name:     "multiple path variables",
+			method:   "GET",
+			target:   "/artists/42/albums/7",
+			status:   http.StatusOK,
+			expected: &echo{Route: "album", Method: "GET", Path: "/artists/42/albums/7", PathParameters: map[string]string{"id": "42", "album": "7"}, QueryParameters: map[string][]string{}, Middlewares: []string{"global"}},
+		},
+		{
+			name:     "catch-all with single segment",
+			method:   "GET",
+			target:   "/files/cover.png",
+			status:   http.StatusOK,
+			expected: &echo{Route: "files", Method: "GET", Path: "/files/cover.png", PathParameters: map[string]string{"rest": "cover.png"}, QueryParameters: map[string][]string{}, Middlewares: []string{"global"}},
+		},
+		{
+			name:     "catch-all with multiple segments",
+			method:   "GET",
+			target:   "/files/covers/2023/discovery.png",
+			status:   http.StatusOK,
+			expected: &echo{Route: "files", Method: "GET", Path: "/files/covers/2023/discovery.png", PathParameters: map[string]string{"rest": "covers/2023/discovery.png"}, QueryParameters: map[string][]string{}, Middlewares: []string{"global"}},
+		},
+		{
+			name:     "catch-all without remaining path",
+			method:   "GET",
+			target:   "/files/",
+			status:   http.StatusOK,
+			expected: &echo{Route: "files", Method: "GET", Path: "/files/", PathParameters: map[string]string{"rest": ""}, QueryParameters: map[string][]string{}, Middlewares: []string{"global"}},
+		},
+		{
+			name:     "catch-all with trailing slash",
+			method:   "GET",
+			target:   "/files/covers/",
+			status:   http.StatusOK,
+			expected: &echo{Route: "files", Method: "GET", Path: "/files/covers/", PathParameters: map[string]string{"rest": "covers/"}, QueryParameters: map[string][]string{}, Middlewares: []string{"global"}},
+		},
+		{
+			name:   "query parameters",
+			method: "GET",
+			target: "/artists?genre=rock&genre=house&filter=name:eq:a;b&name=daft%20punk&empty=",
+			status: http.StatusOK,
+			expected: &echo{Route: "list", Method: "GET", Path: "/artists", PathParameters: map[string]string{}, QueryParameters: map[string][]string{
+				"genre":  {"rock", "house"},
+				"filter": {"name:eq:a;b"},
+				"name":   {"daft punk"},
+				"empty":  {""},
+			}, Middlewares: []string{"global"}},
+		},
+		{
+			name:     "headers and body",
+			method:   "POST",
+			target:   "/artists",
+			header:   "conformance",
+			body:     `{"name": "Daft Punk"}`,
+			status:   http.StatusOK,
+			expected: &echo{Route: "create", Method: "POST", Path: "/artists", PathParameters: map[string]string{}, QueryParameters: map[string][]string{}, Header: "conformance", Body: `{"name": "Daft Punk"}`, Middlewares: []string{"global"}},
+		},
+		{
+			name:        "unknown path",
+			method:      "GET",
+			target:      "/albums",
+			status:      http.StatusNotFound,
+			contentType: "application/problem+json",
+		},
+		{
+			name:        "unknown nested path",
+			method:      "GET",
+			target:      "/artists/42/tracks",
+			status:      http.StatusNotFound,
+			contentType: "application/problem+json",
+		},
+		{
+			name:        "method not allowed",
+			method:      "PUT",
+			target:      "/artists/42",
+			status:      http.StatusMethodNotAllowed,
+			contentType: "application/problem+json",
+		},
+		{
+			name:        "panic",
+			method:      "GET",
+			target:      "/panic",
+			status:      http.StatusInternalServerError,
+			contentType: "application/problem+json",
+		},
+		{
+			name:        "no response",
+			method:      "GET",
+			target:      "/empty",
+			status:      http.StatusInternalServerError,
+			contentType: "application/problem+json",
+		},
+		{
+			name:     "injected dependency",
+			method:   "GET",
+			target:   "/injected",
+			status:   http.StatusOK,
+			expected: &echo{Route: "dependency"},
+		},
+	}
+
+	for _, engine := range append(engineNames(), lambdaEngine) {
+		handler := newConformanceRouter(t, engine)
+
+		for _, test := range tests {
+			t.Run(engine+"/"+test.name, func(t *testing.T) {
+				request := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+				if test.header != "" {
+					request.Header.Set("X-Test", test.header)
+				}
+
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, request)
+
+				if recorder.Code != test.status {
+					t.Fatalf("expected status %d, got %d: %s", test.status, recorder.Code, recorder.Body)
+				}
+
+				contentType := recorder.Header().Get("Content-Type")
+				if test.contentType != "" && !strings.HasPrefix(contentType, test.contentType) {
+					t.Errorf("expected content type '%s', got '%s'", test.contentType, contentType)
+				}
+
+				if test.expected == nil {
+					return
+				}
+
+				actual := echo{}
+
+				err := json.Unmarshal(recorder.Body.Bytes(), &actual)
+				if err != nil {
+					t.Fatalf("failed to decode response body: %s", err)
+				}
+
+				if !reflect.DeepEqual(actual, *test.expected) {
+					t.Errorf("expected %+v, got %+v", *test.expected, actual)
+				}
+			})
+		}
+	}
+}
+
+// Description:
+//
+//	Lists the names of all registered router engines, in alphabetical order.
+//	Engines excluded by build tags are not listed.
+//
+// Returns:
+//
+//	The names of the registered router engines.
+func engineNames() []string {
+	names := make([]string, 0, len(engines))
+
+	for name := range engines {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -1,11 +1,9 @@
+//go:build !nogin
+
 package router
 
 import (
-	"fmt"
-	"io"
 	"net/http"
-	"net/url"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gostream-official/artists/pkg/api"
@@ -15,6 +13,7 @@ import (
 //
 //	Implementation of the Router interface for gin.
 type GinRouter struct {
+	routerBase
 
 	// The gin engine.
 	engine *gin.Engine
@@ -23,9 +22,13 @@ type GinRouter struct {
 // Description:
 //
 //	Package initializer.
-//	Sets gin to release mode.
+//	Sets gin to release mode and registers the gin router engine.
 func init() {
 	gin.SetMode(gin.ReleaseMode)
+
+	registerEngine("gin", func() Router {
+		return NewGinRouter()
+	})
 }
 
 // Description:
@@ -39,11 +42,25 @@ func NewGinRouter() *GinRouter {
 	engine := gin.New()
 
 	engine.RedirectTrailingSlash = true
-	engine.RedirectFixedPath = true
+	engine.HandleMethodNotAllowed = true
+
+	// Fixed path redirects panic in gin for unknown paths below a path variable with static siblings,
+	// e.g. '/artists/:id/tracks' next to '/artists/search'. The standard library router does not redirect either.
+	engine.RedirectFixedPath = false
 
-	return &GinRouter{
+	router := &GinRouter{
 		engine: engine,
 	}
+
+	engine.NoRoute(func(context *gin.Context) {
+		router.serveUnmatched(context.Writer, context.Request, http.StatusNotFound)
+	})
+
+	engine.NoMethod(func(context *gin.Context) {
+		router.serveUnmatched(context.Writer, context.Request, http.StatusMethodNotAllowed)
+	})
+
+	return router
 }
 
 // Description:
@@ -56,10 +73,18 @@ func NewGinRouter() *GinRouter {
 //	method 	The http method to handle.
 //	path   	The path to handle.
 //	handler	The handler responsible for handling the request.
-func (router *GinRouter) Handle(method string, path string, handler RouterHandlerFunc) {
+//
+// Returns:
+//
+//	The route which allows registering route middlewares.
+func (router *GinRouter) Handle(method string, path string, handler RouterHandlerFunc) *RouterRoute {
+	route := &RouterRoute{}
+
 	router.engine.Handle(method, path, func(context *gin.Context) {
-		internalRouteHandler(path, context, handler)
+		router.serve(path, context.Writer, context.Request, route, handler)
 	})
+
+	return route
 }
 
 // Description:
@@ -79,10 +104,12 @@ func (router *GinRouter) Handle(method string, path string, handler RouterHandle
 //
 //	The router injector which allows object injection for the registered endpoint.
 func (router *GinRouter) HandleWith(method string, path string, handler RouterInjectionHandlerFunc) *RouterInjector {
-	injector := &RouterInjector{}
+	injector := router.inject(method, path)
 
 	router.engine.Handle(method, path, func(context *gin.Context) {
-		internalRouteInjectionHandler(path, context, handler, injector)
+		router.serve(path, context.Writer, context.Request, &injector.RouterRoute, func(request *api.APIRequest) *api.APIResponse {
+			return handler(request, injector.Injector)
+		})
 	})
 
 	return injector
@@ -92,211 +119,24 @@ func (router *GinRouter) HandleWith(method string, path string, handler RouterIn
 //
 //	Starts the HTTP server for this router and listens to all registered routes.
 //
-// Returns:
-//
-//	An error if serving the router fails.
-func (router *GinRouter) Run(port uint16) error {
-	portFmt := fmt.Sprintf(":%d", port)
-
-	// server := &http.Server{
-	// 	Addr:    portFmt,
-	// 	Handler: router.engine,
-	// }
-
-	// // Setting this to false apparently reduces memory usage.
-	// // However, setting this to true apparently is the standard and improves performance.
-	// server.SetKeepAlivesEnabled(true)
-
-	// return server.ListenAndServe()
-
-	return router.engine.Run(portFmt)
-}
-
-// Description:
-//
-//	Internal handler method for incoming requests.
-//	Triggered by the gin framework.
-//
-// Parameters:
-//
-//	pathHandle 	The registered path handle.
-//	context 	The internal gin context.
-//	handler 	The registered handler function.
-func internalRouteHandler(pathHandle string, context *gin.Context, handler RouterHandlerFunc) {
-	request := context.Request
-
-	internalRequest, err := transformRequest(pathHandle, request)
-
-	if err != nil {
-		panic("router: cannot transform request")
-	}
-
-	internalResponse := handler(internalRequest)
-	applyResponse(internalResponse, context)
-}
-
-// Description:
-//
-//	Internal handler method for incoming requests.
-//	Allows object injection for the route handler.
-//	Triggered by the gin framework.
-//
-// Parameters:
-//
-//	pathHandle 	The registered path handle.
-//	context 	The internal gin context.
-//	handler 	The registered handler function.
-//	injector	The router injector.
-func internalRouteInjectionHandler(pathHandle string, context *gin.Context, handler RouterInjectionHandlerFunc, injector *RouterInjector) {
-	request := context.Request
-
-	internalRequest, err := transformRequest(pathHandle, request)
-
-	if err != nil {
-		panic("router: cannot transform request")
-	}
-
-	internalResponse := handler(internalRequest, injector.Injector)
-	applyResponse(internalResponse, context)
-}
-
-// Description:
-//
-//	Transforms an incoming HTTP request to a router request.
-//
-// Parameters:
-//
-//	pathHandle 	The registered path handle.
-//	request		The request to transform.
+//	Fails without serving, if a route registered with object injection has no object injected.
 //
 // Returns:
 //
-//	The transformed request, or an error, if the request could not be transformed.
-func transformRequest(pathHandle string, request *http.Request) (*api.APIRequest, error) {
-	result := api.APIRequest{
-		Url:             request.URL.String(),
-		Path:            request.URL.Path,
-		Method:          request.Method,
-		Headers:         make(map[string]string),
-		PathParameters:  make(map[string]string),
-		QueryParameters: make(map[string]string),
-	}
-
-	for key, values := range request.Header {
-		result.Headers[key] = strings.Join(values, ",")
-	}
-
-	pathParameters, err := extractPathParameters(pathHandle, request.URL.Path)
-	if err != nil {
-		return nil, err
-	}
-
-	result.PathParameters = pathParameters
-
-	queryParameters, err := extractQueryParameters(request.URL.String())
-	if err != nil {
-		return nil, err
-	}
-
-	result.QueryParameters = queryParameters
-
-	defer request.Body.Close()
-
-	body, err := io.ReadAll(request.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	result.Body = string(body)
-	return &result, nil
-}
-
-// Description:
-//
-//	Extracts all path parameters using the registered path handle and the actual request path.
-//
-// Example:
-//   - handle: 	/some/path/:variable
-//   - path:	/some/path/128
-//
-// Parameters:
-//
-//	handle The registered path handle.
-//	path The actual request path.
-//
-// Returns:
-//
-//	A key-value map of the extracted path parameters.
-func extractPathParameters(handle string, path string) (map[string]string, error) {
-	result := make(map[string]string)
-
-	path = strings.TrimPrefix(path, "/")
-	handle = strings.TrimPrefix(handle, "/")
-
-	pathSegments := strings.Split(path, "/")
-	handleSegments := strings.Split(handle, "/")
-
-	if len(handleSegments) != len(pathSegments) {
-		return nil, fmt.Errorf("router: number of url segments does not match number of path segments")
-	}
-
-	for index, segment := range handleSegments {
-		if !strings.HasPrefix(segment, ":") {
-			continue
-		}
-
-		paramName := strings.TrimPrefix(segment, ":")
-		result[paramName] = pathSegments[index]
-	}
-
-	return result, nil
-}
-
-// Description:
-//
-//	Extracts all query parameters from the request path.
-//
-// Parameters:
-//
-//	path The actual request path.
-//
-// Returns:
-//
-//	A key-value map of the extracted query parameters.
-func extractQueryParameters(path string) (map[string]string, error) {
-	parameters := make(map[string]string)
-
-	parsedURL, err := url.Parse(path)
-	if err != nil {
-		return nil, err
-	}
-
-	query := parsedURL.Query()
-
-	for key, values := range query {
-		parameters[key] = strings.Join(values, ",")
-	}
-
-	return parameters, nil
+//	An error if serving the router fails. Nil, if the router was shut down while serving.
+//	http.ErrServerClosed, if the router was shut down before it was started.
+func (router *GinRouter) Run(port uint16) error {
+	return router.listen(port, router.engine)
 }
 
 // Description:
 //
-//	Applies a router response to the internal gin context.
+//	Serves a request using the gin engine.
 //
 // Parameters:
 //
-//	response 	The response to apply.
-//	context 	The gin context.
-func applyResponse(response *api.APIResponse, context *gin.Context) {
-	for key, value := range response.Headers {
-		context.Header(key, value)
-	}
-
-	if response.Body == nil {
-		context.Status(response.StatusCode)
-		return
-	}
-
-	context.JSON(response.StatusCode, response.Body)
+//	writer 	The response writer.
+//	request The incoming request.
+func (router *GinRouter) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	router.engine.ServeHTTP(writer, request)
 }
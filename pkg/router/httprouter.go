@@ -0,0 +1,232 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gostream-official/artists/pkg/api"
+)
+
+// Description:
+//
+//	Implementation of the Router interface for the standard library HTTP request multiplexer.
+type HTTPRouter struct {
+	routerBase
+
+	// The HTTP request multiplexer.
+	mux *http.ServeMux
+}
+
+// Description:
+//
+//	Package initializer.
+//	Registers the standard library router engine.
+func init() {
+	registerEngine("http", func() Router {
+		return NewHTTPRouter()
+	})
+}
+
+// Description:
+//
+//	Creates a new standard library router.
+//
+// Returns:
+//
+//	The created standard library router.
+func NewHTTPRouter() *HTTPRouter {
+	return &HTTPRouter{
+		mux: http.NewServeMux(),
+	}
+}
+
+// Description:
+//
+//	Registers a new HTTP handler function for the given method and path.
+//	Paths can include wildcards and path variables.
+//
+// Parameters:
+//
+//	method 	The http method to handle.
+//	path   	The path to handle.
+//	handler	The handler responsible for handling the request.
+//
+// Returns:
+//
+//	The route which allows registering route middlewares.
+func (router *HTTPRouter) Handle(method string, path string, handler RouterHandlerFunc) *RouterRoute {
+	route := &RouterRoute{}
+
+	router.mux.HandleFunc(toServeMuxPattern(method, path), func(writer http.ResponseWriter, request *http.Request) {
+		router.serve(path, writer, request, route, handler)
+	})
+
+	return route
+}
+
+// Description:
+//
+//	Registers a new HTTP handler function for the given method and path.
+//	Paths can include wildcards and path variables.
+//
+//	This method allows object injection for the router handler.
+//
+// Parameters:
+//
+//	method 	The http method to handle.
+//	path   	The path to handle.
+//	handler	The handler responsible for handling the request.
+//
+// Returns:
+//
+//	The router injector which allows object injection for the registered endpoint.
+func (router *HTTPRouter) HandleWith(method string, path string, handler RouterInjectionHandlerFunc) *RouterInjector {
+	injector := router.inject(method, path)
+
+	router.mux.HandleFunc(toServeMuxPattern(method, path), func(writer http.ResponseWriter, request *http.Request) {
+		router.serve(path, writer, request, &injector.RouterRoute, func(request *api.APIRequest) *api.APIResponse {
+			return handler(request, injector.Injector)
+		})
+	})
+
+	return injector
+}
+
+// Description:
+//
+//	Starts the HTTP server for this router and listens to all registered routes.
+//
+//	Fails without serving, if a route registered with object injection has no object injected.
+//
+// Returns:
+//
+//	An error if serving the router fails. Nil, if the router was shut down while serving.
+//	http.ErrServerClosed, if the router was shut down before it was started.
+func (router *HTTPRouter) Run(port uint16) error {
+	return router.listen(port, router)
+}
+
+// Description:
+//
+//	Serves a request using the request multiplexer.
+//	Requests which do not match any route are answered with problem details
+//	instead of the plain text responses of the request multiplexer.
+//
+// Parameters:
+//
+//	writer 	The response writer.
+//	request The incoming request.
+func (router *HTTPRouter) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	handler, pattern := router.mux.Handler(request)
+	if pattern != "" {
+		router.mux.ServeHTTP(writer, request)
+		return
+	}
+
+	recorder := &statusRecorder{
+		header: make(http.Header),
+	}
+
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.statusCode != http.StatusNotFound && recorder.statusCode != http.StatusMethodNotAllowed {
+		router.mux.ServeHTTP(writer, request)
+		return
+	}
+
+	allow := recorder.header.Get("Allow")
+	if allow != "" {
+		writer.Header().Set("Allow", allow)
+	}
+
+	router.serveUnmatched(writer, request, recorder.statusCode)
+}
+
+// Description:
+//
+//	A response writer which only records the headers and the status code.
+//	Used to determine why the request multiplexer rejected a request.
+type statusRecorder struct {
+
+	// The recorded headers.
+	header http.Header
+
+	// The recorded status code.
+	statusCode int
+}
+
+// Description:
+//
+//	Returns the recorded headers.
+//
+// Returns:
+//
+//	The recorded headers.
+func (recorder *statusRecorder) Header() http.Header {
+	return recorder.header
+}
+
+// Description:
+//
+//	Discards the response body.
+//
+// Parameters:
+//
+//	body The response body.
+//
+// Returns:
+//
+//	The length of the body, as if it was written.
+func (recorder *statusRecorder) Write(body []byte) (int, error) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = http.StatusOK
+	}
+
+	return len(body), nil
+}
+
+// Description:
+//
+//	Records the status code.
+//
+// Parameters:
+//
+//	statusCode The status code.
+func (recorder *statusRecorder) WriteHeader(statusCode int) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = statusCode
+	}
+}
+
+// Description:
+//
+//	Converts a method and a path handle into a request multiplexer pattern.
+//
+// Example:
+//   - method:	GET
+//   - handle:	/some/path/:variable/*rest
+//   - pattern:	GET /some/path/{variable}/{rest...}
+//
+// Parameters:
+//
+//	method 	The http method.
+//	handle 	The path handle.
+//
+// Returns:
+//
+//	The request multiplexer pattern.
+func toServeMuxPattern(method string, handle string) string {
+	segments := strings.Split(handle, "/")
+
+	for index, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			segments[index] = fmt.Sprintf("{%s}", strings.TrimPrefix(segment, ":"))
+		case strings.HasPrefix(segment, "*"):
+			segments[index] = fmt.Sprintf("{%s...}", strings.TrimPrefix(segment, "*"))
+		}
+	}
+
+	return fmt.Sprintf("%s %s", method, strings.Join(segments, "/"))
+}
@@ -0,0 +1,307 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/parallel"
+)
+
+// Description:
+//
+//	Implementation of the Router interface for serverless environments, e.g. AWS Lambda.
+//	Does not listen on a port, but dispatches already decoded requests to the registered handlers.
+type LambdaRouter struct {
+	routerBase
+
+	// The registered routes, in registration order.
+	routes []lambdaRoute
+}
+
+// Description:
+//
+//	A route registered with the lambda router.
+type lambdaRoute struct {
+
+	// The http method of the route.
+	method string
+
+	// The path segments of the route.
+	segments []string
+
+	// The route.
+	route *RouterRoute
+
+	// The route handler.
+	handler RouterHandlerFunc
+}
+
+// Description:
+//
+//	Creates a new lambda router.
+//
+// Returns:
+//
+//	The created lambda router.
+func NewLambdaRouter() *LambdaRouter {
+	return &LambdaRouter{}
+}
+
+// Description:
+//
+//	Registers a new HTTP handler function for the given method and path.
+//	Paths can include path variables.
+//
+// Parameters:
+//
+//	method 	The http method to handle.
+//	path   	The path to handle.
+//	handler	The handler responsible for handling the request.
+//
+// Returns:
+//
+//	The route which allows registering route middlewares.
+func (router *LambdaRouter) Handle(method string, path string, handler RouterHandlerFunc) *RouterRoute {
+	route := &RouterRoute{}
+
+	router.routes = append(router.routes, lambdaRoute{
+		method:   method,
+		segments: splitPath(path),
+		route:    route,
+		handler:  handler,
+	})
+
+	return route
+}
+
+// Description:
+//
+//	Registers a new HTTP handler function for the given method and path.
+//	Paths can include path variables.
+//
+//	This method allows object injection for the router handler.
+//
+// Parameters:
+//
+//	method 	The http method to handle.
+//	path   	The path to handle.
+//	handler	The handler responsible for handling the request.
+//
+// Returns:
+//
+//	The router injector which allows object injection for the registered endpoint.
+func (router *LambdaRouter) HandleWith(method string, path string, handler RouterInjectionHandlerFunc) *RouterInjector {
+	injector := router.inject(method, path)
+
+	router.routes = append(router.routes, lambdaRoute{
+		method:   method,
+		segments: splitPath(path),
+		route:    &injector.RouterRoute,
+		handler: func(request *api.APIRequest) *api.APIResponse {
+			return handler(request, injector.Injector)
+		},
+	})
+
+	return injector
+}
+
+// Description:
+//
+//	Checks whether an object was injected for every route registered with object injection.
+//	The lambda router does not listen on a port, requests are passed to Dispatch instead.
+//
+// Returns:
+//
+//	An error naming the first route without injected object.
+func (router *LambdaRouter) Run(port uint16) error {
+	return checkInjections(router.injections)
+}
+
+// Description:
+//
+//	Shuts down the lambda router. Does nothing, as the lambda router does not listen on a port.
+//
+// Parameters:
+//
+//	ctx The shutdown context.
+//
+// Returns:
+//
+//	Always nil.
+func (router *LambdaRouter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// Description:
+//
+//	Dispatches a request to the handler of the best matching route.
+//	Static path segments take precedence over path variables, e.g. '/artists/search'
+//	is preferred over '/artists/:id', and path variables over catch-all segments.
+//	Path parameters are extracted from the matched route.
+//
+// Parameters:
+//
+//	request The request to dispatch.
+//
+// Returns:
+//
+//	The response of the route handler, or a not found or method not allowed response.
+func (router *LambdaRouter) Dispatch(request *api.APIRequest) *api.APIResponse {
+	if request.Parallel == nil {
+		request.Parallel = parallel.NewContext()
+	}
+
+	if request.Context == nil {
+		request.Context = context.Background()
+	}
+
+	segments := splitPath(request.Path)
+
+	var match *lambdaRoute
+	var matchParameters map[string]string
+
+	pathMatched := false
+
+	for index := range router.routes {
+		candidate := &router.routes[index]
+
+		parameters, ok := matchSegments(candidate.segments, segments)
+		if !ok {
+			continue
+		}
+
+		pathMatched = true
+
+		if candidate.method != request.Method {
+			continue
+		}
+
+		if match == nil || moreSpecific(candidate.segments, match.segments) {
+			match = candidate
+			matchParameters = parameters
+		}
+	}
+
+	if match == nil {
+		statusCode := http.StatusNotFound
+		if pathMatched {
+			statusCode = http.StatusMethodNotAllowed
+		}
+
+		return unmatchedRouteResponse(request.Parallel, request.Path, statusCode)
+	}
+
+	request.PathParameters = matchParameters
+	return router.dispatch(request, match.route, match.handler)
+}
+
+// Description:
+//
+//	Splits a path into its segments.
+//
+// Parameters:
+//
+//	path The path to split.
+//
+// Returns:
+//
+//	The path segments.
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+// Description:
+//
+//	Matches path segments against the segments of a route.
+//	A trailing catch-all segment, e.g. '*rest', matches the remaining path, which may be empty.
+//
+// Parameters:
+//
+//	handle 	The route segments. Segments prefixed with ':' are path variables, with '*' catch-all segments.
+//	path 	The path segments.
+//
+// Returns:
+//
+//	The extracted path parameters and whether the path matches the route.
+func matchSegments(handle []string, path []string) (map[string]string, bool) {
+	last := len(handle) - 1
+	catchAll := strings.HasPrefix(handle[last], "*")
+
+	if catchAll && len(path) > len(handle) {
+		path = append(append([]string{}, path[:last]...), strings.Join(path[last:], "/"))
+	}
+
+	if len(handle) != len(path) {
+		return nil, false
+	}
+
+	parameters := make(map[string]string)
+
+	for index, segment := range handle {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			if path[index] == "" {
+				return nil, false
+			}
+
+			parameters[strings.TrimPrefix(segment, ":")] = path[index]
+		case index == last && catchAll:
+			parameters[strings.TrimPrefix(segment, "*")] = path[index]
+		case segment != path[index]:
+			return nil, false
+		}
+	}
+
+	return parameters, true
+}
+
+// Description:
+//
+//	Checks whether a route is more specific than another route matching the same path,
+//	i.e. whether its first differing segment is static while the other one is a path variable
+//	or a catch-all segment, or a path variable while the other one is a catch-all segment.
+//
+// Parameters:
+//
+//	handle 	The route segments.
+//	other 	The route segments to compare with.
+//
+// Returns:
+//
+//	Whether the route is more specific.
+func moreSpecific(handle []string, other []string) bool {
+	for index := 0; index < len(handle) && index < len(other); index++ {
+		kind := segmentKind(handle[index])
+		otherKind := segmentKind(other[index])
+
+		if kind != otherKind {
+			return kind < otherKind
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Ranks a route segment by how specific it is.
+//
+// Parameters:
+//
+//	segment The route segment.
+//
+// Returns:
+//
+//	0 for static segments, 1 for path variables and 2 for catch-all segments.
+func segmentKind(segment string) int {
+	switch {
+	case strings.HasPrefix(segment, ":"):
+		return 1
+	case strings.HasPrefix(segment, "*"):
+		return 2
+	}
+
+	return 0
+}
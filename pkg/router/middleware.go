@@ -0,0 +1,58 @@
+package router
+
+import (
+	"time"
+
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	Function definition for router middlewares.
+//	A middleware wraps the remaining handler chain. It may modify the request before calling next,
+//	modify the response returned by next, or short-circuit by returning a response without calling next.
+type Middleware = func(request *api.APIRequest, next RouterHandlerFunc) *api.APIResponse
+
+// Description:
+//
+//	Wraps a handler with the given middlewares.
+//	The first middleware is the outermost one, i.e. it runs first and sees the final response.
+//
+// Parameters:
+//
+//	handler 	The handler to wrap.
+//	middlewares The middlewares to wrap the handler with.
+//
+// Returns:
+//
+//	The wrapped handler.
+func Chain(handler RouterHandlerFunc, middlewares ...Middleware) RouterHandlerFunc {
+	for index := len(middlewares) - 1; index >= 0; index-- {
+		middleware := middlewares[index]
+		next := handler
+
+		handler = func(request *api.APIRequest) *api.APIResponse {
+			return middleware(request, next)
+		}
+	}
+
+	return handler
+}
+
+// Description:
+//
+//	Creates a middleware which logs the status code and duration of every request.
+//
+// Returns:
+//
+//	The access log middleware.
+func AccessLog() Middleware {
+	return func(request *api.APIRequest, next RouterHandlerFunc) *api.APIResponse {
+		start := time.Now()
+		response := next(request)
+
+		log.Infof("[%s] %s %s: %d (%s)", request.Parallel.ID, request.Method, request.Path, response.StatusCode, time.Since(start))
+		return response
+	}
+}
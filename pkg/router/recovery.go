@@ -0,0 +1,137 @@
+package router
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/parallel"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	Function definition for panic reporters.
+//	Panic reporters are notified about every panic recovered while handling a request,
+//	e.g. to forward them to an error tracking service.
+type PanicReporter = func(request *api.APIRequest, recovered interface{}, stack []byte)
+
+// Description:
+//
+//	Handles a panic recovered while handling a request.
+//	Logs the stack trace and notifies all panic reporters.
+//
+// Parameters:
+//
+//	context 	The parallel context of the request.
+//	request 	The request.
+//	recovered 	The recovered panic value.
+//	reporters 	The panic reporters to notify.
+//
+// Returns:
+//
+//	The internal server error response.
+func handlePanic(context *parallel.Context, request *api.APIRequest, recovered interface{}, reporters []PanicReporter) *api.APIResponse {
+	stack := debug.Stack()
+
+	log.Errorf("[%s] recovered from panic: %v\n%s", context.ID, recovered, stack)
+
+	for _, reporter := range reporters {
+		reportPanic(context, reporter, request, recovered, stack)
+	}
+
+	return internalErrorResponse(context, request.Path)
+}
+
+// Description:
+//
+//	Notifies a single panic reporter. A panicking reporter does not affect the response.
+//
+// Parameters:
+//
+//	context 	The parallel context of the request.
+//	reporter 	The panic reporter to notify.
+//	request 	The request.
+//	recovered 	The recovered panic value.
+//	stack 		The stack trace of the panic.
+func reportPanic(context *parallel.Context, reporter PanicReporter, request *api.APIRequest, recovered interface{}, stack []byte) {
+	defer func() {
+		if reporterPanic := recover(); reporterPanic != nil {
+			log.Errorf("[%s] panic reporter failed: %v", context.ID, reporterPanic)
+		}
+	}()
+
+	reporter(request, recovered, stack)
+}
+
+// Description:
+//
+//	Creates the response for requests which cannot be transformed, e.g. due to a malformed path.
+//
+// Parameters:
+//
+//	context The parallel context of the request.
+//	path 	The request path.
+//
+// Returns:
+//
+//	The bad request response.
+func malformedRequestResponse(context *parallel.Context, path string) *api.APIResponse {
+	return routerProblemResponse(context, path, http.StatusBadRequest, "malformed request")
+}
+
+// Description:
+//
+//	Creates the response for requests which failed unexpectedly.
+//
+// Parameters:
+//
+//	context The parallel context of the request.
+//	path 	The request path.
+//
+// Returns:
+//
+//	The internal server error response.
+func internalErrorResponse(context *parallel.Context, path string) *api.APIResponse {
+	return routerProblemResponse(context, path, http.StatusInternalServerError, "")
+}
+
+// Description:
+//
+//	Creates the response for requests which do not match any route.
+//
+// Parameters:
+//
+//	context 	The parallel context of the request.
+//	path 		The request path.
+//	statusCode 	Either not found or method not allowed.
+//
+// Returns:
+//
+//	The not found or method not allowed response.
+func unmatchedRouteResponse(context *parallel.Context, path string, statusCode int) *api.APIResponse {
+	return routerProblemResponse(context, path, statusCode, "")
+}
+
+// Description:
+//
+//	Creates a problem response for requests which failed within the router.
+//
+// Parameters:
+//
+//	context 	The parallel context of the request.
+//	path 		The request path.
+//	statusCode 	The HTTP status code.
+//	detail 		The problem detail. May be empty.
+//
+// Returns:
+//
+//	The problem response.
+func routerProblemResponse(context *parallel.Context, path string, statusCode int, detail string) *api.APIResponse {
+	problem := api.NewProblem(statusCode, detail)
+
+	problem.Instance = path
+	problem.CorrelationID = context.ID
+
+	return problem.Response()
+}
@@ -1,6 +1,14 @@
 package router
 
-import "github.com/gostream-official/artists/pkg/api"
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/gostream-official/artists/pkg/api"
+	"github.com/gostream-official/artists/pkg/env"
+	"github.com/revx-official/output/log"
+)
 
 // Description:
 //
@@ -27,7 +35,11 @@ type Router interface {
 	//	method 	The http method to handle.
 	//	path   	The path to handle.
 	//	handler	The handler responsible for handling the request.
-	Handle(method string, path string, handler RouterHandlerFunc)
+	//
+	// Returns:
+	//
+	//	The route which allows registering route middlewares.
+	Handle(method string, path string, handler RouterHandlerFunc) *RouterRoute
 
 	// Description:
 	//
@@ -47,14 +59,64 @@ type Router interface {
 	//	The router injector which allows object injection for the registered endpoint.
 	HandleWith(method string, path string, handler RouterInjectionHandlerFunc) *RouterInjector
 
+	// Description:
+	//
+	//	Registers middlewares for all routes, including routes registered before.
+	//	Global middlewares run before route middlewares, in registration order.
+	//	Must be called before the router is started.
+	//
+	// Parameters:
+	//
+	//	middlewares The middlewares to register.
+	Use(middlewares ...Middleware)
+
+	// Description:
+	//
+	//	Registers a panic reporter.
+	//	Panic reporters are notified about every panic recovered while handling a request.
+	//	Must be called before the router is started.
+	//
+	// Parameters:
+	//
+	//	reporter The panic reporter to register.
+	OnPanic(reporter PanicReporter)
+
 	// Description:
 	//
 	//	Starts the HTTP server for this router and listens to all registered routes.
 	//
+	//	Fails without serving, if a route registered with object injection has no object injected.
+	//
 	// Returns:
 	//
-	//	An error if serving the router fails.
+	//	An error if serving the router fails. Nil, if the router was shut down while serving.
+	//	http.ErrServerClosed, if the router was shut down before it was started.
 	Run(port uint16) error
+
+	// Description:
+	//
+	//	Gracefully shuts down the HTTP server of this router.
+	//	Stops accepting new connections and waits for in-flight requests to complete.
+	//	If the router has not been started yet, it is prevented from starting.
+	//
+	// Parameters:
+	//
+	//	ctx The shutdown context. Bounds the time waited for in-flight requests.
+	//
+	// Returns:
+	//
+	//	An error if in-flight requests did not complete in time.
+	Shutdown(ctx context.Context) error
+}
+
+// Description:
+//
+//	A registered route.
+//	Holds the middlewares which only apply to this route.
+type RouterRoute struct {
+
+	// The route middlewares, in registration order.
+	Middlewares []Middleware
 }
 
 // Description:
@@ -62,20 +124,77 @@ type Router interface {
 //	The router injector.
 //	Responsible for object injection for route handlers.
 type RouterInjector struct {
+	RouterRoute
 
 	// The object to inject.
 	Injector interface{}
+
+	// Whether an object was injected.
+	Injected bool
+}
+
+// Description:
+//
+//	The registered router engines, indexed by name.
+//	Engines register themselves, so that excluded engines, e.g. using the 'nogin' build tag, are not linked.
+var engines = make(map[string]func() Router)
+
+// Description:
+//
+//	Registers a router engine.
+//
+// Parameters:
+//
+//	name 	The engine name.
+//	factory The function creating a router of this engine.
+func registerEngine(name string, factory func() Router) {
+	engines[name] = factory
+}
+
+// Description:
+//
+//	Creates a router using the given engine.
+//
+// Parameters:
+//
+//	engine The engine name, either 'gin' or 'http'.
+//
+// Returns:
+//
+//	The created router, or an error if the engine is unknown or not included in this build.
+func New(engine string) (Router, error) {
+	factory, ok := engines[engine]
+	if !ok {
+		return nil, fmt.Errorf("router: unknown engine '%s'", engine)
+	}
+
+	return factory(), nil
 }
 
 // Description:
 //
 //	Creates the default router.
+//	The engine is configured via the 'ROUTER_ENGINE' environment variable. Defaults to gin,
+//	or to the standard library engine if gin is not included in this build.
 //
 // Returns:
 //
 //	The default router.
 func Default() Router {
-	return NewGinRouter()
+	fallback := "gin"
+	if _, ok := engines[fallback]; !ok {
+		fallback = "http"
+	}
+
+	engine := env.GetEnvironmentVariableWithFallback("ROUTER_ENGINE", fallback)
+
+	router, err := New(engine)
+	if err != nil {
+		log.Warnf("%s, falling back to '%s'", err, fallback)
+		router, _ = New(fallback)
+	}
+
+	return router
 }
 
 // Description:
@@ -85,6 +204,147 @@ func Default() Router {
 // Parameters:
 //
 //	object The object to inject.
-func (handler *RouterInjector) Inject(object interface{}) {
+//
+// Returns:
+//
+//	The router injector, for chaining.
+func (handler *RouterInjector) Inject(object interface{}) *RouterInjector {
 	handler.Injector = object
+	handler.Injected = true
+
+	return handler
+}
+
+// Description:
+//
+//	Registers middlewares for the route this method is called on.
+//	Route middlewares run after global middlewares, in registration order.
+//
+// Parameters:
+//
+//	middlewares The middlewares to register.
+//
+// Returns:
+//
+//	The route, for chaining.
+func (route *RouterRoute) Use(middlewares ...Middleware) *RouterRoute {
+	route.Middlewares = append(route.Middlewares, middlewares...)
+	return route
+}
+
+// Description:
+//
+//	Registers middlewares for the route this method is called on.
+//	Route middlewares run after global middlewares, in registration order.
+//
+// Parameters:
+//
+//	middlewares The middlewares to register.
+//
+// Returns:
+//
+//	The router injector, for chaining.
+func (handler *RouterInjector) Use(middlewares ...Middleware) *RouterInjector {
+	handler.RouterRoute.Use(middlewares...)
+	return handler
+}
+
+// Description:
+//
+//	Registers a new HTTP handler function for the given method and path,
+//	which receives a dependency of a fixed type.
+//	Paths can include wildcards and path variables.
+//
+//	The dependency is bound at registration, so the handler cannot receive a dependency of another type.
+//	Panics if the dependency is nil, e.g. a nil pointer, map or interface, as the handler could not use it.
+//
+// Parameters:
+//
+//	router 		The router to register the handler with.
+//	method 		The http method to handle.
+//	path   		The path to handle.
+//	dependency 	The dependency passed to the handler.
+//	handler		The handler responsible for handling the request.
+//
+// Type Parameters:
+//
+//	T The type of the dependency.
+//
+// Returns:
+//
+//	The registered route, which allows registering route middlewares.
+func HandleWith[T interface{}](router Router, method string, path string, dependency T, handler func(request *api.APIRequest, dependency T) *api.APIResponse) *RouterRoute {
+	if isNil(dependency) {
+		panic(fmt.Sprintf("router: nil dependency for route %s %s", method, path))
+	}
+
+	injector := router.HandleWith(method, path, func(request *api.APIRequest, _ interface{}) *api.APIResponse {
+		return handler(request, dependency)
+	})
+
+	injector.Inject(dependency)
+
+	return &injector.RouterRoute
+}
+
+// Description:
+//
+//	Checks whether a dependency is nil.
+//	Nil slices are not considered nil, as they are usable like empty slices.
+//
+// Parameters:
+//
+//	dependency The dependency to check.
+//
+// Returns:
+//
+//	Whether the dependency is a nil interface, pointer, map, function or channel.
+func isNil(dependency interface{}) bool {
+	value := reflect.ValueOf(dependency)
+
+	switch value.Kind() {
+	case reflect.Invalid:
+		return true
+
+	case reflect.Pointer, reflect.Map, reflect.Func, reflect.Chan, reflect.Interface, reflect.UnsafePointer:
+		return value.IsNil()
+	}
+
+	return false
+}
+
+// Description:
+//
+//	A route registered with object injection.
+type injectionRoute struct {
+
+	// The http method of the route.
+	method string
+
+	// The path of the route.
+	path string
+
+	// The router injector of the route.
+	injector *RouterInjector
+}
+
+// Description:
+//
+//	Checks whether an object was injected for every route registered with object injection.
+//
+// Parameters:
+//
+//	routes The routes registered with object injection.
+//
+// Returns:
+//
+//	An error naming the first route without injected object.
+func checkInjections(routes []injectionRoute) error {
+	for _, route := range routes {
+		if !route.injector.Injected {
+			return fmt.Errorf("router: no object injected for route %s %s", route.method, route.path)
+		}
+	}
+
+	return nil
 }
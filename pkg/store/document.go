@@ -0,0 +1,548 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gostream-official/artists/pkg/store/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Description:
+//
+//	Converts an item into its BSON document representation.
+//	Embedded documents are represented as bson.M, arrays as bson.A.
+//
+// Parameters:
+//
+//	item The item to convert.
+//
+// Returns:
+//
+//	The BSON document, or an error if the item cannot be marshalled.
+func toDocument(item interface{}) (bson.M, error) {
+	bytes, err := bson.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := bson.NewDecoder(bsonrw.NewBSONDocumentReader(bytes))
+	if err != nil {
+		return nil, err
+	}
+
+	decoder.DefaultDocumentM()
+
+	document := bson.M{}
+	err = decoder.Decode(&document)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// Description:
+//
+//	Converts a BSON document into an item.
+//
+// Parameters:
+//
+//	document The document to convert.
+//
+// Type Parameters:
+//
+//	T The type of the item to convert to.
+//
+// Returns:
+//
+//	The converted item, or an error if the document cannot be unmarshalled.
+func fromDocument[T interface{}](document bson.M) (T, error) {
+	var item T
+
+	bytes, err := bson.Marshal(document)
+	if err != nil {
+		return item, err
+	}
+
+	err = bson.Unmarshal(bytes, &item)
+	return item, err
+}
+
+// Description:
+//
+//	Creates a deep copy of the given document.
+//
+// Parameters:
+//
+//	document The document to copy.
+//
+// Returns:
+//
+//	The copied document, or an error if copying fails.
+func copyDocument(document bson.M) (bson.M, error) {
+	return toDocument(document)
+}
+
+// Description:
+//
+//	Converts an arbitrary value into its BSON value representation.
+//	If the value cannot be converted, it is returned unchanged.
+//
+// Parameters:
+//
+//	value The value to normalize.
+//
+// Returns:
+//
+//	The normalized value.
+func normalizeValue(value interface{}) interface{} {
+	document, err := toDocument(bson.M{"value": value})
+	if err != nil {
+		return value
+	}
+
+	return document["value"]
+}
+
+// Description:
+//
+//	Sets the value for a dotted key path within a document.
+//	Creates intermediate embedded documents if required.
+//
+// Parameters:
+//
+//	document 	The document to modify.
+//	path 		The dotted key path.
+//	value 		The value to set.
+//
+// Returns:
+//
+//	An error if the path cannot be set.
+func setPath(document bson.M, path string, value interface{}) error {
+	segments := strings.Split(path, ".")
+	var current interface{} = document
+
+	for index, segment := range segments {
+		last := index == len(segments)-1
+
+		switch container := current.(type) {
+		case bson.M:
+			if last {
+				container[segment] = value
+				return nil
+			}
+
+			next, ok := container[segment]
+			if !ok || next == nil {
+				next = bson.M{}
+				container[segment] = next
+			}
+
+			current = next
+		case bson.A:
+			position, err := strconv.Atoi(segment)
+			if err != nil || position < 0 || position >= len(container) {
+				return fmt.Errorf("store: cannot set path '%s'", path)
+			}
+
+			if last {
+				container[position] = value
+				return nil
+			}
+
+			current = container[position]
+		default:
+			return fmt.Errorf("store: cannot set path '%s'", path)
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Resolves the value for a dotted key path within a document.
+//
+// Parameters:
+//
+//	document 	The document to resolve the path in.
+//	path 		The dotted key path.
+//
+// Returns:
+//
+//	The value and whether the path exists.
+func getPath(document bson.M, path string) (interface{}, bool) {
+	var current interface{} = document
+
+	for _, segment := range strings.Split(path, ".") {
+		switch container := current.(type) {
+		case bson.M:
+			next, ok := container[segment]
+			if !ok {
+				return nil, false
+			}
+
+			current = next
+		case bson.A:
+			position, err := strconv.Atoi(segment)
+			if err != nil || position < 0 || position >= len(container) {
+				return nil, false
+			}
+
+			current = container[position]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// Description:
+//
+//	Removes the value for a dotted key path within a document.
+//	Array elements are set to null instead of being removed, as MongoDB does.
+//
+// Parameters:
+//
+//	document 	The document to modify.
+//	path 		The dotted key path.
+func unsetPath(document bson.M, path string) {
+	index := strings.LastIndex(path, ".")
+
+	if index < 0 {
+		delete(document, path)
+		return
+	}
+
+	parent, ok := getPath(document, path[:index])
+	if !ok {
+		return
+	}
+
+	key := path[index+1:]
+
+	switch container := parent.(type) {
+	case bson.M:
+		delete(container, key)
+	case bson.A:
+		position, err := strconv.Atoi(key)
+		if err == nil && position >= 0 && position < len(container) {
+			container[position] = nil
+		}
+	}
+}
+
+// Description:
+//
+//	Creates a copy of a document which only contains the given keys.
+//	The document id is always included, as MongoDB does.
+//
+// Parameters:
+//
+//	document 	The document to project.
+//	keys 		The dotted keys to include.
+//
+// Returns:
+//
+//	The projected document.
+func projectDocument(document bson.M, keys []string) bson.M {
+	result := bson.M{}
+
+	for _, key := range append([]string{"_id"}, keys...) {
+		value, ok := getPath(document, key)
+
+		if ok {
+			setPath(result, key, value)
+		}
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Applies a compiled update document to a document.
+//
+// Parameters:
+//
+//	document 	The document to modify.
+//	update 		The compiled update document.
+//
+// Returns:
+//
+//	An error if the update cannot be applied.
+func applyUpdate(document bson.M, update bson.M) error {
+	for operator, value := range update {
+		fields, err := toFields(value)
+		if err != nil {
+			return err
+		}
+
+		for path, fieldValue := range fields {
+			err := applyUpdateOperator(document, operator, path, normalizeValue(fieldValue))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Applies a single update operator on a single field of a document.
+//
+// Parameters:
+//
+//	document 	The document to modify.
+//	operator 	The update operator, e.g. '$set'.
+//	path 		The dotted key path of the field.
+//	value 		The operator value for the field.
+//
+// Returns:
+//
+//	An error if the update operator cannot be applied.
+func applyUpdateOperator(document bson.M, operator string, path string, value interface{}) error {
+	current, exists := getPath(document, path)
+
+	switch operator {
+	case "$set":
+		return setPath(document, path, value)
+	case "$unset":
+		unsetPath(document, path)
+		return nil
+	case "$inc", "$mul":
+		if !exists {
+			current = int32(0)
+		}
+
+		result, err := applyArithmetic(operator, current, value)
+		if err != nil {
+			return fmt.Errorf("store: cannot apply %s to '%s': %s", operator, path, err)
+		}
+
+		return setPath(document, path, result)
+	case "$min", "$max":
+		order, comparable := query.Compare(value, current)
+
+		if !exists || (comparable && ((operator == "$min" && order < 0) || (operator == "$max" && order > 0))) {
+			return setPath(document, path, value)
+		}
+
+		return nil
+	case "$push", "$addToSet", "$pull":
+		if !exists {
+			if operator == "$pull" {
+				return nil
+			}
+
+			return setPath(document, path, bson.A{value})
+		}
+
+		array, ok := current.(bson.A)
+		if !ok {
+			return fmt.Errorf("store: cannot apply %s to non-array field '%s'", operator, path)
+		}
+
+		return setPath(document, path, applyArrayOperator(operator, array, value))
+	case "$rename":
+		newPath, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("store: invalid rename target for '%s'", path)
+		}
+
+		if !exists {
+			return nil
+		}
+
+		unsetPath(document, path)
+		return setPath(document, newPath, current)
+	case "$currentDate":
+		return setPath(document, path, primitive.NewDateTimeFromTime(time.Now()))
+	}
+
+	return fmt.Errorf("store: unsupported update operator: %s", operator)
+}
+
+// Description:
+//
+//	Applies an array update operator on an array.
+//
+// Parameters:
+//
+//	operator 	The array update operator ('$push', '$addToSet' or '$pull').
+//	array 		The current array.
+//	value 		The operator value.
+//
+// Returns:
+//
+//	The updated array.
+func applyArrayOperator(operator string, array bson.A, value interface{}) bson.A {
+	switch operator {
+	case "$addToSet":
+		for _, element := range array {
+			if query.Equal(element, value) {
+				return array
+			}
+		}
+	case "$pull":
+		result := bson.A{}
+
+		for _, element := range array {
+			if !query.Equal(element, value) {
+				result = append(result, element)
+			}
+		}
+
+		return result
+	}
+
+	return append(array, value)
+}
+
+// Description:
+//
+//	Applies an arithmetic update operator on two BSON numbers.
+//	Integer arithmetic is preserved, unless one of the operands is a double.
+//
+// Parameters:
+//
+//	operator 	The arithmetic update operator ('$inc' or '$mul').
+//	current 	The current value.
+//	operand 	The operand.
+//
+// Returns:
+//
+//	The result, or an error if one of the values is not numeric.
+func applyArithmetic(operator string, current interface{}, operand interface{}) (interface{}, error) {
+	currentInt, currentIsInt := toInteger(current)
+	operandInt, operandIsInt := toInteger(operand)
+
+	if currentIsInt && operandIsInt {
+		result := currentInt + operandInt
+
+		if operator == "$mul" {
+			result = currentInt * operandInt
+		}
+
+		_, currentIs32 := current.(int32)
+		_, operandIs32 := operand.(int32)
+
+		if currentIs32 && operandIs32 && result >= math.MinInt32 && result <= math.MaxInt32 {
+			return int32(result), nil
+		}
+
+		return result, nil
+	}
+
+	currentFloat, currentOk := toFloat(current)
+	operandFloat, operandOk := toFloat(operand)
+
+	if !currentOk || !operandOk {
+		return nil, fmt.Errorf("value is not numeric")
+	}
+
+	if operator == "$mul" {
+		return currentFloat * operandFloat, nil
+	}
+
+	return currentFloat + operandFloat, nil
+}
+
+// Description:
+//
+//	Converts an integral BSON number into an integer.
+//
+// Parameters:
+//
+//	value The value to convert.
+//
+// Returns:
+//
+//	The converted value and whether the value is an integral BSON number.
+func toInteger(value interface{}) (int64, bool) {
+	switch number := value.(type) {
+	case int32:
+		return int64(number), true
+	case int64:
+		return number, true
+	}
+
+	return 0, false
+}
+
+// Description:
+//
+//	Converts a BSON number into a float.
+//
+// Parameters:
+//
+//	value The value to convert.
+//
+// Returns:
+//
+//	The converted value and whether the value is a BSON number.
+func toFloat(value interface{}) (float64, bool) {
+	if number, ok := toInteger(value); ok {
+		return float64(number), true
+	}
+
+	number, ok := value.(float64)
+	return number, ok
+}
+
+// Description:
+//
+//	Converts the value of a compiled update operator into its field mappings.
+//
+// Parameters:
+//
+//	value The update operator value.
+//
+// Returns:
+//
+//	The field mappings, or an error if the value is not a mapping.
+func toFields(value interface{}) (map[string]interface{}, error) {
+	switch fields := value.(type) {
+	case map[string]interface{}:
+		return fields, nil
+	case bson.M:
+		return fields, nil
+	}
+
+	return nil, fmt.Errorf("store: invalid update operator value: %T", value)
+}
+
+// Description:
+//
+//	Checks whether a document matches the given filter.
+//
+// Parameters:
+//
+//	document 	The document to check.
+//	filter 		The filter to evaluate. A nil filter matches every document.
+//
+// Returns:
+//
+//	Whether the document matches, or an error if the filter cannot be evaluated in-process.
+func matchDocument(document bson.M, filter query.IQuery) (bool, error) {
+	if filter == nil {
+		return true, nil
+	}
+
+	matcher, ok := filter.(query.IMatcher)
+	if !ok {
+		return false, fmt.Errorf("store: unsupported filter operator: %T", filter)
+	}
+
+	return matcher.Matches(document), nil
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Description:
+//
+//	The error returned when an item violates a unique index.
+var ErrDuplicateKey = errors.New("store: duplicate key")
+
+// Description:
+//
+//	An index definition.
+//	Indexes are created idempotently, an index which already exists with the same definition is left as is.
+type Index struct {
+
+	// The index name. Derived from the keys, if empty.
+	Name string
+
+	// The dotted key paths of the indexed fields, in order. Indexed in ascending order.
+	Keys []string
+
+	// Whether two documents must not have equal values for all indexed fields.
+	Unique bool
+
+	// Whether documents without any indexed field are excluded from the index.
+	Sparse bool
+
+	// The time after which documents expire, measured from the date stored in the single indexed field.
+	// Zero disables expiry.
+	ExpireAfter time.Duration
+}
+
+// Description:
+//
+//	Gets the index name.
+//
+// Returns:
+//
+//	The configured name, or a name derived from the keys, e.g. 'stats.popularity_1'.
+func (index *Index) IndexName() string {
+	if index.Name != "" {
+		return index.Name
+	}
+
+	parts := make([]string, 0, len(index.Keys))
+	for _, key := range index.Keys {
+		parts = append(parts, key+"_1")
+	}
+
+	return strings.Join(parts, "_")
+}
+
+// Description:
+//
+//	Resolves the indexed values of a document.
+//
+// Parameters:
+//
+//	document The document to resolve the values of.
+//
+// Returns:
+//
+//	The indexed values, missing fields resolve to nil.
+//	Whether the document is indexed, i.e. not excluded by a sparse index.
+func (index *Index) values(document bson.M) ([]interface{}, bool) {
+	values := make([]interface{}, 0, len(index.Keys))
+	present := false
+
+	for _, key := range index.Keys {
+		value, ok := getPath(document, key)
+		present = present || ok
+
+		values = append(values, value)
+	}
+
+	return values, present || !index.Sparse
+}
+
+// Description:
+//
+//	Checks whether a document has expired according to this index.
+//	Documents without date in the indexed field never expire.
+//
+// Parameters:
+//
+//	document 	The document to check.
+//	now 		The current time.
+//
+// Returns:
+//
+//	Whether the document has expired.
+func (index *Index) expired(document bson.M, now time.Time) bool {
+	if index.ExpireAfter <= 0 || len(index.Keys) != 1 {
+		return false
+	}
+
+	value, _ := getPath(document, index.Keys[0])
+
+	var date time.Time
+
+	switch typed := value.(type) {
+	case primitive.DateTime:
+		date = typed.Time()
+	case time.Time:
+		date = typed
+	default:
+		return false
+	}
+
+	return !now.Before(date.Add(index.ExpireAfter))
+}
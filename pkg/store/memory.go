@@ -0,0 +1,759 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gostream-official/artists/pkg/store/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Description:
+//
+//	An in-memory database instance.
+//	Holds all in-memory collections, so that stores referring to the
+//	same database and collection share their documents.
+type MemoryInstance struct {
+
+	// Guards the collection registry.
+	mutex sync.Mutex
+
+	// Serializes transactions.
+	transaction sync.Mutex
+
+	// The registered collections, indexed by database and collection name.
+	collections map[string]*memoryCollection
+}
+
+// Description:
+//
+//	An in-memory collection of documents.
+type memoryCollection struct {
+
+	// Guards the collection documents.
+	mutex sync.RWMutex
+
+	// The documents of this collection, in insertion order.
+	documents []bson.M
+
+	// The indexes of this collection.
+	indexes []Index
+}
+
+// Description:
+//
+//	An in-memory store.
+//	Keeps documents in their BSON representation and evaluates queries natively.
+type MemoryStore[T interface{}] struct {
+
+	// The in-memory collection.
+	collection *memoryCollection
+}
+
+// Description:
+//
+//	Creates a new in-memory instance.
+//
+// Returns:
+//
+//	The created in-memory instance.
+func NewMemoryInstance() *MemoryInstance {
+	return &MemoryInstance{
+		collections: make(map[string]*memoryCollection),
+	}
+}
+
+// Description:
+//
+//	Runs a function within a transaction.
+//	Takes a snapshot of all collections, which is restored if the function fails.
+//	Transactions are serialized, but operations outside of transactions are not isolated from them.
+//
+// Parameters:
+//
+//	ctx The operation context.
+//	fn 	The function to run.
+//
+// Returns:
+//
+//	The error returned by the function.
+func (instance *MemoryInstance) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	instance.transaction.Lock()
+	defer instance.transaction.Unlock()
+
+	snapshot := instance.snapshot()
+
+	defer func() {
+		recovered := recover()
+		if recovered != nil {
+			instance.restore(snapshot)
+			panic(recovered)
+		}
+
+		if err != nil {
+			instance.restore(snapshot)
+		}
+	}()
+
+	return fn(ctx)
+}
+
+// Description:
+//
+//	Takes a snapshot of the documents of all collections.
+//	Documents are replaced rather than modified by all operations, so that they do not need to be copied.
+//
+// Returns:
+//
+//	The documents of all collections, indexed by database and collection name.
+func (instance *MemoryInstance) snapshot() map[string][]bson.M {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+
+	snapshot := make(map[string][]bson.M, len(instance.collections))
+
+	for name, collection := range instance.collections {
+		collection.mutex.RLock()
+		snapshot[name] = append(make([]bson.M, 0, len(collection.documents)), collection.documents...)
+		collection.mutex.RUnlock()
+	}
+
+	return snapshot
+}
+
+// Description:
+//
+//	Restores a snapshot of the documents of all collections.
+//	Collections created after the snapshot was taken are emptied.
+//
+// Parameters:
+//
+//	snapshot The snapshot to restore.
+func (instance *MemoryInstance) restore(snapshot map[string][]bson.M) {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+
+	for name, collection := range instance.collections {
+		documents, ok := snapshot[name]
+		if !ok {
+			documents = make([]bson.M, 0)
+		}
+
+		collection.mutex.Lock()
+		collection.documents = documents
+		collection.mutex.Unlock()
+	}
+}
+
+// Description:
+//
+//	Creates a new in-memory store.
+//
+// Parameters:
+//
+//	instance 	The in-memory instance which is referred to.
+//	database 	The database name referring to.
+//	collection 	The collection name referring to.
+//
+// Type Parameters:
+//
+//	T The type of document stored in the in-memory store to create.
+//
+// Returns:
+//
+//	The created in-memory store.
+func NewMemoryStore[T interface{}](instance *MemoryInstance, database string, collection string) *MemoryStore[T] {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+
+	name := fmt.Sprintf("%s.%s", database, collection)
+
+	collectionRef, ok := instance.collections[name]
+	if !ok {
+		collectionRef = &memoryCollection{
+			documents: make([]bson.M, 0),
+		}
+
+		instance.collections[name] = collectionRef
+	}
+
+	return &MemoryStore[T]{
+		collection: collectionRef,
+	}
+}
+
+// Description:
+//
+//	Creates a new item.
+//	Generates an object id, if the item does not have an id yet.
+//
+// Parameters:
+//
+//	ctx 	The operation context. Cancels the operation when done.
+//	item 	The item to create.
+//
+// Returns:
+//
+//	ErrDuplicateKey if the item violates a unique index, another error if creation fails.
+func (store *MemoryStore[T]) CreateItem(ctx context.Context, item interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	document, err := toDocument(item)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := document["_id"]; !ok {
+		document["_id"] = primitive.NewObjectID()
+	}
+
+	store.collection.mutex.Lock()
+	defer store.collection.mutex.Unlock()
+
+	store.collection.expire(time.Now())
+
+	for _, existing := range store.collection.documents {
+		if query.Equal(existing["_id"], document["_id"]) {
+			return fmt.Errorf("%w: %v", ErrDuplicateKey, document["_id"])
+		}
+	}
+
+	err = store.collection.checkUnique(document, -1)
+	if err != nil {
+		return err
+	}
+
+	store.collection.documents = append(store.collection.documents, document)
+	return nil
+}
+
+// Description:
+//
+//	Updates a single item.
+//
+// Parameters:
+//
+//	ctx 	The operation context. Cancels the operation when done.
+//	filter 	The filter used for searching the documents to update.
+//	update 	The update operator used for updating the filtered documents.
+//
+// Returns:
+//
+//	The number of modified documents.
+//	An error if the update fails.
+func (store *MemoryStore[T]) UpdateItem(ctx context.Context, filter *query.Filter, update *query.Update) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	err := filter.Validate()
+	if err != nil {
+		return 0, err
+	}
+
+	err = update.Validate()
+	if err != nil {
+		return 0, err
+	}
+
+	store.collection.mutex.Lock()
+	defer store.collection.mutex.Unlock()
+
+	store.collection.expire(time.Now())
+
+	for index, document := range store.collection.documents {
+		matches, err := matchDocument(document, filter.Root)
+		if err != nil {
+			return 0, err
+		}
+
+		if !matches {
+			continue
+		}
+
+		if update.Root == nil {
+			return 0, nil
+		}
+
+		updated, err := copyDocument(document)
+		if err != nil {
+			return 0, err
+		}
+
+		err = applyUpdate(updated, update.Root.Compile())
+		if err != nil {
+			return 0, err
+		}
+
+		if query.Equal(document, updated) {
+			return 0, nil
+		}
+
+		err = store.collection.checkUnique(updated, index)
+		if err != nil {
+			return 0, err
+		}
+
+		store.collection.documents[index] = updated
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// Description:
+//
+//	Updates a single item, if it has the expected version (compare-and-set).
+//	Increments the version of the updated item.
+//
+// Parameters:
+//
+//	ctx 	The operation context. Cancels the operation when done.
+//	filter 	The filter used for searching the document to update.
+//	version The expected version of the document.
+//	update 	The update operator used for updating the filtered document.
+//
+// Returns:
+//
+//	ErrNotFound if no document matches the filter,
+//	ErrVersionMismatch if the document has another version,
+//	another error if the update fails.
+func (store *MemoryStore[T]) UpdateItemVersion(ctx context.Context, filter *query.Filter, version int64, update *query.Update) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := filter.Validate()
+	if err != nil {
+		return err
+	}
+
+	versioned := versionUpdate(update)
+
+	err = versioned.Validate()
+	if err != nil {
+		return err
+	}
+
+	store.collection.mutex.Lock()
+	defer store.collection.mutex.Unlock()
+
+	store.collection.expire(time.Now())
+
+	index, err := store.findVersioned(filter.Root, version)
+	if err != nil {
+		return err
+	}
+
+	updated, err := copyDocument(store.collection.documents[index])
+	if err != nil {
+		return err
+	}
+
+	err = applyUpdate(updated, versioned.Root.Compile())
+	if err != nil {
+		return err
+	}
+
+	err = store.collection.checkUnique(updated, index)
+	if err != nil {
+		return err
+	}
+
+	store.collection.documents[index] = updated
+	return nil
+}
+
+// Description:
+//
+//	Queries items in the store.
+//
+// Parameters:
+//
+//	ctx 	The operation context. Cancels the operation when done.
+//	filter 	The query filter to use.
+//
+// Returns:
+//
+//	An array of all items matching the given query filter.
+//	An error if the query fails.
+func (store *MemoryStore[T]) FindItems(ctx context.Context, filter *query.Filter) ([]T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	err := filter.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	store.collection.mutex.RLock()
+	defer store.collection.mutex.RUnlock()
+
+	documents := make([]bson.M, 0)
+	now := time.Now()
+
+	for _, document := range store.collection.documents {
+		if store.collection.isExpired(document, now) {
+			continue
+		}
+
+		matches, err := matchDocument(document, filter.Root)
+		if err != nil {
+			return nil, err
+		}
+
+		if matches {
+			documents = append(documents, document)
+		}
+	}
+
+	if len(filter.Sort) > 0 {
+		sort.SliceStable(documents, func(left int, right int) bool {
+			return query.CompareBy(filter.Sort, documents[left], documents[right]) < 0
+		})
+	}
+
+	if int(filter.Skip) >= len(documents) {
+		documents = documents[:0]
+	} else {
+		documents = documents[filter.Skip:]
+	}
+
+	if filter.Limit > 0 && int(filter.Limit) < len(documents) {
+		documents = documents[:filter.Limit]
+	}
+
+	items := make([]T, 0, len(documents))
+
+	for _, document := range documents {
+		if len(filter.Projection) > 0 {
+			document = projectDocument(document, filter.Projection)
+		}
+
+		item, err := fromDocument[T](document)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// Description:
+//
+//	Deletes an item by its ID.
+//
+// Parameters:
+//
+//	ctx The operation context. Cancels the operation when done.
+//	id 	The ID of the document to delete.
+//
+// Returns:
+//
+//	The number of deleted documents.
+//	An error if the request fails.
+func (store *MemoryStore[T]) DeleteItem(ctx context.Context, id string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	store.collection.mutex.Lock()
+	defer store.collection.mutex.Unlock()
+
+	store.collection.expire(time.Now())
+
+	for index, document := range store.collection.documents {
+		if !query.Equal(document["_id"], id) {
+			continue
+		}
+
+		documents := store.collection.documents
+		store.collection.documents = append(documents[:index:index], documents[index+1:]...)
+
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// Description:
+//
+//	Deletes an item by its ID, if it has the expected version (compare-and-set).
+//
+// Parameters:
+//
+//	ctx 	The operation context. Cancels the operation when done.
+//	id 		The ID of the document to delete.
+//	version The expected version of the document.
+//
+// Returns:
+//
+//	ErrNotFound if the document does not exist,
+//	ErrVersionMismatch if the document has another version,
+//	another error if the request fails.
+func (store *MemoryStore[T]) DeleteItemVersion(ctx context.Context, id string, version int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	store.collection.mutex.Lock()
+	defer store.collection.mutex.Unlock()
+
+	store.collection.expire(time.Now())
+
+	filter := query.FilterOperatorEq{
+		Key:   "_id",
+		Value: id,
+	}
+
+	index, err := store.findVersioned(filter, version)
+	if err != nil {
+		return err
+	}
+
+	documents := store.collection.documents
+	store.collection.documents = append(documents[:index:index], documents[index+1:]...)
+
+	return nil
+}
+
+// Description:
+//
+//	Finds the first document matching the filter and checks its version.
+//	The collection must be locked by the caller.
+//
+// Parameters:
+//
+//	filter 	The filter used for searching the document. May be nil.
+//	version The expected version of the document.
+//
+// Returns:
+//
+//	The index of the document within the collection.
+//	ErrNotFound if no document matches the filter,
+//	ErrVersionMismatch if the document has another version.
+func (store *MemoryStore[T]) findVersioned(filter query.IQuery, version int64) (int, error) {
+	for index, document := range store.collection.documents {
+		matches, err := matchDocument(document, filter)
+		if err != nil {
+			return 0, err
+		}
+
+		if !matches {
+			continue
+		}
+
+		matches, err = matchDocument(document, versionFilter(nil, version))
+		if err != nil {
+			return 0, err
+		}
+
+		if !matches {
+			return 0, ErrVersionMismatch
+		}
+
+		return index, nil
+	}
+
+	return 0, ErrNotFound
+}
+
+// Description:
+//
+//	Creates an index.
+//	Unique indexes are enforced on every write, expiring documents are removed lazily.
+//	An index with the same name is replaced.
+//
+// Parameters:
+//
+//	ctx 	The operation context. Cancels the operation when done.
+//	index 	The index to create.
+//
+// Returns:
+//
+//	ErrDuplicateKey if the documents violate the unique index, another error if creation fails.
+func (store *MemoryStore[T]) CreateIndex(ctx context.Context, index Index) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	store.collection.mutex.Lock()
+	defer store.collection.mutex.Unlock()
+
+	store.collection.expire(time.Now())
+
+	indexes := make([]Index, 0, len(store.collection.indexes)+1)
+	for _, existing := range store.collection.indexes {
+		if existing.IndexName() != index.IndexName() {
+			indexes = append(indexes, existing)
+		}
+	}
+
+	indexes = append(indexes, index)
+
+	if index.Unique {
+		for position, document := range store.collection.documents {
+			err := checkUniqueIndex(index, store.collection.documents, document, position)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	store.collection.indexes = indexes
+	return nil
+}
+
+// Description:
+//
+//	Drops an index by its name.
+//	Dropping an index which does not exist has no effect.
+//
+// Parameters:
+//
+//	ctx 	The operation context. Cancels the operation when done.
+//	name 	The name of the index to drop.
+//
+// Returns:
+//
+//	An error if the context is done.
+func (store *MemoryStore[T]) DropIndex(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	store.collection.mutex.Lock()
+	defer store.collection.mutex.Unlock()
+
+	indexes := make([]Index, 0, len(store.collection.indexes))
+	for _, existing := range store.collection.indexes {
+		if existing.IndexName() != name {
+			indexes = append(indexes, existing)
+		}
+	}
+
+	store.collection.indexes = indexes
+	return nil
+}
+
+// Description:
+//
+//	Removes all expired documents.
+//	The collection must be locked for writing by the caller.
+//
+// Parameters:
+//
+//	now The current time.
+func (collection *memoryCollection) expire(now time.Time) {
+	documents := collection.documents[:0]
+
+	for _, document := range collection.documents {
+		if !collection.isExpired(document, now) {
+			documents = append(documents, document)
+		}
+	}
+
+	for index := len(documents); index < len(collection.documents); index++ {
+		collection.documents[index] = nil
+	}
+
+	collection.documents = documents
+}
+
+// Description:
+//
+//	Checks whether a document has expired according to any index.
+//	The collection must be locked by the caller.
+//
+// Parameters:
+//
+//	document 	The document to check.
+//	now 		The current time.
+//
+// Returns:
+//
+//	Whether the document has expired.
+func (collection *memoryCollection) isExpired(document bson.M, now time.Time) bool {
+	for _, index := range collection.indexes {
+		if index.expired(document, now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Checks whether a document violates any unique index.
+//	The collection must be locked by the caller.
+//
+// Parameters:
+//
+//	document 	The document to check.
+//	position 	The position of the document within the collection, which is skipped. -1 for new documents.
+//
+// Returns:
+//
+//	ErrDuplicateKey if a unique index is violated.
+func (collection *memoryCollection) checkUnique(document bson.M, position int) error {
+	for _, index := range collection.indexes {
+		if !index.Unique {
+			continue
+		}
+
+		err := checkUniqueIndex(index, collection.documents, document, position)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Checks whether a document violates a unique index.
+//
+// Parameters:
+//
+//	index 		The unique index.
+//	documents 	The documents of the collection.
+//	document 	The document to check.
+//	position 	The position of the document within the documents, which is skipped. -1 for new documents.
+//
+// Returns:
+//
+//	ErrDuplicateKey if the index is violated.
+func checkUniqueIndex(index Index, documents []bson.M, document bson.M, position int) error {
+	values, indexed := index.values(document)
+	if !indexed {
+		return nil
+	}
+
+	for other, existing := range documents {
+		if other == position {
+			continue
+		}
+
+		existingValues, indexed := index.values(existing)
+		if indexed && query.Equal(existingValues, values) {
+			return fmt.Errorf("%w: index %s: %v", ErrDuplicateKey, index.IndexName(), values)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,573 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gostream-official/artists/pkg/store/query"
+)
+
+// Description:
+//
+//	The item type of the in-memory store tests.
+type memoryTestItem struct {
+
+	// The item id.
+	ID string `bson:"_id"`
+
+	// The item name.
+	Name string `bson:"name"`
+
+	// A sortable number.
+	Rank int32 `bson:"rank"`
+
+	// The item version.
+	Version int64 `bson:"version"`
+
+	// The expiry date, if any.
+	ExpiresAt *time.Time `bson:"expiresAt,omitempty"`
+}
+
+// Description:
+//
+//	Creates an in-memory store with the given items.
+//
+// Parameters:
+//
+//	t 		The test context.
+//	items 	The items to create.
+//
+// Returns:
+//
+//	The in-memory instance and the store.
+func newMemoryTestStore(t *testing.T, items ...memoryTestItem) (*MemoryInstance, *MemoryStore[memoryTestItem]) {
+	t.Helper()
+
+	instance := NewMemoryInstance()
+	store := NewMemoryStore[memoryTestItem](instance, "test", "items")
+
+	for _, item := range items {
+		err := store.CreateItem(context.Background(), item)
+		if err != nil {
+			t.Fatalf("failed to create item %s: %s", item.ID, err)
+		}
+	}
+
+	return instance, store
+}
+
+// Description:
+//
+//	Finds the item with the given id.
+//
+// Parameters:
+//
+//	t 		The test context.
+//	store 	The store to search.
+//	id 		The item id.
+//
+// Returns:
+//
+//	The item, or nil if it does not exist.
+func findMemoryTestItem(t *testing.T, store *MemoryStore[memoryTestItem], id string) *memoryTestItem {
+	t.Helper()
+
+	items, err := store.FindItems(context.Background(), &query.Filter{
+		Root: query.FilterOperatorEq{Key: "_id", Value: id},
+	})
+
+	if err != nil {
+		t.Fatalf("failed to find item %s: %s", id, err)
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	return &items[0]
+}
+
+// Description:
+//
+//	Returns the ids of the given items.
+//
+// Parameters:
+//
+//	items The items.
+//
+// Returns:
+//
+//	The item ids, in order.
+func memoryTestIDs(items []memoryTestItem) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+
+	return ids
+}
+
+// Description:
+//
+//	Tests creating, finding, updating and deleting items.
+//
+// Parameters:
+//
+//	t The test context.
+func TestMemoryStoreCRUD(t *testing.T) {
+	ctx := context.Background()
+	_, store := newMemoryTestStore(t, memoryTestItem{ID: "a", Name: "Daft Punk", Rank: 1})
+
+	item := findMemoryTestItem(t, store, "a")
+	if item == nil || item.Name != "Daft Punk" {
+		t.Fatalf("expected created item, got %+v", item)
+	}
+
+	err := store.CreateItem(ctx, memoryTestItem{ID: "a", Name: "Justice"})
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("expected ErrDuplicateKey for duplicate id, got '%v'", err)
+	}
+
+	filter := &query.Filter{Root: query.FilterOperatorEq{Key: "_id", Value: "a"}}
+	update := &query.Update{Root: query.UpdateOperatorSet{Set: map[string]interface{}{"name": "Justice"}}}
+
+	modified, err := store.UpdateItem(ctx, filter, update)
+	if err != nil || modified != 1 {
+		t.Fatalf("expected one modified item, got %d, '%v'", modified, err)
+	}
+
+	modified, err = store.UpdateItem(ctx, filter, update)
+	if err != nil || modified != 0 {
+		t.Errorf("expected no modified item for unchanged item, got %d, '%v'", modified, err)
+	}
+
+	missing := &query.Filter{Root: query.FilterOperatorEq{Key: "_id", Value: "b"}}
+
+	modified, err = store.UpdateItem(ctx, missing, update)
+	if err != nil || modified != 0 {
+		t.Errorf("expected no modified item for missing item, got %d, '%v'", modified, err)
+	}
+
+	item = findMemoryTestItem(t, store, "a")
+	if item == nil || item.Name != "Justice" || item.Rank != 1 {
+		t.Errorf("expected updated item, got %+v", item)
+	}
+
+	deleted, err := store.DeleteItem(ctx, "a")
+	if err != nil || deleted != 1 {
+		t.Fatalf("expected one deleted item, got %d, '%v'", deleted, err)
+	}
+
+	deleted, err = store.DeleteItem(ctx, "a")
+	if err != nil || deleted != 0 {
+		t.Errorf("expected no deleted item, got %d, '%v'", deleted, err)
+	}
+
+	if item := findMemoryTestItem(t, store, "a"); item != nil {
+		t.Errorf("expected deleted item not to be found, got %+v", item)
+	}
+}
+
+// Description:
+//
+//	Tests that invalid filters and updates are rejected.
+//
+// Parameters:
+//
+//	t The test context.
+func TestMemoryStoreInvalidQueries(t *testing.T) {
+	ctx := context.Background()
+	_, store := newMemoryTestStore(t, memoryTestItem{ID: "a"})
+
+	empty := &query.Filter{Root: query.FilterOperatorAnd{}}
+	update := &query.Update{Root: query.UpdateOperatorSet{Set: map[string]interface{}{"name": "Justice"}}}
+
+	_, err := store.FindItems(ctx, empty)
+	if err == nil {
+		t.Errorf("expected FindItems to reject an empty 'and'")
+	}
+
+	_, err = store.UpdateItem(ctx, empty, update)
+	if err == nil {
+		t.Errorf("expected UpdateItem to reject an empty 'and'")
+	}
+
+	err = store.UpdateItemVersion(ctx, empty, 0, update)
+	if err == nil {
+		t.Errorf("expected UpdateItemVersion to reject an empty 'and'")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = store.FindItems(cancelled, &query.Filter{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got '%v'", err)
+	}
+}
+
+// Description:
+//
+//	Tests versioned updates and deletions.
+//
+// Parameters:
+//
+//	t The test context.
+func TestMemoryStoreVersion(t *testing.T) {
+	ctx := context.Background()
+	_, store := newMemoryTestStore(t, memoryTestItem{ID: "a", Name: "Daft Punk"})
+
+	filter := &query.Filter{Root: query.FilterOperatorEq{Key: "_id", Value: "a"}}
+	update := &query.Update{Root: query.UpdateOperatorSet{Set: map[string]interface{}{"name": "Justice"}}}
+
+	err := store.UpdateItemVersion(ctx, filter, 0, update)
+	if err != nil {
+		t.Fatalf("expected update of version 0 to succeed, got '%s'", err)
+	}
+
+	item := findMemoryTestItem(t, store, "a")
+	if item == nil || item.Name != "Justice" || item.Version != 1 {
+		t.Fatalf("expected updated item in version 1, got %+v", item)
+	}
+
+	err = store.UpdateItemVersion(ctx, filter, 0, update)
+	if !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch for stale version, got '%v'", err)
+	}
+
+	err = store.UpdateItemVersion(ctx, filter, 1, &query.Update{})
+	if err != nil {
+		t.Errorf("expected empty update of version 1 to succeed, got '%s'", err)
+	}
+
+	item = findMemoryTestItem(t, store, "a")
+	if item == nil || item.Version != 2 {
+		t.Errorf("expected empty update to increment the version, got %+v", item)
+	}
+
+	missing := &query.Filter{Root: query.FilterOperatorEq{Key: "_id", Value: "b"}}
+
+	err = store.UpdateItemVersion(ctx, missing, 0, update)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for missing item, got '%v'", err)
+	}
+
+	err = store.DeleteItemVersion(ctx, "a", 1)
+	if !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch for stale version, got '%v'", err)
+	}
+
+	err = store.DeleteItemVersion(ctx, "b", 0)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for missing item, got '%v'", err)
+	}
+
+	err = store.DeleteItemVersion(ctx, "a", 2)
+	if err != nil {
+		t.Fatalf("expected deletion of version 2 to succeed, got '%s'", err)
+	}
+
+	if item := findMemoryTestItem(t, store, "a"); item != nil {
+		t.Errorf("expected deleted item not to be found, got %+v", item)
+	}
+}
+
+// Description:
+//
+//	Tests that transactions commit on success and roll back all collections on failure.
+//
+// Parameters:
+//
+//	t The test context.
+func TestMemoryInstanceTransaction(t *testing.T) {
+	ctx := context.Background()
+	instance, store := newMemoryTestStore(t, memoryTestItem{ID: "a", Name: "Daft Punk"})
+	other := NewMemoryStore[memoryTestItem](instance, "test", "others")
+
+	failure := errors.New("failure")
+
+	err := instance.WithTransaction(ctx, func(ctx context.Context) error {
+		_, err := store.DeleteItem(ctx, "a")
+		if err != nil {
+			return err
+		}
+
+		err = other.CreateItem(ctx, memoryTestItem{ID: "b"})
+		if err != nil {
+			return err
+		}
+
+		return failure
+	})
+
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the error of the function, got '%v'", err)
+	}
+
+	if item := findMemoryTestItem(t, store, "a"); item == nil || item.Name != "Daft Punk" {
+		t.Errorf("expected deletion to be rolled back, got %+v", item)
+	}
+
+	if item := findMemoryTestItem(t, other, "b"); item != nil {
+		t.Errorf("expected creation to be rolled back, got %+v", item)
+	}
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != "panic" {
+				t.Errorf("expected the panic to be propagated, got %v", recovered)
+			}
+		}()
+
+		_ = instance.WithTransaction(ctx, func(ctx context.Context) error {
+			_, _ = store.DeleteItem(ctx, "a")
+			panic("panic")
+		})
+	}()
+
+	if item := findMemoryTestItem(t, store, "a"); item == nil {
+		t.Errorf("expected deletion to be rolled back after a panic")
+	}
+
+	err = instance.WithTransaction(ctx, func(ctx context.Context) error {
+		return other.CreateItem(ctx, memoryTestItem{ID: "b"})
+	})
+
+	if err != nil {
+		t.Fatalf("expected transaction to succeed, got '%s'", err)
+	}
+
+	if item := findMemoryTestItem(t, other, "b"); item == nil {
+		t.Errorf("expected creation to be committed")
+	}
+}
+
+// Description:
+//
+//	Tests sorting, skipping, limiting and projecting query results.
+//
+// Parameters:
+//
+//	t The test context.
+func TestMemoryStoreFindItems(t *testing.T) {
+	_, store := newMemoryTestStore(t,
+		memoryTestItem{ID: "a", Name: "Daft Punk", Rank: 2},
+		memoryTestItem{ID: "b", Name: "Justice", Rank: 3},
+		memoryTestItem{ID: "c", Name: "Air", Rank: 1},
+		memoryTestItem{ID: "d", Name: "Cassius", Rank: 3},
+	)
+
+	tests := []struct {
+		name     string
+		filter   query.Filter
+		expected []string
+	}{
+		{
+			name:     "insertion order",
+			filter:   query.Filter{},
+			expected: []string{"a", "b", "c", "d"},
+		},
+		{
+			name:     "filtered",
+			filter:   query.Filter{Root: query.FilterOperatorGte{Key: "rank", Value: 2}},
+			expected: []string{"a", "b", "d"},
+		},
+		{
+			name:     "sorted ascending",
+			filter:   query.Filter{Sort: []query.SortKey{{Key: "name", Order: query.SortAscending}}},
+			expected: []string{"c", "d", "a", "b"},
+		},
+		{
+			name:     "sorted descending is stable",
+			filter:   query.Filter{Sort: []query.SortKey{{Key: "rank", Order: query.SortDescending}}},
+			expected: []string{"b", "d", "a", "c"},
+		},
+		{
+			name:     "sorted by multiple keys",
+			filter:   query.Filter{Sort: []query.SortKey{{Key: "rank", Order: query.SortDescending}, {Key: "name", Order: query.SortAscending}}},
+			expected: []string{"d", "b", "a", "c"},
+		},
+		{
+			name:     "skipped and limited",
+			filter:   query.Filter{Sort: []query.SortKey{{Key: "rank", Order: query.SortAscending}}, Skip: 1, Limit: 2},
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "skipped beyond results",
+			filter:   query.Filter{Skip: 4},
+			expected: []string{},
+		},
+		{
+			name:     "limit beyond results",
+			filter:   query.Filter{Skip: 3, Limit: 10},
+			expected: []string{"d"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, err := store.FindItems(context.Background(), &test.filter)
+			if err != nil {
+				t.Fatalf("failed to find items: %s", err)
+			}
+
+			actual := memoryTestIDs(items)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+
+	items, err := store.FindItems(context.Background(), &query.Filter{
+		Root:       query.FilterOperatorEq{Key: "_id", Value: "a"},
+		Projection: []string{"name"},
+	})
+
+	if err != nil {
+		t.Fatalf("failed to find items: %s", err)
+	}
+
+	expected := []memoryTestItem{{ID: "a", Name: "Daft Punk"}}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("expected projected items %+v, got %+v", expected, items)
+	}
+}
+
+// Description:
+//
+//	Tests that unique indexes are enforced on creation, update and index creation.
+//
+// Parameters:
+//
+//	t The test context.
+func TestMemoryStoreUniqueIndex(t *testing.T) {
+	ctx := context.Background()
+	_, store := newMemoryTestStore(t,
+		memoryTestItem{ID: "a", Name: "Daft Punk"},
+		memoryTestItem{ID: "b", Name: "Justice"},
+	)
+
+	err := store.CreateIndex(ctx, Index{Keys: []string{"name"}, Unique: true})
+	if err != nil {
+		t.Fatalf("failed to create index: %s", err)
+	}
+
+	err = store.CreateItem(ctx, memoryTestItem{ID: "c", Name: "Justice"})
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("expected ErrDuplicateKey on creation, got '%v'", err)
+	}
+
+	filter := &query.Filter{Root: query.FilterOperatorEq{Key: "_id", Value: "a"}}
+	update := &query.Update{Root: query.UpdateOperatorSet{Set: map[string]interface{}{"name": "Justice"}}}
+
+	_, err = store.UpdateItem(ctx, filter, update)
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("expected ErrDuplicateKey on update, got '%v'", err)
+	}
+
+	err = store.UpdateItemVersion(ctx, filter, 0, update)
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("expected ErrDuplicateKey on versioned update, got '%v'", err)
+	}
+
+	if item := findMemoryTestItem(t, store, "a"); item == nil || item.Name != "Daft Punk" || item.Version != 0 {
+		t.Errorf("expected rejected updates not to be applied, got %+v", item)
+	}
+
+	err = store.CreateIndex(ctx, Index{Name: "rank", Keys: []string{"rank"}, Unique: true})
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("expected ErrDuplicateKey when existing items violate the index, got '%v'", err)
+	}
+
+	err = store.CreateItem(ctx, memoryTestItem{ID: "c", Name: "Air"})
+	if err != nil {
+		t.Errorf("expected rejected index not to be created, got '%s'", err)
+	}
+
+	err = store.DropIndex(ctx, "name_1")
+	if err != nil {
+		t.Fatalf("failed to drop index: %s", err)
+	}
+
+	err = store.CreateItem(ctx, memoryTestItem{ID: "d", Name: "Justice"})
+	if err != nil {
+		t.Errorf("expected dropped index not to be enforced, got '%s'", err)
+	}
+}
+
+// Description:
+//
+//	Tests that sparse unique indexes ignore documents without indexed fields.
+//
+// Parameters:
+//
+//	t The test context.
+func TestMemoryStoreSparseIndex(t *testing.T) {
+	ctx := context.Background()
+	_, store := newMemoryTestStore(t, memoryTestItem{ID: "a"}, memoryTestItem{ID: "b"})
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	err := store.CreateIndex(ctx, Index{Keys: []string{"expiresAt"}, Unique: true, Sparse: true})
+	if err != nil {
+		t.Fatalf("expected documents without indexed field to be ignored, got '%s'", err)
+	}
+
+	err = store.CreateItem(ctx, memoryTestItem{ID: "c", ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatalf("failed to create item: %s", err)
+	}
+
+	err = store.CreateItem(ctx, memoryTestItem{ID: "d", ExpiresAt: &expiresAt})
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("expected ErrDuplicateKey for indexed documents, got '%v'", err)
+	}
+}
+
+// Description:
+//
+//	Tests that documents expire according to TTL indexes.
+//
+// Parameters:
+//
+//	t The test context.
+func TestMemoryStoreTTLIndex(t *testing.T) {
+	ctx := context.Background()
+
+	past := time.Now().Add(-2 * time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	_, store := newMemoryTestStore(t,
+		memoryTestItem{ID: "expired", ExpiresAt: &past},
+		memoryTestItem{ID: "alive", ExpiresAt: &future},
+		memoryTestItem{ID: "eternal"},
+	)
+
+	err := store.CreateIndex(ctx, Index{Keys: []string{"expiresAt"}, ExpireAfter: time.Minute})
+	if err != nil {
+		t.Fatalf("failed to create index: %s", err)
+	}
+
+	items, err := store.FindItems(ctx, &query.Filter{})
+	if err != nil {
+		t.Fatalf("failed to find items: %s", err)
+	}
+
+	expected := []string{"alive", "eternal"}
+	if actual := memoryTestIDs(items); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	deleted, err := store.DeleteItem(ctx, "expired")
+	if err != nil || deleted != 0 {
+		t.Errorf("expected expired item to be removed, got %d, '%v'", deleted, err)
+	}
+
+	err = store.CreateItem(ctx, memoryTestItem{ID: "expired"})
+	if err != nil {
+		t.Errorf("expected id of expired item to be free, got '%s'", err)
+	}
+}
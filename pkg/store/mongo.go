@@ -2,6 +2,9 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/gostream-official/artists/pkg/store/query"
 	"go.mongodb.org/mongo-driver/bson"
@@ -9,6 +12,16 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Description:
+//
+//	The MongoDB error code reported for operations on a collection which does not exist.
+const namespaceNotFoundCode = 26
+
+// Description:
+//
+//	The MongoDB error code reported for dropping an index which does not exist.
+const indexNotFoundCode = 27
+
 // Description:
 //
 //	A MongoDB instance.
@@ -27,6 +40,9 @@ type MongoStore[T interface{}] struct {
 
 	// The MongoDB collection.
 	Collection *mongo.Collection
+
+	// The maximum duration of a single operation. Zero disables the timeout.
+	Timeout time.Duration
 }
 
 // Description:
@@ -62,6 +78,81 @@ func NewMongoInstance(uri string) (*MongoInstance, error) {
 	}, nil
 }
 
+// Description:
+//
+//	Disconnects the MongoDB client.
+//	Waits for in-use connections to be returned to the pool.
+//
+// Parameters:
+//
+//	ctx The disconnect context. Bounds the time waited for in-use connections.
+//
+// Returns:
+//
+//	An error if disconnecting fails.
+func (instance *MongoInstance) Disconnect(ctx context.Context) error {
+	return instance.Client.Disconnect(ctx)
+}
+
+// Description:
+//
+//	Checks whether the MongoDB deployment supports transactions,
+//	i.e. whether it is a replica set or a sharded cluster.
+//
+// Parameters:
+//
+//	ctx The operation context.
+//
+// Returns:
+//
+//	Whether transactions are supported.
+//	An error if the deployment cannot be queried.
+func (instance *MongoInstance) SupportsTransactions(ctx context.Context) (bool, error) {
+	var hello struct {
+
+		// The name of the replica set. Empty, if the server is not a replica set member.
+		SetName string `bson:"setName"`
+
+		// Identifies a mongos router of a sharded cluster, if equal to 'isdbgrid'.
+		Msg string `bson:"msg"`
+	}
+
+	err := instance.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false, err
+	}
+
+	return hello.SetName != "" || hello.Msg == "isdbgrid", nil
+}
+
+// Description:
+//
+//	Runs a function within a MongoDB transaction.
+//	Requires a replica set or sharded cluster. Transient transaction errors are retried.
+//
+// Parameters:
+//
+//	ctx The operation context.
+//	fn 	The function to run.
+//
+// Returns:
+//
+//	The error returned by the function, or an error if the transaction fails.
+func (instance *MongoInstance) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := instance.Client.StartSession()
+	if err != nil {
+		return err
+	}
+
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+
+	return err
+}
+
 // Description:
 //
 //	Creates a new mongo store.
@@ -94,17 +185,20 @@ func NewMongoStore[T interface{}](instance *MongoInstance, database string, coll
 //
 // Parameters:
 //
-//	item The item to create.
+//	ctx 	The operation context. Cancels the operation when done.
+//	item 	The item to create.
 //
 // Returns:
 //
-//	An error if creation fails.
-func (store *MongoStore[T]) CreateItem(item interface{}) error {
-	ctx := context.Background()
+//	ErrDuplicateKey if the item violates a unique index, another error if creation fails.
+func (store *MongoStore[T]) CreateItem(ctx context.Context, item interface{}) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	_, err := store.Collection.InsertOne(ctx, item)
 
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 
 	return nil
@@ -116,14 +210,25 @@ func (store *MongoStore[T]) CreateItem(item interface{}) error {
 //
 // Parameters:
 //
-//	filter The filter used for searching the documents to update.
-//	update The update operator used for updating the filtered documents.
+//	ctx 	The operation context. Cancels the operation when done.
+//	filter 	The filter used for searching the documents to update.
+//	update 	The update operator used for updating the filtered documents.
 //
 // Returns:
 //
 //	The number of modified documents.
 //	An error if the update fails.
-func (store *MongoStore[T]) UpdateItem(filter *query.Filter, update *query.Update) (int64, error) {
+func (store *MongoStore[T]) UpdateItem(ctx context.Context, filter *query.Filter, update *query.Update) (int64, error) {
+	err := filter.Validate()
+	if err != nil {
+		return 0, err
+	}
+
+	err = update.Validate()
+	if err != nil {
+		return 0, err
+	}
+
 	var query bson.M
 	var updateQuery bson.M
 
@@ -139,29 +244,82 @@ func (store *MongoStore[T]) UpdateItem(filter *query.Filter, update *query.Updat
 		updateQuery = update.Root.Compile()
 	}
 
-	ctx := context.Background()
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
 	result, err := store.Collection.UpdateOne(ctx, query, updateQuery)
 
 	if err != nil {
-		return 0, err
+		return 0, translateError(err)
 	}
 
 	return result.ModifiedCount, nil
 }
 
+// Description:
+//
+//	Updates a single item, if it has the expected version (compare-and-set).
+//	Increments the version of the updated item.
+//
+// Parameters:
+//
+//	ctx 	The operation context. Cancels the operation when done.
+//	filter 	The filter used for searching the document to update.
+//	version The expected version of the document.
+//	update 	The update operator used for updating the filtered document.
+//
+// Returns:
+//
+//	ErrNotFound if no document matches the filter,
+//	ErrVersionMismatch if the document has another version,
+//	another error if the update fails.
+func (store *MongoStore[T]) UpdateItemVersion(ctx context.Context, filter *query.Filter, version int64, update *query.Update) error {
+	err := filter.Validate()
+	if err != nil {
+		return err
+	}
+
+	versioned := versionUpdate(update)
+
+	err = versioned.Validate()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	result, err := store.Collection.UpdateOne(ctx, versionFilter(filter.Root, version).Compile(), versioned.Root.Compile())
+	if err != nil {
+		return translateError(err)
+	}
+
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	return store.classifyMismatch(ctx, filter.Root)
+}
+
 // Description:
 //
 //	Queries items in the store.
 //
 // Parameters:
 //
-//	The query filter to use.
+//	ctx 	The operation context. Cancels the operation when done.
+//	filter 	The query filter to use.
 //
 // Returns:
 //
 //	An array of all items matching the given query filter.
 //	An error if the query fails.
-func (store *MongoStore[T]) FindItems(filter *query.Filter) ([]T, error) {
+func (store *MongoStore[T]) FindItems(ctx context.Context, filter *query.Filter) ([]T, error) {
+	err := filter.Validate()
+	if err != nil {
+		return nil, err
+	}
+
 	items := make([]T, 0)
 
 	var query bson.M
@@ -172,8 +330,20 @@ func (store *MongoStore[T]) FindItems(filter *query.Filter) ([]T, error) {
 		query = filter.Root.Compile()
 	}
 
-	ctx := context.Background()
-	options := options.Find().SetLimit(int64(filter.Limit))
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	options := options.Find().SetLimit(int64(filter.Limit)).SetSkip(int64(filter.Skip))
+
+	sort := filter.CompileSort()
+	if sort != nil {
+		options.SetSort(sort)
+	}
+
+	projection := filter.CompileProjection()
+	if projection != nil {
+		options.SetProjection(projection)
+	}
 
 	cursor, err := store.Collection.Find(ctx, query, options)
 	if err != nil {
@@ -193,6 +363,11 @@ func (store *MongoStore[T]) FindItems(filter *query.Filter) ([]T, error) {
 		items = append(items, item)
 	}
 
+	err = cursor.Err()
+	if err != nil {
+		return nil, err
+	}
+
 	return items, nil
 }
 
@@ -202,14 +377,16 @@ func (store *MongoStore[T]) FindItems(filter *query.Filter) ([]T, error) {
 //
 // Parameters:
 //
-//	The ID of the document to delete.
+//	ctx The operation context. Cancels the operation when done.
+//	id 	The ID of the document to delete.
 //
 // Returns:
 //
 //	The number of deleted documents.
 //	An error if the request fails.
-func (store MongoStore[T]) DeleteItem(id string) (int64, error) {
-	ctx := context.Background()
+func (store *MongoStore[T]) DeleteItem(ctx context.Context, id string) (int64, error) {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
 
 	result, err := store.Collection.DeleteOne(ctx, bson.M{
 		"_id": id,
@@ -221,3 +398,183 @@ func (store MongoStore[T]) DeleteItem(id string) (int64, error) {
 
 	return result.DeletedCount, nil
 }
+
+// Description:
+//
+//	Derives the context of a single operation, bounded by the configured operation timeout.
+//
+// Parameters:
+//
+//	ctx The parent context.
+//
+// Returns:
+//
+//	The operation context and the function releasing its resources.
+func (store *MongoStore[T]) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if store.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, store.Timeout)
+}
+
+// Description:
+//
+//	Deletes an item by its ID, if it has the expected version (compare-and-set).
+//
+// Parameters:
+//
+//	ctx 	The operation context. Cancels the operation when done.
+//	id 		The ID of the document to delete.
+//	version The expected version of the document.
+//
+// Returns:
+//
+//	ErrNotFound if the document does not exist,
+//	ErrVersionMismatch if the document has another version,
+//	another error if the request fails.
+func (store *MongoStore[T]) DeleteItemVersion(ctx context.Context, id string, version int64) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	filter := query.FilterOperatorEq{
+		Key:   "_id",
+		Value: id,
+	}
+
+	result, err := store.Collection.DeleteOne(ctx, versionFilter(filter, version).Compile())
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount > 0 {
+		return nil
+	}
+
+	return store.classifyMismatch(ctx, filter)
+}
+
+// Description:
+//
+//	Determines why a versioned operation did not match any document.
+//
+// Parameters:
+//
+//	ctx 	The operation context.
+//	filter 	The filter of the operation, without version condition. May be nil.
+//
+// Returns:
+//
+//	ErrNotFound if no document matches the filter, ErrVersionMismatch otherwise,
+//	or another error if the request fails.
+func (store *MongoStore[T]) classifyMismatch(ctx context.Context, filter query.IQuery) error {
+	query := bson.M{}
+	if filter != nil {
+		query = filter.Compile()
+	}
+
+	count, err := store.Collection.CountDocuments(ctx, query, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return ErrNotFound
+	}
+
+	return ErrVersionMismatch
+}
+
+// Description:
+//
+//	Creates an index.
+//	Creating an index which already exists with the same definition has no effect.
+//
+// Parameters:
+//
+//	ctx 	The operation context. Cancels the operation when done.
+//	index 	The index to create.
+//
+// Returns:
+//
+//	ErrDuplicateKey if the documents violate the unique index, another error if creation fails.
+func (store *MongoStore[T]) CreateIndex(ctx context.Context, index Index) error {
+	keys := bson.D{}
+	for _, key := range index.Keys {
+		keys = append(keys, bson.E{Key: key, Value: 1})
+	}
+
+	options := options.Index().SetName(index.IndexName())
+
+	if index.Unique {
+		options.SetUnique(true)
+	}
+
+	if index.Sparse {
+		options.SetSparse(true)
+	}
+
+	if index.ExpireAfter > 0 {
+		options.SetExpireAfterSeconds(int32(index.ExpireAfter / time.Second))
+	}
+
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	_, err := store.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    keys,
+		Options: options,
+	})
+
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Drops an index by its name.
+//	Dropping an index which does not exist, or an index of a collection which does not exist, has no effect.
+//
+// Parameters:
+//
+//	ctx 	The operation context. Cancels the operation when done.
+//	name 	The name of the index to drop.
+//
+// Returns:
+//
+//	An error if the index cannot be dropped.
+func (store *MongoStore[T]) DropIndex(ctx context.Context, name string) error {
+	ctx, cancel := store.withTimeout(ctx)
+	defer cancel()
+
+	_, err := store.Collection.Indexes().DropOne(ctx, name)
+
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && (commandErr.Code == namespaceNotFoundCode || commandErr.Code == indexNotFoundCode) {
+		return nil
+	}
+
+	return err
+}
+
+// Description:
+//
+//	Translates MongoDB errors into store errors.
+//
+// Parameters:
+//
+//	err The MongoDB error.
+//
+// Returns:
+//
+//	The error wrapped with ErrDuplicateKey for duplicate key errors, otherwise the error itself.
+func translateError(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: %s", ErrDuplicateKey, err)
+	}
+
+	return err
+}
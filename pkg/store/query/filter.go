@@ -1,9 +1,21 @@
 package query
 
 import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+const (
+
+	// The maximum length of regular expression patterns in filters.
+	MaxRegexLength = 256
+)
+
 // Description:
 //
 //	The root filter object.
@@ -15,6 +27,43 @@ type Filter struct {
 
 	// The query result limit.
 	Limit uint32
+
+	// The number of query results to skip.
+	Skip uint32
+
+	// The sort specification. Sort keys are applied in order.
+	Sort []SortKey
+
+	// The document keys to include in query results.
+	// All keys are included if empty.
+	Projection []string
+}
+
+// Description:
+//
+//	The order in which query results are sorted.
+type SortOrder int
+
+const (
+
+	// Sorts query results in ascending order.
+	SortAscending SortOrder = 1
+
+	// Sorts query results in descending order.
+	SortDescending SortOrder = -1
+)
+
+// Description:
+//
+//	A sort key.
+//	Describes by which document key and in which order query results are sorted.
+type SortKey struct {
+
+	// The document key to sort by.
+	Key string `json:"key"`
+
+	// The sort order.
+	Order SortOrder `json:"order"`
 }
 
 // Description:
@@ -137,6 +186,226 @@ type FilterOperatorGte struct {
 	Value interface{}
 }
 
+// Description:
+//
+//	The 'nor' filter. Allows to filter documents which fail all given filter conditions.
+type FilterOperatorNor struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// All filter conditions checked by the 'nor' operator.
+	Nor []IQuery
+}
+
+// Description:
+//
+//	The 'not' filter.
+//	Allows to filter documents which do not match a given field filter condition.
+//	Documents which do not contain the field are matched as well.
+type FilterOperatorNot struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The field filter condition to invert.
+	Not IQuery
+}
+
+// Description:
+//
+//	The 'in' filter.
+//	Allows to filter documents for fields with a value which equals any of the given values.
+type FilterOperatorIn struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// The document value should equal any of these values.
+	Values []interface{}
+}
+
+// Description:
+//
+//	The 'not in' filter.
+//	Allows to filter documents for fields with a value which equals none of the given values.
+type FilterOperatorNin struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// The document value should equal none of these values.
+	Values []interface{}
+}
+
+// Description:
+//
+//	The 'exists' filter.
+//	Allows to filter documents which contain or do not contain a specific field.
+type FilterOperatorExists struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// Whether the document field should exist.
+	Exists bool
+}
+
+// Description:
+//
+//	The 'regex' filter.
+//	Allows to filter documents for string fields matching a regular expression.
+type FilterOperatorRegex struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// The regular expression pattern.
+	Pattern string
+
+	// The regular expression options (any of 'i', 'm', 's' and 'x').
+	Options string
+}
+
+// Description:
+//
+//	The 'all' filter.
+//	Allows to filter documents for array fields which contain all of the given values.
+type FilterOperatorAll struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// The document array should contain all of these values.
+	Values []interface{}
+}
+
+// Description:
+//
+//	The 'size' filter.
+//	Allows to filter documents for array fields with a specific number of elements.
+type FilterOperatorSize struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// The number of elements the document array should have.
+	Size int
+}
+
+// Description:
+//
+//	The 'element match' filter.
+//	Allows to filter documents for array fields with at least one element matching all given conditions.
+//	The keys of the sub filter are relative to the array elements.
+//	Use an empty key to refer to scalar array elements.
+type FilterOperatorElemMatch struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// The filter condition an array element should match.
+	Match IQuery
+}
+
+// Description:
+//
+//	Validates the filter.
+//	Checks that logical operators have at least one sub filter, that no sub filter is missing
+//	and that regular expressions are valid. MongoDB rejects empty logical operators and invalid
+//	regular expressions, so they are rejected by every store.
+//
+// Returns:
+//
+//	An error if the filter is invalid.
+func (filter *Filter) Validate() error {
+	if filter.Root == nil {
+		return nil
+	}
+
+	return validateFilter(filter.Root)
+}
+
+// Description:
+//
+//	Validates a filter and its sub filters.
+//
+// Parameters:
+//
+//	filter The filter to validate.
+//
+// Returns:
+//
+//	An error if the filter is invalid.
+func validateFilter(filter IQuery) error {
+	switch filter := filter.(type) {
+	case nil:
+		return fmt.Errorf("query: missing sub filter")
+	case FilterOperatorAnd:
+		return validateFilters("and", filter.And)
+	case FilterOperatorOr:
+		return validateFilters("or", filter.Or)
+	case FilterOperatorNor:
+		return validateFilters("nor", filter.Nor)
+	case FilterOperatorNot:
+		return validateFilter(filter.Not)
+	case FilterOperatorElemMatch:
+		return validateFilter(filter.Match)
+	case FilterOperatorRegex:
+		return ValidateRegex(filter.Pattern, filter.Options)
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Validates the sub filters of a logical operator.
+//
+// Parameters:
+//
+//	operator 	The name of the logical operator.
+//	filters 	The sub filters to validate.
+//
+// Returns:
+//
+//	An error if there are no sub filters or a sub filter is invalid.
+func validateFilters(operator string, filters []IQuery) error {
+	if len(filters) == 0 {
+		return fmt.Errorf("query: '%s' requires at least one sub filter", operator)
+	}
+
+	for _, filter := range filters {
+		err := validateFilter(filter)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Description:
 //
 //	Compiles the filter and potential sub filters into a MongoDB BSON document.
@@ -236,3 +505,736 @@ func (filter FilterOperatorGt) Compile() bson.M {
 func (filter FilterOperatorGte) Compile() bson.M {
 	return bson.M{filter.Key: bson.M{"$gte": filter.Value}}
 }
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorNor) Compile() bson.M {
+	norArray := make([]bson.M, 0)
+
+	for _, nor := range filter.Nor {
+		norArray = append(norArray, nor.Compile())
+	}
+
+	return bson.M{"$nor": norArray}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//	Field filter conditions are inverted using '$not', any other conditions using '$nor'.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorNot) Compile() bson.M {
+	condition := filter.Not.Compile()
+
+	if len(condition) == 1 {
+		for key, value := range condition {
+			if strings.HasPrefix(key, "$") {
+				break
+			}
+
+			expression, ok := value.(bson.M)
+			if !ok {
+				expression = bson.M{"$eq": value}
+			}
+
+			return bson.M{key: bson.M{"$not": expression}}
+		}
+	}
+
+	return bson.M{"$nor": []bson.M{condition}}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorIn) Compile() bson.M {
+	return bson.M{filter.Key: bson.M{"$in": valuesOrEmpty(filter.Values)}}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorNin) Compile() bson.M {
+	return bson.M{filter.Key: bson.M{"$nin": valuesOrEmpty(filter.Values)}}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorExists) Compile() bson.M {
+	return bson.M{filter.Key: bson.M{"$exists": filter.Exists}}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorRegex) Compile() bson.M {
+	expression := bson.M{"$regex": filter.Pattern}
+
+	if filter.Options != "" {
+		expression["$options"] = filter.Options
+	}
+
+	return bson.M{filter.Key: expression}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorAll) Compile() bson.M {
+	return bson.M{filter.Key: bson.M{"$all": valuesOrEmpty(filter.Values)}}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorSize) Compile() bson.M {
+	return bson.M{filter.Key: bson.M{"$size": filter.Size}}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorElemMatch) Compile() bson.M {
+	return bson.M{filter.Key: bson.M{"$elemMatch": elementCondition(filter.Match.Compile())}}
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorAnd) Matches(document interface{}) bool {
+	for _, and := range filter.And {
+		if !matches(and, document) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorOr) Matches(document interface{}) bool {
+	for _, or := range filter.Or {
+		if matches(or, document) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorEq) Matches(document interface{}) bool {
+	return matchEquals(document, filter.Key, filter.Value)
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorNeq) Matches(document interface{}) bool {
+	return !matchEquals(document, filter.Key, filter.Value)
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorLt) Matches(document interface{}) bool {
+	return matchCompare(document, filter.Key, filter.Value, func(order int) bool {
+		return order < 0
+	})
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorLte) Matches(document interface{}) bool {
+	return matchCompare(document, filter.Key, filter.Value, func(order int) bool {
+		return order <= 0
+	})
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorGt) Matches(document interface{}) bool {
+	return matchCompare(document, filter.Key, filter.Value, func(order int) bool {
+		return order > 0
+	})
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorGte) Matches(document interface{}) bool {
+	return matchCompare(document, filter.Key, filter.Value, func(order int) bool {
+		return order >= 0
+	})
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorNor) Matches(document interface{}) bool {
+	for _, nor := range filter.Nor {
+		if matches(nor, document) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorNot) Matches(document interface{}) bool {
+	return !matches(filter.Not, document)
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorIn) Matches(document interface{}) bool {
+	for _, value := range filter.Values {
+		if matchEquals(document, filter.Key, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorNin) Matches(document interface{}) bool {
+	for _, value := range filter.Values {
+		if matchEquals(document, filter.Key, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorExists) Matches(document interface{}) bool {
+	return (len(Lookup(document, filter.Key)) > 0) == filter.Exists
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorRegex) Matches(document interface{}) bool {
+	expression, err := compileRegex(filter.Pattern, filter.Options)
+	if err != nil {
+		return false
+	}
+
+	for _, candidate := range Lookup(document, filter.Key) {
+		values := elements(candidate)
+
+		if values == nil {
+			values = []interface{}{candidate}
+		}
+
+		for _, value := range values {
+			text, ok := value.(string)
+			if ok && expression.MatchString(text) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorAll) Matches(document interface{}) bool {
+	if len(filter.Values) == 0 {
+		return false
+	}
+
+	for _, value := range filter.Values {
+		if !matchEquals(document, filter.Key, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorSize) Matches(document interface{}) bool {
+	for _, candidate := range Lookup(document, filter.Key) {
+		values := elements(candidate)
+
+		if values != nil && len(values) == filter.Size {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Checks whether the given document matches this filter.
+//
+// Parameters:
+//
+//	document The document to check.
+//
+// Returns:
+//
+//	Whether the document matches this filter.
+func (filter FilterOperatorElemMatch) Matches(document interface{}) bool {
+	for _, candidate := range Lookup(document, filter.Key) {
+		for _, element := range elements(candidate) {
+			if matches(filter.Match, element) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Evaluates a sub filter against a document.
+//	Sub filters which cannot be evaluated in-process never match.
+//
+// Parameters:
+//
+//	filter 		The sub filter to evaluate.
+//	document 	The document to check.
+//
+// Returns:
+//
+//	Whether the document matches the sub filter.
+func matches(filter IQuery, document interface{}) bool {
+	matcher, ok := filter.(IMatcher)
+	if !ok {
+		return false
+	}
+
+	return matcher.Matches(document)
+}
+
+// Description:
+//
+//	Returns the given values, or an empty array if there are none.
+//	MongoDB rejects null where an array is expected.
+//
+// Parameters:
+//
+//	values The values.
+//
+// Returns:
+//
+//	The values, never nil.
+func valuesOrEmpty(values []interface{}) []interface{} {
+	if values == nil {
+		return make([]interface{}, 0)
+	}
+
+	return values
+}
+
+// Description:
+//
+//	Converts a compiled sub filter into an element condition for '$elemMatch'.
+//	Conditions on the empty key refer to scalar array elements and are unwrapped.
+//
+// Parameters:
+//
+//	condition The compiled sub filter.
+//
+// Returns:
+//
+//	The element condition.
+func elementCondition(condition bson.M) bson.M {
+	if len(condition) != 1 {
+		return condition
+	}
+
+	if value, ok := condition[""]; ok {
+		expression, ok := value.(bson.M)
+		if !ok {
+			expression = bson.M{"$eq": value}
+		}
+
+		return expression
+	}
+
+	and, ok := condition["$and"].([]bson.M)
+	if !ok {
+		return condition
+	}
+
+	merged := bson.M{}
+	for _, sub := range and {
+		if _, ok := sub[""]; !ok || len(sub) != 1 {
+			return condition
+		}
+
+		for operator, value := range elementCondition(sub) {
+			if _, exists := merged[operator]; exists {
+				return condition
+			}
+
+			merged[operator] = value
+		}
+	}
+
+	return merged
+}
+
+// Description:
+//
+//	Validates a regular expression used in a filter.
+//	Patterns must compile as Go regular expressions, so that every store evaluates them identically.
+//	MongoDB evaluates patterns with a backtracking engine, so long patterns, nested quantifiers
+//	and quantified alternations, e.g. '(a+)+' or '(a|ab)*', are rejected.
+//
+// Parameters:
+//
+//	pattern The regular expression pattern.
+//	options The regular expression options.
+//
+// Returns:
+//
+//	An error if the regular expression is invalid or not allowed.
+func ValidateRegex(pattern string, options string) error {
+	if len(pattern) > MaxRegexLength {
+		return fmt.Errorf("query: regular expression exceeds %d characters", MaxRegexLength)
+	}
+
+	prepared, err := prepareRegex(pattern, options)
+	if err != nil {
+		return err
+	}
+
+	tree, err := syntax.Parse(prepared, syntax.Perl)
+	if err != nil {
+		return fmt.Errorf("query: invalid regular expression: %s", err)
+	}
+
+	if backtracks(tree, false) {
+		return fmt.Errorf("query: regular expression contains nested quantifiers or quantified alternations")
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Checks whether a parsed regular expression contains a quantified sub expression
+//	which is itself quantified or an alternation.
+//
+// Parameters:
+//
+//	tree 		The parsed regular expression.
+//	quantified 	Whether the expression is part of a quantified sub expression.
+//
+// Returns:
+//
+//	Whether the expression may cause excessive backtracking.
+func backtracks(tree *syntax.Regexp, quantified bool) bool {
+	switch tree.Op {
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		if quantified {
+			return true
+		}
+
+		quantified = true
+	case syntax.OpAlternate:
+		if quantified {
+			return true
+		}
+	}
+
+	for _, sub := range tree.Sub {
+		if backtracks(sub, quantified) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Compiles a MongoDB regular expression into a Go regular expression.
+//	Supports the 'i', 'm', 's' and 'x' options.
+//
+// Parameters:
+//
+//	pattern The regular expression pattern.
+//	options The regular expression options.
+//
+// Returns:
+//
+//	The compiled regular expression, or an error if the pattern or options are invalid.
+func compileRegex(pattern string, options string) (*regexp.Regexp, error) {
+	prepared, err := prepareRegex(pattern, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return regexp.Compile(prepared)
+}
+
+// Description:
+//
+//	Converts a MongoDB regular expression into a Go regular expression pattern.
+//	The options are applied as inline flags.
+//
+// Parameters:
+//
+//	pattern The regular expression pattern.
+//	options The regular expression options.
+//
+// Returns:
+//
+//	The Go regular expression pattern, or an error if the options are invalid.
+func prepareRegex(pattern string, options string) (string, error) {
+	flags := ""
+
+	for _, option := range options {
+		switch option {
+		case 'i', 'm', 's':
+			flags += string(option)
+		case 'x':
+			pattern = stripExtendedRegex(pattern)
+		default:
+			return "", fmt.Errorf("query: unsupported regex option: %c", option)
+		}
+	}
+
+	if flags != "" {
+		pattern = fmt.Sprintf("(?%s)%s", flags, pattern)
+	}
+
+	return pattern, nil
+}
+
+// Description:
+//
+//	Removes unescaped whitespace and comments from an extended regular expression.
+//	Character classes are kept as they are.
+//
+// Parameters:
+//
+//	pattern The extended regular expression pattern.
+//
+// Returns:
+//
+//	The plain regular expression pattern.
+func stripExtendedRegex(pattern string) string {
+	var builder strings.Builder
+
+	escaped := false
+	inClass := false
+	inComment := false
+
+	for _, character := range pattern {
+		switch {
+		case inComment:
+			inComment = character != '\n'
+			continue
+		case escaped:
+			escaped = false
+		case character == '\\':
+			escaped = true
+		case inClass:
+			inClass = character != ']'
+		case character == '[':
+			inClass = true
+		case character == '#':
+			inComment = true
+			continue
+		case unicode.IsSpace(character):
+			continue
+		}
+
+		builder.WriteRune(character)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,335 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Description:
+//
+//	The document the filter tests are evaluated against.
+var filterTestDocument = bson.M{
+	"_id":       "6f1c2a0e",
+	"name":      "Daft Punk",
+	"genres":    bson.A{"electronic", "house"},
+	"followers": int64(1000),
+	"stats": bson.M{
+		"popularity": 0.8,
+	},
+	"albums": bson.A{
+		bson.M{"title": "Discovery", "year": int32(2001)},
+		bson.M{"title": "Homework", "year": int32(1997)},
+	},
+}
+
+// Description:
+//
+//	Tests the compilation of filters into MongoDB BSON documents.
+//
+// Parameters:
+//
+//	t The test context.
+func TestFilterCompile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   IQuery
+		expected bson.M
+	}{
+		{
+			name:     "in",
+			filter:   FilterOperatorIn{Key: "genres", Values: []interface{}{"rock", "house"}},
+			expected: bson.M{"genres": bson.M{"$in": []interface{}{"rock", "house"}}},
+		},
+		{
+			name:     "in without values",
+			filter:   FilterOperatorIn{Key: "genres"},
+			expected: bson.M{"genres": bson.M{"$in": []interface{}{}}},
+		},
+		{
+			name:     "nin",
+			filter:   FilterOperatorNin{Key: "genres", Values: []interface{}{"rock"}},
+			expected: bson.M{"genres": bson.M{"$nin": []interface{}{"rock"}}},
+		},
+		{
+			name:     "nin without values",
+			filter:   FilterOperatorNin{Key: "genres"},
+			expected: bson.M{"genres": bson.M{"$nin": []interface{}{}}},
+		},
+		{
+			name:     "exists",
+			filter:   FilterOperatorExists{Key: "deletedAt", Exists: true},
+			expected: bson.M{"deletedAt": bson.M{"$exists": true}},
+		},
+		{
+			name:     "not exists",
+			filter:   FilterOperatorExists{Key: "deletedAt", Exists: false},
+			expected: bson.M{"deletedAt": bson.M{"$exists": false}},
+		},
+		{
+			name:     "regex",
+			filter:   FilterOperatorRegex{Key: "name", Pattern: "^daft"},
+			expected: bson.M{"name": bson.M{"$regex": "^daft"}},
+		},
+		{
+			name:     "regex with options",
+			filter:   FilterOperatorRegex{Key: "name", Pattern: "^daft", Options: "i"},
+			expected: bson.M{"name": bson.M{"$regex": "^daft", "$options": "i"}},
+		},
+		{
+			name:     "all",
+			filter:   FilterOperatorAll{Key: "genres", Values: []interface{}{"house", "electronic"}},
+			expected: bson.M{"genres": bson.M{"$all": []interface{}{"house", "electronic"}}},
+		},
+		{
+			name:     "size",
+			filter:   FilterOperatorSize{Key: "genres", Size: 2},
+			expected: bson.M{"genres": bson.M{"$size": 2}},
+		},
+		{
+			name: "nor",
+			filter: FilterOperatorNor{Nor: []IQuery{
+				FilterOperatorEq{Key: "name", Value: "Justice"},
+				FilterOperatorGt{Key: "followers", Value: 5000},
+			}},
+			expected: bson.M{"$nor": []bson.M{
+				{"name": "Justice"},
+				{"followers": bson.M{"$gt": 5000}},
+			}},
+		},
+		{
+			name:     "not of field equality",
+			filter:   FilterOperatorNot{Not: FilterOperatorEq{Key: "name", Value: "Justice"}},
+			expected: bson.M{"name": bson.M{"$not": bson.M{"$eq": "Justice"}}},
+		},
+		{
+			name:     "not of field operator",
+			filter:   FilterOperatorNot{Not: FilterOperatorIn{Key: "genres", Values: []interface{}{"rock"}}},
+			expected: bson.M{"genres": bson.M{"$not": bson.M{"$in": []interface{}{"rock"}}}},
+		},
+		{
+			name: "not of logical operator",
+			filter: FilterOperatorNot{Not: FilterOperatorOr{Or: []IQuery{
+				FilterOperatorEq{Key: "name", Value: "Justice"},
+			}}},
+			expected: bson.M{"$nor": []bson.M{
+				{"$or": []bson.M{{"name": "Justice"}}},
+			}},
+		},
+		{
+			name: "element match on documents",
+			filter: FilterOperatorElemMatch{Key: "albums", Match: FilterOperatorAnd{And: []IQuery{
+				FilterOperatorEq{Key: "title", Value: "Discovery"},
+				FilterOperatorGte{Key: "year", Value: 2000},
+			}}},
+			expected: bson.M{"albums": bson.M{"$elemMatch": bson.M{"$and": []bson.M{
+				{"title": "Discovery"},
+				{"year": bson.M{"$gte": 2000}},
+			}}}},
+		},
+		{
+			name:     "element match on scalars",
+			filter:   FilterOperatorElemMatch{Key: "genres", Match: FilterOperatorEq{Key: "", Value: "house"}},
+			expected: bson.M{"genres": bson.M{"$elemMatch": bson.M{"$eq": "house"}}},
+		},
+		{
+			name: "element match on scalar range",
+			filter: FilterOperatorElemMatch{Key: "scores", Match: FilterOperatorAnd{And: []IQuery{
+				FilterOperatorGte{Key: "", Value: 80},
+				FilterOperatorLt{Key: "", Value: 90},
+			}}},
+			expected: bson.M{"scores": bson.M{"$elemMatch": bson.M{"$gte": 80, "$lt": 90}}},
+		},
+		{
+			name: "nested operators",
+			filter: FilterOperatorAnd{And: []IQuery{
+				FilterOperatorIn{Key: "genres", Values: []interface{}{"house"}},
+				FilterOperatorNot{Not: FilterOperatorExists{Key: "deletedAt", Exists: true}},
+			}},
+			expected: bson.M{"$and": []bson.M{
+				{"genres": bson.M{"$in": []interface{}{"house"}}},
+				{"deletedAt": bson.M{"$not": bson.M{"$exists": true}}},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.filter.Compile()
+
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests the in-memory evaluation of filters, which must agree with MongoDB.
+//
+// Parameters:
+//
+//	t The test context.
+func TestFilterMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   IMatcher
+		expected bool
+	}{
+		{"in matches scalar", FilterOperatorIn{Key: "name", Values: []interface{}{"Justice", "Daft Punk"}}, true},
+		{"in matches array element", FilterOperatorIn{Key: "genres", Values: []interface{}{"rock", "house"}}, true},
+		{"in without match", FilterOperatorIn{Key: "genres", Values: []interface{}{"rock"}}, false},
+		{"in without values", FilterOperatorIn{Key: "genres"}, false},
+		{"in on missing field", FilterOperatorIn{Key: "label", Values: []interface{}{"Virgin"}}, false},
+		{"in matches missing field by nil", FilterOperatorIn{Key: "label", Values: []interface{}{nil}}, true},
+		{"in on nested field", FilterOperatorIn{Key: "stats.popularity", Values: []interface{}{0.8}}, true},
+		{"in compares numbers across types", FilterOperatorIn{Key: "followers", Values: []interface{}{1000}}, true},
+
+		{"nin without match", FilterOperatorNin{Key: "genres", Values: []interface{}{"rock"}}, true},
+		{"nin with array element match", FilterOperatorNin{Key: "genres", Values: []interface{}{"house"}}, false},
+		{"nin on missing field", FilterOperatorNin{Key: "label", Values: []interface{}{"Virgin"}}, true},
+		{"nin without values", FilterOperatorNin{Key: "genres"}, true},
+
+		{"exists on present field", FilterOperatorExists{Key: "name", Exists: true}, true},
+		{"exists on missing field", FilterOperatorExists{Key: "deletedAt", Exists: true}, false},
+		{"not exists on missing field", FilterOperatorExists{Key: "deletedAt", Exists: false}, true},
+		{"exists on nested field", FilterOperatorExists{Key: "stats.popularity", Exists: true}, true},
+		{"exists on missing nested field", FilterOperatorExists{Key: "stats.rank", Exists: true}, false},
+		{"exists within array documents", FilterOperatorExists{Key: "albums.year", Exists: true}, true},
+
+		{"regex", FilterOperatorRegex{Key: "name", Pattern: "^Daft"}, true},
+		{"regex is case-sensitive", FilterOperatorRegex{Key: "name", Pattern: "^daft"}, false},
+		{"regex with case-insensitive option", FilterOperatorRegex{Key: "name", Pattern: "^daft", Options: "i"}, true},
+		{"regex with extended option", FilterOperatorRegex{Key: "name", Pattern: "^daft \\ punk # comment", Options: "ix"}, true},
+		{"regex on array elements", FilterOperatorRegex{Key: "genres", Pattern: "^hou"}, true},
+		{"regex on non-string field", FilterOperatorRegex{Key: "followers", Pattern: "1000"}, false},
+		{"regex on missing field", FilterOperatorRegex{Key: "label", Pattern: ".*"}, false},
+		{"regex with invalid pattern", FilterOperatorRegex{Key: "name", Pattern: "("}, false},
+		{"regex with invalid option", FilterOperatorRegex{Key: "name", Pattern: "Daft", Options: "q"}, false},
+
+		{"all", FilterOperatorAll{Key: "genres", Values: []interface{}{"house", "electronic"}}, true},
+		{"all with missing value", FilterOperatorAll{Key: "genres", Values: []interface{}{"house", "rock"}}, false},
+		{"all without values", FilterOperatorAll{Key: "genres"}, false},
+		{"all on missing field", FilterOperatorAll{Key: "label", Values: []interface{}{"Virgin"}}, false},
+
+		{"size", FilterOperatorSize{Key: "genres", Size: 2}, true},
+		{"size mismatch", FilterOperatorSize{Key: "genres", Size: 1}, false},
+		{"size on scalar", FilterOperatorSize{Key: "name", Size: 1}, false},
+		{"size on missing field", FilterOperatorSize{Key: "label", Size: 0}, false},
+
+		{"nor without match", FilterOperatorNor{Nor: []IQuery{FilterOperatorEq{Key: "name", Value: "Justice"}}}, true},
+		{"nor with match", FilterOperatorNor{Nor: []IQuery{
+			FilterOperatorEq{Key: "name", Value: "Justice"},
+			FilterOperatorEq{Key: "name", Value: "Daft Punk"},
+		}}, false},
+		{"nor without conditions", FilterOperatorNor{}, true},
+
+		{"not of match", FilterOperatorNot{Not: FilterOperatorEq{Key: "name", Value: "Daft Punk"}}, false},
+		{"not of mismatch", FilterOperatorNot{Not: FilterOperatorEq{Key: "name", Value: "Justice"}}, true},
+		{"not matches missing field", FilterOperatorNot{Not: FilterOperatorGt{Key: "label", Value: 0}}, true},
+		{"double not", FilterOperatorNot{Not: FilterOperatorNot{Not: FilterOperatorEq{Key: "name", Value: "Daft Punk"}}}, true},
+
+		{"element match on documents", FilterOperatorElemMatch{Key: "albums", Match: FilterOperatorAnd{And: []IQuery{
+			FilterOperatorEq{Key: "title", Value: "Discovery"},
+			FilterOperatorGte{Key: "year", Value: 2000},
+		}}}, true},
+		{"element match requires a single element", FilterOperatorElemMatch{Key: "albums", Match: FilterOperatorAnd{And: []IQuery{
+			FilterOperatorEq{Key: "title", Value: "Homework"},
+			FilterOperatorGte{Key: "year", Value: 2000},
+		}}}, false},
+		{"element match on scalars", FilterOperatorElemMatch{Key: "genres", Match: FilterOperatorEq{Key: "", Value: "house"}}, true},
+		{"element match on scalar mismatch", FilterOperatorElemMatch{Key: "genres", Match: FilterOperatorEq{Key: "", Value: "rock"}}, false},
+		{"element match on scalar field", FilterOperatorElemMatch{Key: "name", Match: FilterOperatorEq{Key: "", Value: "Daft Punk"}}, false},
+		{"element match on missing field", FilterOperatorElemMatch{Key: "singles", Match: FilterOperatorExists{Key: "title", Exists: true}}, false},
+
+		{"nested operators", FilterOperatorAnd{And: []IQuery{
+			FilterOperatorIn{Key: "genres", Values: []interface{}{"house"}},
+			FilterOperatorNot{Not: FilterOperatorExists{Key: "deletedAt", Exists: true}},
+			FilterOperatorOr{Or: []IQuery{
+				FilterOperatorSize{Key: "genres", Size: 3},
+				FilterOperatorRegex{Key: "albums.title", Pattern: "^Home"},
+			}},
+		}}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.filter.Matches(filterTestDocument)
+
+			if actual != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests that filters which MongoDB would reject or which cannot be compiled are invalid.
+//
+// Parameters:
+//
+//	t The test context.
+func TestFilterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		root    IQuery
+		message string
+	}{
+		{"no root", nil, ""},
+		{"field filter", FilterOperatorEq{Key: "name", Value: "Daft Punk"}, ""},
+		{"nested operators", FilterOperatorAnd{And: []IQuery{
+			FilterOperatorOr{Or: []IQuery{FilterOperatorEq{Key: "name", Value: "Justice"}}},
+			FilterOperatorNot{Not: FilterOperatorExists{Key: "deletedAt", Exists: true}},
+			FilterOperatorElemMatch{Key: "genres", Match: FilterOperatorEq{Key: "", Value: "house"}},
+		}}, ""},
+
+		{"empty and", FilterOperatorAnd{}, "'and' requires at least one sub filter"},
+		{"empty or", FilterOperatorOr{Or: []IQuery{}}, "'or' requires at least one sub filter"},
+		{"empty nor", FilterOperatorNor{}, "'nor' requires at least one sub filter"},
+		{"nested empty or", FilterOperatorAnd{And: []IQuery{FilterOperatorOr{}}}, "'or' requires at least one sub filter"},
+		{"nil sub filter", FilterOperatorOr{Or: []IQuery{FilterOperatorEq{Key: "name"}, nil}}, "missing sub filter"},
+		{"nil not", FilterOperatorNot{}, "missing sub filter"},
+		{"nil element match", FilterOperatorElemMatch{Key: "albums"}, "missing sub filter"},
+		{"empty and in element match", FilterOperatorElemMatch{Key: "albums", Match: FilterOperatorAnd{}}, "'and' requires at least one sub filter"},
+
+		{"regex", FilterOperatorRegex{Key: "name", Pattern: "^daft.*punk$", Options: "i"}, ""},
+		{"regex with bounded repetition", FilterOperatorRegex{Key: "name", Pattern: "^[a-z]{2,8}$"}, ""},
+		{"regex with alternation", FilterOperatorRegex{Key: "name", Pattern: "^(daft|punk)"}, ""},
+		{"regex with invalid pattern", FilterOperatorRegex{Key: "name", Pattern: "("}, "invalid regular expression"},
+		{"regex with lookbehind", FilterOperatorRegex{Key: "name", Pattern: "(?<=x)a"}, "invalid regular expression"},
+		{"regex with backreference", FilterOperatorRegex{Key: "name", Pattern: "(a)\\1"}, "invalid regular expression"},
+		{"regex with invalid option", FilterOperatorRegex{Key: "name", Pattern: "daft", Options: "q"}, "unsupported regex option"},
+		{"regex with nested quantifiers", FilterOperatorRegex{Key: "name", Pattern: "(a+)+$"}, "nested quantifiers"},
+		{"regex with nested bounded quantifiers", FilterOperatorRegex{Key: "name", Pattern: "(a{1,3}){1,3}"}, "nested quantifiers"},
+		{"regex with quantified alternation", FilterOperatorRegex{Key: "name", Pattern: "(a|ab)*c"}, "quantified alternations"},
+		{"regex too long", FilterOperatorRegex{Key: "name", Pattern: strings.Repeat("a", MaxRegexLength+1)}, "exceeds 256 characters"},
+		{"regex in not", FilterOperatorNot{Not: FilterOperatorRegex{Key: "name", Pattern: "(a*)*"}}, "nested quantifiers"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter := Filter{Root: test.root}
+
+			err := filter.Validate()
+			if test.message == "" {
+				if err != nil {
+					t.Errorf("expected filter to be valid, got '%s'", err)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected an error containing '%s'", test.message)
+			}
+
+			if !strings.Contains(err.Error(), test.message) {
+				t.Errorf("expected error containing '%s', got '%s'", test.message, err)
+			}
+		})
+	}
+}
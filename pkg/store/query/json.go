@@ -0,0 +1,663 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Description:
+//
+//	The JSON representation of a filter.
+type jsonFilter struct {
+
+	// The root filter node.
+	Root json.RawMessage `json:"filter,omitempty"`
+
+	// The query result limit.
+	Limit uint32 `json:"limit,omitempty"`
+
+	// The number of query results to skip.
+	Skip uint32 `json:"skip,omitempty"`
+
+	// The sort specification.
+	Sort []SortKey `json:"sort,omitempty"`
+
+	// The document keys to include in query results.
+	Projection []string `json:"projection,omitempty"`
+}
+
+// Description:
+//
+//	The JSON representation of an update.
+type jsonUpdate struct {
+
+	// The root update node.
+	Root json.RawMessage `json:"update,omitempty"`
+}
+
+// Description:
+//
+//	A decoded, type-tagged JSON node.
+//	Collects the first error which occurs while reading node properties.
+type jsonNode struct {
+
+	// The node type.
+	nodeType string
+
+	// The node properties.
+	properties map[string]json.RawMessage
+
+	// The first error which occurred while reading node properties.
+	err error
+}
+
+// Description:
+//
+//	Encodes the filter as JSON.
+//	Filter nodes are type-tagged, values are encoded as MongoDB extended JSON.
+//
+// Returns:
+//
+//	The JSON encoding, or an error if the filter cannot be encoded.
+func (filter Filter) MarshalJSON() ([]byte, error) {
+	result := jsonFilter{
+		Limit:      filter.Limit,
+		Skip:       filter.Skip,
+		Sort:       filter.Sort,
+		Projection: filter.Projection,
+	}
+
+	if filter.Root != nil {
+		root, err := MarshalQuery(filter.Root)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Root = root
+	}
+
+	return json.Marshal(result)
+}
+
+// Description:
+//
+//	Decodes the filter from JSON.
+//
+// Parameters:
+//
+//	data The JSON encoding.
+//
+// Returns:
+//
+//	An error if the JSON encoding is invalid.
+func (filter *Filter) UnmarshalJSON(data []byte) error {
+	decoded := jsonFilter{}
+
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+
+	var root IQuery
+
+	if len(decoded.Root) > 0 && string(decoded.Root) != "null" {
+		root, err = UnmarshalQuery(decoded.Root)
+		if err != nil {
+			return err
+		}
+	}
+
+	*filter = Filter{
+		Root:       root,
+		Limit:      decoded.Limit,
+		Skip:       decoded.Skip,
+		Sort:       decoded.Sort,
+		Projection: decoded.Projection,
+	}
+
+	return filter.Validate()
+}
+
+// Description:
+//
+//	Encodes the update as JSON.
+//	Update nodes are type-tagged, values are encoded as MongoDB extended JSON.
+//
+// Returns:
+//
+//	The JSON encoding, or an error if the update cannot be encoded.
+func (update Update) MarshalJSON() ([]byte, error) {
+	result := jsonUpdate{}
+
+	if update.Root != nil {
+		root, err := MarshalQuery(update.Root)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Root = root
+	}
+
+	return json.Marshal(result)
+}
+
+// Description:
+//
+//	Decodes the update from JSON.
+//
+// Parameters:
+//
+//	data The JSON encoding.
+//
+// Returns:
+//
+//	An error if the JSON encoding is invalid.
+func (update *Update) UnmarshalJSON(data []byte) error {
+	decoded := jsonUpdate{}
+
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+
+	var root IQuery
+
+	if len(decoded.Root) > 0 && string(decoded.Root) != "null" {
+		root, err = UnmarshalQuery(decoded.Root)
+		if err != nil {
+			return err
+		}
+	}
+
+	*update = Update{
+		Root: root,
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Encodes a filter or update operator as type-tagged JSON node.
+//
+// Parameters:
+//
+//	node The filter or update operator.
+//
+// Returns:
+//
+//	The JSON encoding, or an error if the operator is unknown or cannot be encoded.
+func MarshalQuery(node IQuery) (json.RawMessage, error) {
+	properties := make(map[string]interface{})
+	var err error
+
+	set := func(name string, value interface{}) {
+		if err == nil {
+			properties[name], err = marshalValue(value)
+		}
+	}
+
+	nodes := func(name string, values []IQuery) {
+		encoded := make([]json.RawMessage, 0, len(values))
+
+		for _, value := range values {
+			if err != nil {
+				return
+			}
+
+			var child json.RawMessage
+
+			child, err = MarshalQuery(value)
+			encoded = append(encoded, child)
+		}
+
+		properties[name] = encoded
+	}
+
+	switch node := node.(type) {
+	case FilterOperatorAnd:
+		properties["type"] = "and"
+		nodes("and", node.And)
+	case FilterOperatorOr:
+		properties["type"] = "or"
+		nodes("or", node.Or)
+	case FilterOperatorNor:
+		properties["type"] = "nor"
+		nodes("nor", node.Nor)
+	case FilterOperatorNot:
+		properties["type"] = "not"
+		properties["not"], err = MarshalQuery(node.Not)
+	case FilterOperatorEq:
+		properties["type"], properties["key"] = "eq", node.Key
+		set("value", node.Value)
+	case FilterOperatorNeq:
+		properties["type"], properties["key"] = "neq", node.Key
+		set("value", node.Value)
+	case FilterOperatorLt:
+		properties["type"], properties["key"] = "lt", node.Key
+		set("value", node.Value)
+	case FilterOperatorLte:
+		properties["type"], properties["key"] = "lte", node.Key
+		set("value", node.Value)
+	case FilterOperatorGt:
+		properties["type"], properties["key"] = "gt", node.Key
+		set("value", node.Value)
+	case FilterOperatorGte:
+		properties["type"], properties["key"] = "gte", node.Key
+		set("value", node.Value)
+	case FilterOperatorIn:
+		properties["type"], properties["key"] = "in", node.Key
+		set("values", valuesOrEmpty(node.Values))
+	case FilterOperatorNin:
+		properties["type"], properties["key"] = "nin", node.Key
+		set("values", valuesOrEmpty(node.Values))
+	case FilterOperatorAll:
+		properties["type"], properties["key"] = "all", node.Key
+		set("values", valuesOrEmpty(node.Values))
+	case FilterOperatorExists:
+		properties["type"], properties["key"], properties["exists"] = "exists", node.Key, node.Exists
+	case FilterOperatorRegex:
+		properties["type"], properties["key"], properties["pattern"] = "regex", node.Key, node.Pattern
+		properties["options"] = node.Options
+	case FilterOperatorSize:
+		properties["type"], properties["key"], properties["size"] = "size", node.Key, node.Size
+	case FilterOperatorElemMatch:
+		properties["type"], properties["key"] = "elemMatch", node.Key
+		properties["match"], err = MarshalQuery(node.Match)
+	case UpdateOperatorSet:
+		properties["type"] = "set"
+		set("set", node.Set)
+	case UpdateOperatorInc:
+		properties["type"] = "inc"
+		set("inc", node.Inc)
+	case UpdateOperatorUnset:
+		properties["type"], properties["unset"] = "unset", node.Unset
+	case UpdateOperatorPush:
+		properties["type"] = "push"
+		set("push", node.Push)
+	case UpdateOperatorPull:
+		properties["type"] = "pull"
+		set("pull", node.Pull)
+	case UpdateOperatorAddToSet:
+		properties["type"] = "addToSet"
+		set("addToSet", node.AddToSet)
+	case UpdateOperatorMin:
+		properties["type"] = "min"
+		set("min", node.Min)
+	case UpdateOperatorMax:
+		properties["type"] = "max"
+		set("max", node.Max)
+	case UpdateOperatorMul:
+		properties["type"] = "mul"
+		set("mul", node.Mul)
+	case UpdateOperatorRename:
+		properties["type"], properties["rename"] = "rename", node.Rename
+	case UpdateOperatorCurrentDate:
+		properties["type"], properties["currentDate"] = "currentDate", node.CurrentDate
+	case UpdateOperatorCombine:
+		properties["type"] = "combine"
+		nodes("combine", node.Combine)
+	default:
+		return nil, fmt.Errorf("query: cannot encode operator: %T", node)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(properties)
+}
+
+// Description:
+//
+//	Decodes a type-tagged JSON node into a filter or update operator.
+//	Values may be given as MongoDB extended JSON or as plain JSON.
+//
+// Parameters:
+//
+//	data The JSON encoding.
+//
+// Returns:
+//
+//	The decoded operator, or an error if the JSON encoding is invalid.
+func UnmarshalQuery(data []byte) (IQuery, error) {
+	properties := make(map[string]json.RawMessage)
+
+	err := json.Unmarshal(data, &properties)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &jsonNode{
+		properties: properties,
+	}
+
+	node.read("type", &node.nodeType)
+
+	var result IQuery
+
+	switch node.nodeType {
+	case "and":
+		result = FilterOperatorAnd{And: node.nodes("and")}
+	case "or":
+		result = FilterOperatorOr{Or: node.nodes("or")}
+	case "nor":
+		result = FilterOperatorNor{Nor: node.nodes("nor")}
+	case "not":
+		result = FilterOperatorNot{Not: node.node("not")}
+	case "eq":
+		result = FilterOperatorEq{Key: node.key(), Value: node.value("value")}
+	case "neq":
+		result = FilterOperatorNeq{Key: node.key(), Value: node.value("value")}
+	case "lt":
+		result = FilterOperatorLt{Key: node.key(), Value: node.value("value")}
+	case "lte":
+		result = FilterOperatorLte{Key: node.key(), Value: node.value("value")}
+	case "gt":
+		result = FilterOperatorGt{Key: node.key(), Value: node.value("value")}
+	case "gte":
+		result = FilterOperatorGte{Key: node.key(), Value: node.value("value")}
+	case "in":
+		result = FilterOperatorIn{Key: node.key(), Values: node.values("values")}
+	case "nin":
+		result = FilterOperatorNin{Key: node.key(), Values: node.values("values")}
+	case "all":
+		result = FilterOperatorAll{Key: node.key(), Values: node.values("values")}
+	case "exists":
+		filter := FilterOperatorExists{Key: node.key()}
+		node.read("exists", &filter.Exists)
+		result = filter
+	case "regex":
+		filter := FilterOperatorRegex{Key: node.key()}
+		node.read("pattern", &filter.Pattern)
+		node.optional("options", &filter.Options)
+		result = filter
+	case "size":
+		filter := FilterOperatorSize{Key: node.key()}
+		node.read("size", &filter.Size)
+		result = filter
+	case "elemMatch":
+		result = FilterOperatorElemMatch{Key: node.key(), Match: node.node("match")}
+	case "set":
+		result = UpdateOperatorSet{Set: node.fields("set")}
+	case "inc":
+		result = UpdateOperatorInc{Inc: node.fields("inc")}
+	case "unset":
+		update := UpdateOperatorUnset{}
+		node.read("unset", &update.Unset)
+		result = update
+	case "push":
+		result = UpdateOperatorPush{Push: node.fields("push")}
+	case "pull":
+		result = UpdateOperatorPull{Pull: node.fields("pull")}
+	case "addToSet":
+		result = UpdateOperatorAddToSet{AddToSet: node.fields("addToSet")}
+	case "min":
+		result = UpdateOperatorMin{Min: node.fields("min")}
+	case "max":
+		result = UpdateOperatorMax{Max: node.fields("max")}
+	case "mul":
+		result = UpdateOperatorMul{Mul: node.fields("mul")}
+	case "rename":
+		update := UpdateOperatorRename{}
+		node.read("rename", &update.Rename)
+		result = update
+	case "currentDate":
+		update := UpdateOperatorCurrentDate{}
+		node.read("currentDate", &update.CurrentDate)
+		result = update
+	case "combine":
+		result = UpdateOperatorCombine{Combine: node.nodes("combine")}
+	default:
+		if node.err == nil {
+			node.err = fmt.Errorf("query: unknown node type '%s'", node.nodeType)
+		}
+	}
+
+	if node.err != nil {
+		return nil, node.err
+	}
+
+	return result, nil
+}
+
+// Description:
+//
+//	Reads a required node property.
+//
+// Parameters:
+//
+//	name 	The property name.
+//	target 	The target to decode the property into.
+func (node *jsonNode) read(name string, target interface{}) {
+	if node.err != nil {
+		return
+	}
+
+	raw, ok := node.properties[name]
+	if !ok {
+		node.err = fmt.Errorf("query: node '%s' is missing property '%s'", node.nodeType, name)
+		return
+	}
+
+	err := json.Unmarshal(raw, target)
+	if err != nil {
+		node.err = fmt.Errorf("query: invalid property '%s' of node '%s': %s", name, node.nodeType, err)
+	}
+}
+
+// Description:
+//
+//	Reads an optional node property.
+//
+// Parameters:
+//
+//	name 	The property name.
+//	target 	The target to decode the property into.
+func (node *jsonNode) optional(name string, target interface{}) {
+	if _, ok := node.properties[name]; ok {
+		node.read(name, target)
+	}
+}
+
+// Description:
+//
+//	Reads the 'key' property of a node.
+//
+// Returns:
+//
+//	The document key.
+func (node *jsonNode) key() string {
+	key := ""
+	node.read("key", &key)
+
+	return key
+}
+
+// Description:
+//
+//	Reads a sub node property.
+//
+// Parameters:
+//
+//	name The property name.
+//
+// Returns:
+//
+//	The decoded sub node.
+func (node *jsonNode) node(name string) IQuery {
+	raw := json.RawMessage{}
+	node.read(name, &raw)
+
+	if node.err != nil {
+		return nil
+	}
+
+	result, err := UnmarshalQuery(raw)
+	node.err = err
+
+	return result
+}
+
+// Description:
+//
+//	Reads a sub node array property.
+//
+// Parameters:
+//
+//	name The property name.
+//
+// Returns:
+//
+//	The decoded sub nodes.
+func (node *jsonNode) nodes(name string) []IQuery {
+	raws := make([]json.RawMessage, 0)
+	node.read(name, &raws)
+
+	result := make([]IQuery, 0, len(raws))
+
+	for _, raw := range raws {
+		if node.err != nil {
+			return nil
+		}
+
+		child, err := UnmarshalQuery(raw)
+		node.err = err
+
+		result = append(result, child)
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Reads an extended JSON value property.
+//
+// Parameters:
+//
+//	name The property name.
+//
+// Returns:
+//
+//	The decoded value.
+func (node *jsonNode) value(name string) interface{} {
+	raw := json.RawMessage{}
+	node.read(name, &raw)
+
+	if node.err != nil {
+		return nil
+	}
+
+	value, err := unmarshalValue(raw)
+	if err != nil {
+		node.err = fmt.Errorf("query: invalid property '%s' of node '%s': %s", name, node.nodeType, err)
+	}
+
+	return value
+}
+
+// Description:
+//
+//	Reads an extended JSON array property.
+//
+// Parameters:
+//
+//	name The property name.
+//
+// Returns:
+//
+//	The decoded values.
+func (node *jsonNode) values(name string) []interface{} {
+	value := node.value(name)
+
+	if node.err != nil {
+		return nil
+	}
+
+	array, ok := value.(bson.A)
+	if !ok {
+		node.err = fmt.Errorf("query: property '%s' of node '%s' must be an array", name, node.nodeType)
+		return nil
+	}
+
+	return []interface{}(array)
+}
+
+// Description:
+//
+//	Reads an extended JSON field mapping property.
+//
+// Parameters:
+//
+//	name The property name.
+//
+// Returns:
+//
+//	The decoded field mappings.
+func (node *jsonNode) fields(name string) map[string]interface{} {
+	value := node.value(name)
+
+	if node.err != nil {
+		return nil
+	}
+
+	fields, ok := value.(bson.M)
+	if !ok {
+		node.err = fmt.Errorf("query: property '%s' of node '%s' must be an object", name, node.nodeType)
+		return nil
+	}
+
+	return map[string]interface{}(fields)
+}
+
+// Description:
+//
+//	Encodes a value as canonical MongoDB extended JSON, so that its BSON type is preserved.
+//
+// Parameters:
+//
+//	value The value to encode.
+//
+// Returns:
+//
+//	The extended JSON encoding, or an error if the value cannot be encoded.
+func marshalValue(value interface{}) (json.RawMessage, error) {
+	bytes, err := bson.MarshalExtJSON(bson.M{"value": value}, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapper := make(map[string]json.RawMessage)
+
+	err = json.Unmarshal(bytes, &wrapper)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapper["value"], nil
+}
+
+// Description:
+//
+//	Decodes a MongoDB extended JSON value. Plain JSON values are accepted as well.
+//
+// Parameters:
+//
+//	raw The extended JSON encoding.
+//
+// Returns:
+//
+//	The decoded value, or an error if the encoding is invalid.
+func unmarshalValue(raw json.RawMessage) (interface{}, error) {
+	wrapper := bson.M{}
+
+	err := bson.UnmarshalExtJSON([]byte(`{"value":`+string(raw)+`}`), false, &wrapper)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapper["value"], nil
+}
@@ -0,0 +1,264 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Description:
+//
+//	Normalizes a compiled query, so that equal queries compare equal regardless of Go value types,
+//	e.g. 'int' and 'int32' values, which MongoDB cannot distinguish either.
+//
+// Parameters:
+//
+//	t 			The test context.
+//	compiled 	The compiled query.
+//
+// Returns:
+//
+//	The normalized query.
+func normalize(t *testing.T, compiled bson.M) bson.M {
+	t.Helper()
+
+	bytes, err := bson.Marshal(compiled)
+	if err != nil {
+		t.Fatalf("failed to encode compiled query: %s", err)
+	}
+
+	result := bson.M{}
+
+	err = bson.Unmarshal(bytes, &result)
+	if err != nil {
+		t.Fatalf("failed to decode compiled query: %s", err)
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Tests that every node type survives a JSON round trip unchanged.
+//
+// Parameters:
+//
+//	t The test context.
+func TestQueryRoundTrip(t *testing.T) {
+	date := time.Date(2023, time.May, 4, 12, 30, 0, 0, time.UTC)
+	objectID, _ := primitive.ObjectIDFromHex("64538d0e4f1c2a0e5b7d4a43")
+
+	tests := []struct {
+		name string
+		node IQuery
+	}{
+		{"and", FilterOperatorAnd{And: []IQuery{
+			FilterOperatorEq{Key: "name", Value: "Daft Punk"},
+			FilterOperatorGt{Key: "followers", Value: int64(1000)},
+		}}},
+		{"or", FilterOperatorOr{Or: []IQuery{
+			FilterOperatorEq{Key: "name", Value: "Daft Punk"},
+			FilterOperatorEq{Key: "name", Value: "Justice"},
+		}}},
+		{"nor", FilterOperatorNor{Nor: []IQuery{FilterOperatorEq{Key: "name", Value: "Justice"}}}},
+		{"not", FilterOperatorNot{Not: FilterOperatorIn{Key: "genres", Values: []interface{}{"rock"}}}},
+		{"eq string", FilterOperatorEq{Key: "name", Value: "Daft Punk"}},
+		{"eq null", FilterOperatorEq{Key: "deletedAt", Value: nil}},
+		{"eq bool", FilterOperatorEq{Key: "verified", Value: true}},
+		{"eq date", FilterOperatorEq{Key: "createdAt", Value: date}},
+		{"eq object id", FilterOperatorEq{Key: "_id", Value: objectID}},
+		{"neq", FilterOperatorNeq{Key: "_id", Value: "6f1c2a0e"}},
+		{"lt", FilterOperatorLt{Key: "stats.popularity", Value: 0.5}},
+		{"lte", FilterOperatorLte{Key: "followers", Value: int32(10)}},
+		{"gt", FilterOperatorGt{Key: "followers", Value: int64(1) << 40}},
+		{"gte", FilterOperatorGte{Key: "createdAt", Value: date}},
+		{"in", FilterOperatorIn{Key: "genres", Values: []interface{}{"rock", "house"}}},
+		{"in without values", FilterOperatorIn{Key: "genres", Values: []interface{}{}}},
+		{"nin", FilterOperatorNin{Key: "genres", Values: []interface{}{"rock", nil}}},
+		{"all", FilterOperatorAll{Key: "genres", Values: []interface{}{"rock", "house"}}},
+		{"exists", FilterOperatorExists{Key: "deletedAt", Exists: false}},
+		{"regex", FilterOperatorRegex{Key: "name", Pattern: "^daft"}},
+		{"regex with options", FilterOperatorRegex{Key: "name", Pattern: "^daft", Options: "ix"}},
+		{"size", FilterOperatorSize{Key: "genres", Size: 2}},
+		{"elemMatch", FilterOperatorElemMatch{Key: "albums", Match: FilterOperatorAnd{And: []IQuery{
+			FilterOperatorEq{Key: "title", Value: "Discovery"},
+			FilterOperatorGte{Key: "year", Value: int32(2000)},
+		}}}},
+		{"elemMatch on scalars", FilterOperatorElemMatch{Key: "genres", Match: FilterOperatorEq{Key: "", Value: "house"}}},
+		{"set", UpdateOperatorSet{Set: map[string]interface{}{
+			"name":             "Daft Punk",
+			"genres":           bson.A{"electronic", "house"},
+			"stats.popularity": 0.8,
+			"deletedAt":        date,
+		}}},
+		{"inc", UpdateOperatorInc{Inc: map[string]interface{}{"version": int64(1), "followers": int32(-5)}}},
+		{"unset", UpdateOperatorUnset{Unset: []string{"nameKey", "deletedAt"}}},
+		{"push", UpdateOperatorPush{Push: map[string]interface{}{"genres": "jazz"}}},
+		{"pull", UpdateOperatorPull{Pull: map[string]interface{}{"genres": "rock"}}},
+		{"addToSet", UpdateOperatorAddToSet{AddToSet: map[string]interface{}{"genres": "jazz"}}},
+		{"min", UpdateOperatorMin{Min: map[string]interface{}{"stats.popularity": 0.1}}},
+		{"max", UpdateOperatorMax{Max: map[string]interface{}{"followers": int64(5000)}}},
+		{"mul", UpdateOperatorMul{Mul: map[string]interface{}{"followers": int32(2)}}},
+		{"rename", UpdateOperatorRename{Rename: map[string]string{"followers": "fans"}}},
+		{"currentDate", UpdateOperatorCurrentDate{CurrentDate: []string{"updatedAt"}}},
+		{"combine", UpdateOperatorCombine{Combine: []IQuery{
+			UpdateOperatorSet{Set: map[string]interface{}{"name": "Daft Punk"}},
+			UpdateOperatorUnset{Unset: []string{"nameKey"}},
+			UpdateOperatorInc{Inc: map[string]interface{}{"version": int64(1)}},
+		}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded, err := MarshalQuery(test.node)
+			if err != nil {
+				t.Fatalf("failed to encode node: %s", err)
+			}
+
+			decoded, err := UnmarshalQuery(encoded)
+			if err != nil {
+				t.Fatalf("failed to decode node %s: %s", encoded, err)
+			}
+
+			if reflect.TypeOf(decoded) != reflect.TypeOf(test.node) {
+				t.Fatalf("expected node of type %T, got %T", test.node, decoded)
+			}
+
+			expected := normalize(t, test.node.Compile())
+			actual := normalize(t, decoded.Compile())
+
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("expected %v, got %v (encoded as %s)", expected, actual, encoded)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests that filters and updates survive a JSON round trip unchanged, including their options.
+//
+// Parameters:
+//
+//	t The test context.
+func TestFilterAndUpdateRoundTrip(t *testing.T) {
+	filter := Filter{
+		Root: FilterOperatorAnd{And: []IQuery{
+			FilterOperatorIn{Key: "genres", Values: []interface{}{"rock"}},
+			FilterOperatorNot{Not: FilterOperatorExists{Key: "deletedAt", Exists: true}},
+		}},
+		Limit:      20,
+		Skip:       40,
+		Sort:       []SortKey{{Key: "stats.popularity", Order: SortDescending}, {Key: "_id", Order: SortAscending}},
+		Projection: []string{"name", "genres"},
+	}
+
+	encoded, err := json.Marshal(filter)
+	if err != nil {
+		t.Fatalf("failed to encode filter: %s", err)
+	}
+
+	decoded := Filter{}
+
+	err = json.Unmarshal(encoded, &decoded)
+	if err != nil {
+		t.Fatalf("failed to decode filter: %s", err)
+	}
+
+	if decoded.Limit != filter.Limit || decoded.Skip != filter.Skip {
+		t.Errorf("expected limit %d and skip %d, got %d and %d", filter.Limit, filter.Skip, decoded.Limit, decoded.Skip)
+	}
+
+	if !reflect.DeepEqual(decoded.Sort, filter.Sort) || !reflect.DeepEqual(decoded.Projection, filter.Projection) {
+		t.Errorf("expected sort %v and projection %v, got %v and %v", filter.Sort, filter.Projection, decoded.Sort, decoded.Projection)
+	}
+
+	if !reflect.DeepEqual(normalize(t, decoded.Root.Compile()), normalize(t, filter.Root.Compile())) {
+		t.Errorf("expected filter %v, got %v", filter.Root.Compile(), decoded.Root.Compile())
+	}
+
+	update := Update{
+		Root: UpdateOperatorSet{Set: map[string]interface{}{"followers": int64(10)}},
+	}
+
+	encoded, err = json.Marshal(update)
+	if err != nil {
+		t.Fatalf("failed to encode update: %s", err)
+	}
+
+	decodedUpdate := Update{}
+
+	err = json.Unmarshal(encoded, &decodedUpdate)
+	if err != nil {
+		t.Fatalf("failed to decode update: %s", err)
+	}
+
+	if !reflect.DeepEqual(normalize(t, decodedUpdate.Root.Compile()), normalize(t, update.Root.Compile())) {
+		t.Errorf("expected update %v, got %v", update.Root.Compile(), decodedUpdate.Root.Compile())
+	}
+
+	empty := Filter{}
+
+	err = json.Unmarshal([]byte(`{}`), &empty)
+	if err != nil || empty.Root != nil {
+		t.Errorf("expected empty filter without root, got %v (%v)", empty.Root, err)
+	}
+}
+
+// Description:
+//
+//	Tests that invalid nodes are rejected.
+//
+// Parameters:
+//
+//	t The test context.
+func TestUnmarshalQueryRejectsInvalidNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		message string
+	}{
+		{"unknown type", `{"type": "where", "key": "name", "value": "x"}`, "unknown node type 'where'"},
+		{"unknown mongo operator", `{"type": "$eq", "key": "name", "value": "x"}`, "unknown node type '$eq'"},
+		{"missing type", `{"key": "name", "value": "x"}`, "missing property 'type'"},
+		{"nested unknown type", `{"type": "and", "and": [{"type": "eq", "key": "name", "value": "x"}, {"type": "like", "key": "name"}]}`, "unknown node type 'like'"},
+		{"unknown type in not", `{"type": "not", "not": {"type": "between"}}`, "unknown node type 'between'"},
+		{"unknown type in combine", `{"type": "combine", "combine": [{"type": "append"}]}`, "unknown node type 'append'"},
+		{"missing key", `{"type": "eq", "value": "x"}`, "missing property 'key'"},
+		{"missing values", `{"type": "in", "key": "genres"}`, "missing property 'values'"},
+		{"values not an array", `{"type": "in", "key": "genres", "values": "rock"}`, "must be an array"},
+		{"fields not an object", `{"type": "set", "set": ["name"]}`, "must be an object"},
+		{"invalid size", `{"type": "size", "key": "genres", "size": "two"}`, "invalid property 'size'"},
+		{"not an object", `["eq"]`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node, err := UnmarshalQuery([]byte(test.json))
+			if err == nil {
+				t.Fatalf("expected an error, got %T", node)
+			}
+
+			if !strings.Contains(err.Error(), test.message) {
+				t.Errorf("expected error containing '%s', got '%s'", test.message, err)
+			}
+		})
+	}
+
+	filter := Filter{}
+
+	err := json.Unmarshal([]byte(`{"filter": {"type": "where"}}`), &filter)
+	if err == nil {
+		t.Errorf("expected filter with unknown node type to be rejected")
+	}
+
+	err = json.Unmarshal([]byte(`{"filter": {"type": "and", "and": []}}`), &filter)
+	if err == nil {
+		t.Errorf("expected filter with empty 'and' node to be rejected")
+	}
+}
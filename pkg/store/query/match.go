@@ -0,0 +1,650 @@
+package query
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+
+	// The reflection type of ordered BSON documents.
+	documentDType = reflect.TypeOf(primitive.D{})
+
+	// The reflection type of BSON object ids.
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+
+	// The reflection type of BSON date times.
+	dateTimeType = reflect.TypeOf(primitive.DateTime(0))
+
+	// The reflection type of times.
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// Description:
+//
+//	Resolves a dotted key within a document.
+//	Documents can be structs (resolved through their bson or json tags), maps or ordered BSON documents.
+//	Arrays of embedded documents are traversed element-wise, as MongoDB does.
+//	Numeric key segments index into arrays.
+//
+// Parameters:
+//
+//	document 	The document to resolve the key in.
+//	key 		The dotted key to resolve. An empty key resolves to the document itself.
+//
+// Returns:
+//
+//	All values found for the given key. Empty, if the key does not exist.
+func Lookup(document interface{}, key string) []interface{} {
+	if key == "" {
+		return []interface{}{document}
+	}
+
+	return lookup(reflect.ValueOf(document), strings.Split(key, "."))
+}
+
+// Description:
+//
+//	Checks whether two values are equal with MongoDB comparison semantics.
+//	Numbers are compared by value regardless of their type, arrays element-wise
+//	and documents field-wise.
+//
+// Parameters:
+//
+//	left 	The left value.
+//	right 	The right value.
+//
+// Returns:
+//
+//	Whether both values are equal.
+func Equal(left interface{}, right interface{}) bool {
+	leftValue := indirect(reflect.ValueOf(left))
+	rightValue := indirect(reflect.ValueOf(right))
+
+	if !leftValue.IsValid() || !rightValue.IsValid() {
+		return !leftValue.IsValid() && !rightValue.IsValid()
+	}
+
+	if isSequence(leftValue) && isSequence(rightValue) {
+		if leftValue.Len() != rightValue.Len() {
+			return false
+		}
+
+		for index := 0; index < leftValue.Len(); index++ {
+			if !Equal(leftValue.Index(index).Interface(), rightValue.Index(index).Interface()) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if isStringMap(leftValue) && isStringMap(rightValue) {
+		if leftValue.Len() != rightValue.Len() {
+			return false
+		}
+
+		iterator := leftValue.MapRange()
+		for iterator.Next() {
+			other := rightValue.MapIndex(iterator.Key())
+
+			if !other.IsValid() || !Equal(iterator.Value().Interface(), other.Interface()) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	order, ok := Compare(left, right)
+	if ok {
+		return order == 0
+	}
+
+	return reflect.DeepEqual(leftValue.Interface(), rightValue.Interface())
+}
+
+// Description:
+//
+//	Compares two scalar values with MongoDB comparison semantics.
+//	Only values of the same type class (numbers, strings, booleans, dates, object ids) are comparable.
+//
+// Parameters:
+//
+//	left 	The left value.
+//	right 	The right value.
+//
+// Returns:
+//
+//	The comparison result (-1, 0 or 1) and whether both values are comparable.
+func Compare(left interface{}, right interface{}) (int, bool) {
+	leftValue := indirect(reflect.ValueOf(left))
+	rightValue := indirect(reflect.ValueOf(right))
+
+	if !leftValue.IsValid() || !rightValue.IsValid() {
+		return 0, false
+	}
+
+	leftTime, leftOk := toTime(leftValue)
+	rightTime, rightOk := toTime(rightValue)
+
+	if leftOk || rightOk {
+		if !leftOk || !rightOk {
+			return 0, false
+		}
+
+		return leftTime.Compare(rightTime), true
+	}
+
+	if leftValue.Type() == objectIDType || rightValue.Type() == objectIDType {
+		if leftValue.Type() != rightValue.Type() {
+			return 0, false
+		}
+
+		leftID := leftValue.Interface().(primitive.ObjectID)
+		rightID := rightValue.Interface().(primitive.ObjectID)
+
+		return strings.Compare(leftID.Hex(), rightID.Hex()), true
+	}
+
+	leftNumber, leftOk := toNumber(leftValue)
+	rightNumber, rightOk := toNumber(rightValue)
+
+	if leftOk && rightOk {
+		return compareOrdered(leftNumber, rightNumber), true
+	}
+
+	if leftValue.Kind() == reflect.String && rightValue.Kind() == reflect.String {
+		return strings.Compare(leftValue.String(), rightValue.String()), true
+	}
+
+	if leftValue.Kind() == reflect.Bool && rightValue.Kind() == reflect.Bool {
+		return compareOrdered(boolToNumber(leftValue.Bool()), boolToNumber(rightValue.Bool())), true
+	}
+
+	return 0, false
+}
+
+// Description:
+//
+//	Checks whether the value at the given key equals the given value.
+//	Array fields match if the array itself or any of its elements equals the value.
+//	Missing fields match a nil value.
+//
+// Parameters:
+//
+//	document 	The document to check.
+//	key 		The dotted key.
+//	value 		The value to compare with.
+//
+// Returns:
+//
+//	Whether the value at the given key equals the given value.
+func matchEquals(document interface{}, key string, value interface{}) bool {
+	candidates := Lookup(document, key)
+
+	if len(candidates) == 0 {
+		return isNil(value)
+	}
+
+	for _, candidate := range candidates {
+		if Equal(candidate, value) {
+			return true
+		}
+
+		for _, element := range elements(candidate) {
+			if Equal(element, value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Checks whether any value at the given key satisfies an ordering condition.
+//	Array fields are checked element-wise. Values which are not comparable never match.
+//
+// Parameters:
+//
+//	document 	The document to check.
+//	key 		The dotted key.
+//	value 		The value to compare with.
+//	condition 	The condition on the comparison result.
+//
+// Returns:
+//
+//	Whether any value at the given key satisfies the condition.
+func matchCompare(document interface{}, key string, value interface{}, condition func(int) bool) bool {
+	for _, candidate := range Lookup(document, key) {
+		values := elements(candidate)
+
+		if values == nil {
+			values = []interface{}{candidate}
+		}
+
+		for _, element := range values {
+			order, ok := Compare(element, value)
+			if ok && condition(order) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Resolves the given path segments within a reflected value.
+//
+// Parameters:
+//
+//	value 	The value to resolve the path in.
+//	path 	The remaining path segments.
+//
+// Returns:
+//
+//	All values found at the given path.
+func lookup(value reflect.Value, path []string) []interface{} {
+	value = indirect(value)
+
+	if len(path) == 0 {
+		if !value.IsValid() {
+			return []interface{}{nil}
+		}
+
+		return []interface{}{value.Interface()}
+	}
+
+	if !value.IsValid() {
+		return nil
+	}
+
+	if value.Type() == documentDType {
+		for _, element := range value.Interface().(primitive.D) {
+			if element.Key == path[0] {
+				return lookup(reflect.ValueOf(element.Value), path[1:])
+			}
+		}
+
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+
+		next := value.MapIndex(reflect.ValueOf(path[0]).Convert(value.Type().Key()))
+		if !next.IsValid() {
+			return nil
+		}
+
+		return lookup(next, path[1:])
+	case reflect.Struct:
+		next, ok := structField(value, path[0])
+		if !ok {
+			return nil
+		}
+
+		return lookup(next, path[1:])
+	case reflect.Slice, reflect.Array:
+		if !isSequence(value) {
+			return nil
+		}
+
+		index, err := strconv.Atoi(path[0])
+		if err == nil {
+			if index < 0 || index >= value.Len() {
+				return nil
+			}
+
+			return lookup(value.Index(index), path[1:])
+		}
+
+		results := make([]interface{}, 0)
+		for index := 0; index < value.Len(); index++ {
+			element := indirect(value.Index(index))
+
+			if isDocument(element) {
+				results = append(results, lookup(element, path)...)
+			}
+		}
+
+		return results
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Resolves a struct field by its document key.
+//	The bson tag takes precedence, followed by the json tag and the lowercased field name.
+//	Inlined and embedded structs are searched as well.
+//
+// Parameters:
+//
+//	value 	The struct value.
+//	key 	The document key of the field.
+//
+// Returns:
+//
+//	The field value and whether the field exists.
+func structField(value reflect.Value, key string) (reflect.Value, bool) {
+	structType := value.Type()
+
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline, skip := fieldName(field)
+		if skip {
+			continue
+		}
+
+		if inline {
+			embedded := indirect(value.Field(index))
+
+			if embedded.IsValid() && embedded.Kind() == reflect.Struct {
+				result, ok := structField(embedded, key)
+				if ok {
+					return result, true
+				}
+			}
+
+			continue
+		}
+
+		if name == key {
+			return value.Field(index), true
+		}
+	}
+
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		tag, ok := field.Tag.Lookup("json")
+
+		if !field.IsExported() || !ok {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if name != "" && name != "-" && name == key {
+			return value.Field(index), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// Description:
+//
+//	Determines the document key of a struct field.
+//
+// Parameters:
+//
+//	field The struct field.
+//
+// Returns:
+//
+//	The document key, whether the field is inlined and whether the field is skipped.
+func fieldName(field reflect.StructField) (string, bool, bool) {
+	for _, tagName := range []string{"bson", "json"} {
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+
+		if parts[0] == "-" {
+			return "", false, true
+		}
+
+		for _, option := range parts[1:] {
+			if option == "inline" {
+				return "", true, false
+			}
+		}
+
+		if parts[0] != "" {
+			return parts[0], false, false
+		}
+	}
+
+	if field.Anonymous {
+		return "", true, false
+	}
+
+	return strings.ToLower(field.Name), false, false
+}
+
+// Description:
+//
+//	Dereferences pointers and interfaces.
+//
+// Parameters:
+//
+//	value The value to dereference.
+//
+// Returns:
+//
+//	The dereferenced value. Invalid, if a nil value was encountered.
+func indirect(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+
+		value = value.Elem()
+	}
+
+	return value
+}
+
+// Description:
+//
+//	Checks whether the given value is nil.
+//
+// Parameters:
+//
+//	value The value to check.
+//
+// Returns:
+//
+//	Whether the value is nil.
+func isNil(value interface{}) bool {
+	return !indirect(reflect.ValueOf(value)).IsValid()
+}
+
+// Description:
+//
+//	Checks whether a value is an array (excluding binary data and object ids).
+//
+// Parameters:
+//
+//	value The value to check.
+//
+// Returns:
+//
+//	Whether the value is an array.
+func isSequence(value reflect.Value) bool {
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return false
+	}
+
+	if value.Type() == documentDType {
+		return false
+	}
+
+	return value.Type().Elem().Kind() != reflect.Uint8
+}
+
+// Description:
+//
+//	Checks whether a value is a document (struct, map or ordered document).
+//
+// Parameters:
+//
+//	value The value to check.
+//
+// Returns:
+//
+//	Whether the value is a document.
+func isDocument(value reflect.Value) bool {
+	if !value.IsValid() {
+		return false
+	}
+
+	if value.Type() == documentDType {
+		return true
+	}
+
+	if value.Type() == timeType {
+		return false
+	}
+
+	return value.Kind() == reflect.Struct || isStringMap(value)
+}
+
+// Description:
+//
+//	Checks whether a value is a map with string keys.
+//
+// Parameters:
+//
+//	value The value to check.
+//
+// Returns:
+//
+//	Whether the value is a map with string keys.
+func isStringMap(value reflect.Value) bool {
+	return value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String
+}
+
+// Description:
+//
+//	Returns the elements of an array value.
+//
+// Parameters:
+//
+//	value The value to get the elements of.
+//
+// Returns:
+//
+//	The array elements, or nil if the value is not an array.
+func elements(value interface{}) []interface{} {
+	reflected := indirect(reflect.ValueOf(value))
+
+	if !reflected.IsValid() || !isSequence(reflected) {
+		return nil
+	}
+
+	result := make([]interface{}, reflected.Len())
+	for index := range result {
+		result[index] = reflected.Index(index).Interface()
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Converts a numeric value into a float.
+//
+// Parameters:
+//
+//	value The value to convert.
+//
+// Returns:
+//
+//	The converted value and whether the value is numeric.
+func toNumber(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+
+	return 0, false
+}
+
+// Description:
+//
+//	Converts a date value into a time.
+//
+// Parameters:
+//
+//	value The value to convert.
+//
+// Returns:
+//
+//	The converted value and whether the value is a date.
+func toTime(value reflect.Value) (time.Time, bool) {
+	switch value.Type() {
+	case timeType:
+		return value.Interface().(time.Time), true
+	case dateTimeType:
+		return value.Interface().(primitive.DateTime).Time(), true
+	}
+
+	return time.Time{}, false
+}
+
+// Description:
+//
+//	Converts a boolean into a number, so that false orders before true.
+//
+// Parameters:
+//
+//	value The value to convert.
+//
+// Returns:
+//
+//	The converted value.
+func boolToNumber(value bool) int {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
+// Description:
+//
+//	Compares two ordered values.
+//
+// Parameters:
+//
+//	left 	The left value.
+//	right 	The right value.
+//
+// Returns:
+//
+//	-1 if left is less than right, 1 if left is greater than right, 0 otherwise.
+func compareOrdered[T int | float64](left T, right T) int {
+	if left < right {
+		return -1
+	}
+
+	if left > right {
+		return 1
+	}
+
+	return 0
+}
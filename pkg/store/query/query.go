@@ -16,3 +16,25 @@ type IQuery interface {
 	//	The filter represented as a MongoDB bson document.
 	Compile() bson.M
 }
+
+// Description:
+//
+//	The matcher interface.
+//	Implemented by filters which can be evaluated against Go values in-process.
+type IMatcher interface {
+	IQuery
+
+	// Description:
+	//
+	//	Checks whether the given document matches the filter.
+	//	Uses MongoDB-compatible comparison semantics.
+	//
+	// Parameters:
+	//
+	//	document The document to check. Can be a struct, a map or a BSON document.
+	//
+	// Returns:
+	//
+	//	Whether the document matches the filter.
+	Matches(document interface{}) bool
+}
@@ -0,0 +1,704 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+
+	// The maximum nesting depth of RSQL expressions used if none is configured.
+	DefaultRSQLMaxDepth = 8
+)
+
+// Description:
+//
+//	The RSQL/FIQL parser.
+//	Parses filter expressions such as 'genres=in=(rock,metal);stats.popularity=gt=0.5,followers=ge=1000'
+//	into filters. The ';' (or 'and') operator binds stronger than the ',' (or 'or') operator.
+//
+//	Supported comparison operators:
+//	  - '==' equals, '*' acts as wildcard for unquoted string values
+//	  - '!=' not equals, '*' acts as wildcard for unquoted string values
+//	  - '=lt=' or '<', '=le=' or '<=', '=gt=' or '>', '=ge=' or '>='
+//	  - '=in=' and '=out=' with a list of values, e.g. '=in=(rock,metal)'
+//	  - '=all=' with a list of values, for array fields
+//	  - '=size=' with an integer, for array fields
+//	  - '=prefix=' and '=contains=' with a case-insensitive substring, for string fields
+//	  - '=ex=' with 'true' or 'false'
+type RSQLParser struct {
+
+	// The schema of the filtered documents. Only schema fields can be used as selectors.
+	Schema *Schema
+
+	// The maximum nesting depth of parentheses.
+	// Falls back to DefaultRSQLMaxDepth if zero.
+	MaxDepth int
+}
+
+// Description:
+//
+//	An RSQL syntax or semantic error.
+type RSQLError struct {
+
+	// The byte offset within the expression at which the error occurred.
+	Position int
+
+	// The error message.
+	Message string
+}
+
+// Description:
+//
+//	The internal parser state for a single expression.
+type rsqlState struct {
+
+	// The parser configuration.
+	parser *RSQLParser
+
+	// The expression to parse.
+	input string
+
+	// The current byte offset.
+	position int
+}
+
+// Description:
+//
+//	A single comparison argument.
+type rsqlValue struct {
+
+	// The unescaped value text.
+	text string
+
+	// The byte offset of the value within the expression.
+	position int
+
+	// Whether the value was quoted.
+	quoted bool
+}
+
+// Description:
+//
+//	Formats the error.
+//
+// Returns:
+//
+//	The error message, including the error position.
+func (err *RSQLError) Error() string {
+	return fmt.Sprintf("query: %s at position %d", err.Message, err.Position)
+}
+
+// Description:
+//
+//	Parses an RSQL expression into a filter.
+//
+// Parameters:
+//
+//	input The RSQL expression.
+//
+// Returns:
+//
+//	The parsed filter, or an *RSQLError if the expression is invalid.
+func (parser *RSQLParser) Parse(input string) (IQuery, error) {
+	state := &rsqlState{
+		parser: parser,
+		input:  input,
+	}
+
+	result, err := state.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	state.skipSpace()
+
+	if state.position < len(input) {
+		return nil, state.errorf(state.position, "unexpected character '%c'", input[state.position])
+	}
+
+	return result, nil
+}
+
+// Description:
+//
+//	Parses a disjunction of conjunctions.
+//
+// Parameters:
+//
+//	depth The current nesting depth.
+//
+// Returns:
+//
+//	The parsed filter, or an error.
+func (state *rsqlState) parseOr(depth int) (IQuery, error) {
+	operands := make([]IQuery, 0)
+
+	for {
+		operand, err := state.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+
+		operands = append(operands, operand)
+
+		if !state.consumeOperator(",", "or") {
+			break
+		}
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+
+	return FilterOperatorOr{Or: operands}, nil
+}
+
+// Description:
+//
+//	Parses a conjunction of constraints.
+//
+// Parameters:
+//
+//	depth The current nesting depth.
+//
+// Returns:
+//
+//	The parsed filter, or an error.
+func (state *rsqlState) parseAnd(depth int) (IQuery, error) {
+	operands := make([]IQuery, 0)
+
+	for {
+		operand, err := state.parseConstraint(depth)
+		if err != nil {
+			return nil, err
+		}
+
+		operands = append(operands, operand)
+
+		if !state.consumeOperator(";", "and") {
+			break
+		}
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+
+	return FilterOperatorAnd{And: operands}, nil
+}
+
+// Description:
+//
+//	Parses a parenthesized expression or a single comparison.
+//
+// Parameters:
+//
+//	depth The current nesting depth.
+//
+// Returns:
+//
+//	The parsed filter, or an error.
+func (state *rsqlState) parseConstraint(depth int) (IQuery, error) {
+	state.skipSpace()
+
+	if !state.peek('(') {
+		return state.parseComparison()
+	}
+
+	maxDepth := state.parser.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultRSQLMaxDepth
+	}
+
+	if depth >= maxDepth {
+		return nil, state.errorf(state.position, "maximum nesting depth of %d exceeded", maxDepth)
+	}
+
+	state.position++
+
+	result, err := state.parseOr(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	state.skipSpace()
+
+	if !state.peek(')') {
+		return nil, state.errorf(state.position, "expected ')'")
+	}
+
+	state.position++
+	return result, nil
+}
+
+// Description:
+//
+//	Parses a single comparison, e.g. 'followers=ge=1000'.
+//
+// Returns:
+//
+//	The parsed filter, or an error.
+func (state *rsqlState) parseComparison() (IQuery, error) {
+	selectorPosition := state.position
+
+	for state.position < len(state.input) && isSelectorCharacter(state.input[state.position]) {
+		state.position++
+	}
+
+	selector := state.input[selectorPosition:state.position]
+	if selector == "" {
+		return nil, state.errorf(selectorPosition, "expected selector")
+	}
+
+	field, ok := state.parser.Schema.Field(selector)
+	if !ok {
+		return nil, state.errorf(selectorPosition, "unknown field '%s'", selector)
+	}
+
+	operatorPosition := state.position
+
+	operator, err := state.parseOperator()
+	if err != nil {
+		return nil, err
+	}
+
+	arguments, list, err := state.parseArguments()
+	if err != nil {
+		return nil, err
+	}
+
+	switch operator {
+	case "=in=", "=out=", "=all=":
+		if operator == "=all=" && !field.Array {
+			return nil, state.errorf(operatorPosition, "operator '%s' requires an array field", operator)
+		}
+
+		values := make([]interface{}, 0, len(arguments))
+
+		for _, argument := range arguments {
+			value, err := state.coerce(field, selector, argument)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		switch operator {
+		case "=in=":
+			return FilterOperatorIn{Key: field.Key, Values: values}, nil
+		case "=out=":
+			return FilterOperatorNin{Key: field.Key, Values: values}, nil
+		}
+
+		return FilterOperatorAll{Key: field.Key, Values: values}, nil
+	}
+
+	if list {
+		return nil, state.errorf(arguments[0].position-1, "operator '%s' requires a single value", operator)
+	}
+
+	argument := arguments[0]
+
+	switch operator {
+	case "=prefix=", "=contains=":
+		if field.Type.Kind() != reflect.String {
+			return nil, state.errorf(operatorPosition, "operator '%s' requires a string field", operator)
+		}
+
+		pattern := regexp.QuoteMeta(argument.text)
+		if operator == "=prefix=" {
+			pattern = "^" + pattern
+		}
+
+		err := ValidateRegex(pattern, "i")
+		if err != nil {
+			return nil, state.errorf(argument.position, "value of operator '%s' is too long", operator)
+		}
+
+		return FilterOperatorRegex{Key: field.Key, Pattern: pattern, Options: "i"}, nil
+	case "=ex=":
+		exists, err := strconv.ParseBool(argument.text)
+		if err != nil {
+			return nil, state.errorf(argument.position, "invalid value '%s', expected 'true' or 'false'", argument.text)
+		}
+
+		return FilterOperatorExists{Key: field.Key, Exists: exists}, nil
+	case "=size=":
+		if !field.Array {
+			return nil, state.errorf(operatorPosition, "operator '%s' requires an array field", operator)
+		}
+
+		size, err := strconv.Atoi(argument.text)
+		if err != nil || size < 0 {
+			return nil, state.errorf(argument.position, "invalid size '%s'", argument.text)
+		}
+
+		return FilterOperatorSize{Key: field.Key, Size: size}, nil
+	case "==", "!=":
+		if field.Type.Kind() == reflect.String && !argument.quoted && strings.Contains(argument.text, "*") {
+			regex := FilterOperatorRegex{Key: field.Key, Pattern: wildcardPattern(argument.text)}
+
+			err := ValidateRegex(regex.Pattern, regex.Options)
+			if err != nil {
+				return nil, state.errorf(argument.position, "value of operator '%s' is too long", operator)
+			}
+
+			if operator == "!=" {
+				return FilterOperatorNot{Not: regex}, nil
+			}
+
+			return regex, nil
+		}
+	}
+
+	value, err := state.coerce(field, selector, argument)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operator {
+	case "==":
+		return FilterOperatorEq{Key: field.Key, Value: value}, nil
+	case "!=":
+		return FilterOperatorNeq{Key: field.Key, Value: value}, nil
+	case "=lt=", "<":
+		return FilterOperatorLt{Key: field.Key, Value: value}, nil
+	case "=le=", "<=":
+		return FilterOperatorLte{Key: field.Key, Value: value}, nil
+	case "=gt=", ">":
+		return FilterOperatorGt{Key: field.Key, Value: value}, nil
+	case "=ge=", ">=":
+		return FilterOperatorGte{Key: field.Key, Value: value}, nil
+	}
+
+	return nil, state.errorf(operatorPosition, "unknown operator '%s'", operator)
+}
+
+// Description:
+//
+//	Parses a comparison operator.
+//
+// Returns:
+//
+//	The parsed operator, or an error.
+func (state *rsqlState) parseOperator() (string, error) {
+	start := state.position
+	rest := state.input[start:]
+
+	for _, operator := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(rest, operator) {
+			state.position += len(operator)
+			return operator, nil
+		}
+	}
+
+	if strings.HasPrefix(rest, "=") {
+		end := 1
+
+		for end < len(rest) && unicode.IsLetter(rune(rest[end])) {
+			end++
+		}
+
+		if end > 1 && end < len(rest) && rest[end] == '=' {
+			operator := rest[:end+1]
+
+			switch operator {
+			case "=lt=", "=le=", "=gt=", "=ge=", "=in=", "=out=", "=all=", "=size=", "=prefix=", "=contains=", "=ex=":
+				state.position += len(operator)
+				return operator, nil
+			}
+
+			return "", state.errorf(start, "unknown operator '%s'", operator)
+		}
+	}
+
+	return "", state.errorf(start, "expected comparison operator")
+}
+
+// Description:
+//
+//	Parses the comparison arguments. Either a single value or a parenthesized list of values.
+//
+// Returns:
+//
+//	The parsed arguments, whether they were given as a list, or an error.
+func (state *rsqlState) parseArguments() ([]rsqlValue, bool, error) {
+	if !state.peek('(') {
+		value, err := state.parseValue()
+		if err != nil {
+			return nil, false, err
+		}
+
+		return []rsqlValue{value}, false, nil
+	}
+
+	state.position++
+	values := make([]rsqlValue, 0)
+
+	for {
+		state.skipSpace()
+
+		value, err := state.parseValue()
+		if err != nil {
+			return nil, false, err
+		}
+
+		values = append(values, value)
+		state.skipSpace()
+
+		if state.peek(',') {
+			state.position++
+			continue
+		}
+
+		if state.peek(')') {
+			state.position++
+			return values, true, nil
+		}
+
+		return nil, false, state.errorf(state.position, "expected ',' or ')'")
+	}
+}
+
+// Description:
+//
+//	Parses a single quoted or unquoted value.
+//
+// Returns:
+//
+//	The parsed value, or an error.
+func (state *rsqlState) parseValue() (rsqlValue, error) {
+	start := state.position
+
+	if state.peek('\'') || state.peek('"') {
+		quote := state.input[start]
+
+		var builder strings.Builder
+		state.position++
+
+		for state.position < len(state.input) {
+			character := state.input[state.position]
+
+			if character == '\\' && state.position+1 < len(state.input) {
+				builder.WriteByte(state.input[state.position+1])
+				state.position += 2
+
+				continue
+			}
+
+			state.position++
+
+			if character == quote {
+				return rsqlValue{text: builder.String(), position: start, quoted: true}, nil
+			}
+
+			builder.WriteByte(character)
+		}
+
+		return rsqlValue{}, state.errorf(start, "unterminated string")
+	}
+
+	for state.position < len(state.input) && !isReservedCharacter(state.input[state.position]) {
+		state.position++
+	}
+
+	if state.position == start {
+		return rsqlValue{}, state.errorf(start, "expected value")
+	}
+
+	return rsqlValue{text: state.input[start:state.position], position: start}, nil
+}
+
+// Description:
+//
+//	Converts an argument into a value of the schema field type.
+//
+// Parameters:
+//
+//	field 		The schema field.
+//	selector 	The field selector, used for error messages.
+//	argument 	The argument to convert.
+//
+// Returns:
+//
+//	The converted value, or an error if the argument is not a valid value for the field.
+func (state *rsqlState) coerce(field SchemaField, selector string, argument rsqlValue) (interface{}, error) {
+	var value interface{}
+	var err error
+
+	switch {
+	case field.Type == timeType:
+		value, err = time.Parse(time.RFC3339, argument.text)
+	case field.Type.Kind() == reflect.String:
+		value = argument.text
+	case field.Type.Kind() == reflect.Bool:
+		value, err = strconv.ParseBool(argument.text)
+	case field.Type.Kind() >= reflect.Int && field.Type.Kind() <= reflect.Int64:
+		value, err = strconv.ParseInt(argument.text, 10, field.Type.Bits())
+	case field.Type.Kind() >= reflect.Uint && field.Type.Kind() <= reflect.Uint64:
+		var number uint64
+
+		number, err = strconv.ParseUint(argument.text, 10, field.Type.Bits())
+		if err == nil && number > uint64(1<<63-1) {
+			err = fmt.Errorf("value out of range")
+		}
+
+		value = int64(number)
+	case field.Type.Kind() == reflect.Float32 || field.Type.Kind() == reflect.Float64:
+		value, err = strconv.ParseFloat(argument.text, 64)
+	default:
+		return nil, state.errorf(argument.position, "field '%s' cannot be filtered", selector)
+	}
+
+	if err != nil {
+		return nil, state.errorf(argument.position, "invalid value '%s' for field '%s'", argument.text, selector)
+	}
+
+	return value, nil
+}
+
+// Description:
+//
+//	Consumes a logical operator, given either as symbol or as keyword.
+//	Keywords must be surrounded by whitespace.
+//
+// Parameters:
+//
+//	symbol 	The operator symbol, e.g. ';'.
+//	keyword The operator keyword, e.g. 'and'.
+//
+// Returns:
+//
+//	Whether the operator was consumed.
+func (state *rsqlState) consumeOperator(symbol string, keyword string) bool {
+	start := state.position
+	spaced := state.skipSpace() > 0
+
+	if strings.HasPrefix(state.input[state.position:], symbol) {
+		state.position += len(symbol)
+		return true
+	}
+
+	end := state.position + len(keyword)
+
+	if spaced && strings.HasPrefix(state.input[state.position:], keyword) && end < len(state.input) && unicode.IsSpace(rune(state.input[end])) {
+		state.position = end
+		return true
+	}
+
+	state.position = start
+	return false
+}
+
+// Description:
+//
+//	Skips whitespace.
+//
+// Returns:
+//
+//	The number of skipped characters.
+func (state *rsqlState) skipSpace() int {
+	start := state.position
+
+	for state.position < len(state.input) && unicode.IsSpace(rune(state.input[state.position])) {
+		state.position++
+	}
+
+	return state.position - start
+}
+
+// Description:
+//
+//	Checks whether the next character equals the given character.
+//
+// Parameters:
+//
+//	character The character to check.
+//
+// Returns:
+//
+//	Whether the next character equals the given character.
+func (state *rsqlState) peek(character byte) bool {
+	return state.position < len(state.input) && state.input[state.position] == character
+}
+
+// Description:
+//
+//	Creates an RSQL error.
+//
+// Parameters:
+//
+//	position 	The error position.
+//	format 		The error message format.
+//	arguments 	The error message arguments.
+//
+// Returns:
+//
+//	The created error.
+func (state *rsqlState) errorf(position int, format string, arguments ...interface{}) *RSQLError {
+	return &RSQLError{
+		Position: position,
+		Message:  fmt.Sprintf(format, arguments...),
+	}
+}
+
+// Description:
+//
+//	Checks whether a character can be part of a selector.
+//
+// Parameters:
+//
+//	character The character to check.
+//
+// Returns:
+//
+//	Whether the character can be part of a selector.
+func isSelectorCharacter(character byte) bool {
+	return character == '_' || character == '.' || character == '-' ||
+		(character >= 'a' && character <= 'z') ||
+		(character >= 'A' && character <= 'Z') ||
+		(character >= '0' && character <= '9')
+}
+
+// Description:
+//
+//	Checks whether a character is reserved and cannot be part of an unquoted value.
+//
+// Parameters:
+//
+//	character The character to check.
+//
+// Returns:
+//
+//	Whether the character is reserved.
+func isReservedCharacter(character byte) bool {
+	return strings.IndexByte("\"'();,=!~<>", character) >= 0 || unicode.IsSpace(rune(character))
+}
+
+// Description:
+//
+//	Converts a wildcard value into an anchored regular expression.
+//
+// Parameters:
+//
+//	value The value, using '*' as wildcard.
+//
+// Returns:
+//
+//	The regular expression pattern.
+func wildcardPattern(value string) string {
+	parts := strings.Split(value, "*")
+
+	for index, part := range parts {
+		parts[index] = regexp.QuoteMeta(part)
+	}
+
+	return "^" + strings.Join(parts, ".*") + "$"
+}
@@ -0,0 +1,265 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Description:
+//
+//	The data model the RSQL tests are parsed against.
+type rsqlTestArtist struct {
+
+	// The artist id.
+	ID string `json:"id" bson:"_id"`
+
+	// The artist name.
+	Name string `json:"name" bson:"name"`
+
+	// The artist genres.
+	Genres []string `json:"genres" bson:"genres"`
+
+	// The number of followers.
+	Followers uint32 `json:"followers" bson:"followers"`
+
+	// Whether the artist is verified.
+	Verified bool `json:"verified" bson:"verified"`
+
+	// The creation date.
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+
+	// The artist statistics.
+	Stats struct {
+
+		// The artist popularity.
+		Popularity float64 `json:"popularity" bson:"popularity"`
+	} `json:"stats" bson:"stats"`
+
+	// Arbitrary labels, which cannot be filtered.
+	Labels map[string]string `json:"labels" bson:"labels"`
+}
+
+// Description:
+//
+//	The parser the RSQL tests use.
+var rsqlTestParser = &RSQLParser{
+	Schema: NewSchema(rsqlTestArtist{}),
+}
+
+// Description:
+//
+//	Tests the parsing of valid RSQL expressions.
+//
+// Parameters:
+//
+//	t The test context.
+func TestRSQLParse(t *testing.T) {
+	created := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   IQuery
+	}{
+		{"equals", "name==Justice", FilterOperatorEq{Key: "name", Value: "Justice"}},
+		{"not equals", "name!=Justice", FilterOperatorNeq{Key: "name", Value: "Justice"}},
+		{"selector mapped to document key", "id==42", FilterOperatorEq{Key: "_id", Value: "42"}},
+		{"nested field", "stats.popularity=gt=0.5", FilterOperatorGt{Key: "stats.popularity", Value: 0.5}},
+		{"symbolic operators", "followers<10;followers<=20;followers>5;followers>=6", FilterOperatorAnd{And: []IQuery{
+			FilterOperatorLt{Key: "followers", Value: int64(10)},
+			FilterOperatorLte{Key: "followers", Value: int64(20)},
+			FilterOperatorGt{Key: "followers", Value: int64(5)},
+			FilterOperatorGte{Key: "followers", Value: int64(6)},
+		}}},
+		{"named operators", "followers=lt=10;followers=le=20;followers=gt=5;followers=ge=6", FilterOperatorAnd{And: []IQuery{
+			FilterOperatorLt{Key: "followers", Value: int64(10)},
+			FilterOperatorLte{Key: "followers", Value: int64(20)},
+			FilterOperatorGt{Key: "followers", Value: int64(5)},
+			FilterOperatorGte{Key: "followers", Value: int64(6)},
+		}}},
+
+		{"and binds stronger than or", "name==a,name==b;genres==rock", FilterOperatorOr{Or: []IQuery{
+			FilterOperatorEq{Key: "name", Value: "a"},
+			FilterOperatorAnd{And: []IQuery{
+				FilterOperatorEq{Key: "name", Value: "b"},
+				FilterOperatorEq{Key: "genres", Value: "rock"},
+			}},
+		}}},
+		{"and before or", "name==a;name==b,genres==rock", FilterOperatorOr{Or: []IQuery{
+			FilterOperatorAnd{And: []IQuery{
+				FilterOperatorEq{Key: "name", Value: "a"},
+				FilterOperatorEq{Key: "name", Value: "b"},
+			}},
+			FilterOperatorEq{Key: "genres", Value: "rock"},
+		}}},
+		{"parentheses override precedence", "(name==a,name==b);genres==rock", FilterOperatorAnd{And: []IQuery{
+			FilterOperatorOr{Or: []IQuery{
+				FilterOperatorEq{Key: "name", Value: "a"},
+				FilterOperatorEq{Key: "name", Value: "b"},
+			}},
+			FilterOperatorEq{Key: "genres", Value: "rock"},
+		}}},
+		{"keyword operators", "name==a or name==b and genres==rock", FilterOperatorOr{Or: []IQuery{
+			FilterOperatorEq{Key: "name", Value: "a"},
+			FilterOperatorAnd{And: []IQuery{
+				FilterOperatorEq{Key: "name", Value: "b"},
+				FilterOperatorEq{Key: "genres", Value: "rock"},
+			}},
+		}}},
+		{"whitespace around operators", " name==a ; ( genres==rock ) ", FilterOperatorAnd{And: []IQuery{
+			FilterOperatorEq{Key: "name", Value: "a"},
+			FilterOperatorEq{Key: "genres", Value: "rock"},
+		}}},
+
+		{"double quoted value", `name=="Daft Punk"`, FilterOperatorEq{Key: "name", Value: "Daft Punk"}},
+		{"single quoted value", `name=='Daft Punk'`, FilterOperatorEq{Key: "name", Value: "Daft Punk"}},
+		{"quoted reserved characters", `name=='a;b,(c)=d'`, FilterOperatorEq{Key: "name", Value: "a;b,(c)=d"}},
+		{"escaped quote", `name=="say \"hi\""`, FilterOperatorEq{Key: "name", Value: `say "hi"`}},
+		{"escaped single quote", `name=='it\'s'`, FilterOperatorEq{Key: "name", Value: "it's"}},
+		{"escaped backslash", `name=='a\\b'`, FilterOperatorEq{Key: "name", Value: `a\b`}},
+		{"quoted values in list", `genres=in=("rock, pop",'r&b')`, FilterOperatorIn{Key: "genres", Values: []interface{}{"rock, pop", "r&b"}}},
+
+		{"wildcard", "name==daft*", FilterOperatorRegex{Key: "name", Pattern: "^daft.*$"}},
+		{"negated wildcard", "name!=*punk", FilterOperatorNot{Not: FilterOperatorRegex{Key: "name", Pattern: "^.*punk$"}}},
+		{"wildcard escapes regex characters", "name==a.b*", FilterOperatorRegex{Key: "name", Pattern: `^a\.b.*$`}},
+		{"quoted wildcard is literal", `name=="daft*"`, FilterOperatorEq{Key: "name", Value: "daft*"}},
+		{"prefix", "name=prefix=daft", FilterOperatorRegex{Key: "name", Pattern: "^daft", Options: "i"}},
+		{"contains", "name=contains=a.b", FilterOperatorRegex{Key: "name", Pattern: `a\.b`, Options: "i"}},
+		{"contains escapes regex characters", `name=contains='(a+)+'`, FilterOperatorRegex{Key: "name", Pattern: `\(a\+\)\+`, Options: "i"}},
+
+		{"in", "genres=in=(rock,metal)", FilterOperatorIn{Key: "genres", Values: []interface{}{"rock", "metal"}}},
+		{"out", "followers=out=(1, 2)", FilterOperatorNin{Key: "followers", Values: []interface{}{int64(1), int64(2)}}},
+		{"all", "genres=all=(rock)", FilterOperatorAll{Key: "genres", Values: []interface{}{"rock"}}},
+		{"size", "genres=size=2", FilterOperatorSize{Key: "genres", Size: 2}},
+		{"exists", "name=ex=true", FilterOperatorExists{Key: "name", Exists: true}},
+		{"not exists", "name=ex=false", FilterOperatorExists{Key: "name", Exists: false}},
+
+		{"boolean", "verified==true", FilterOperatorEq{Key: "verified", Value: true}},
+		{"time", "createdAt=lt=2023-01-01T00:00:00Z", FilterOperatorLt{Key: "createdAt", Value: created}},
+		{"unsigned integer", "followers==4294967295", FilterOperatorEq{Key: "followers", Value: int64(4294967295)}},
+		{"float from integer", "stats.popularity==1", FilterOperatorEq{Key: "stats.popularity", Value: 1.0}},
+		{"nesting up to the depth limit", strings.Repeat("(", DefaultRSQLMaxDepth) + "name==a" + strings.Repeat(")", DefaultRSQLMaxDepth), FilterOperatorEq{Key: "name", Value: "a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := rsqlTestParser.Parse(test.expression)
+			if err != nil {
+				t.Fatalf("failed to parse '%s': %s", test.expression, err)
+			}
+
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, actual)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests that invalid RSQL expressions are rejected at the correct position.
+//
+// Parameters:
+//
+//	t The test context.
+func TestRSQLParseErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		position   int
+		message    string
+	}{
+		{"empty expression", "", 0, "expected selector"},
+		{"missing selector", "==a", 0, "expected selector"},
+		{"unknown field", "label==x", 0, "unknown field 'label'"},
+		{"embedded document", "stats==x", 0, "unknown field 'stats'"},
+		{"unknown field after and", "name==a;label==x", 8, "unknown field 'label'"},
+		{"non-filterable field", "labels==x", 8, "field 'labels' cannot be filtered"},
+		{"missing operator", "name", 4, "expected comparison operator"},
+		{"invalid operator", "name=~x", 4, "expected comparison operator"},
+		{"unknown operator", "name=like=x", 4, "unknown operator '=like='"},
+		{"removed regex operator", "name=re=x", 4, "unknown operator '=re='"},
+		{"missing value", "name==", 6, "expected value"},
+		{"trailing and", "name==a;", 8, "expected selector"},
+		{"unterminated string", "name=='abc", 6, "unterminated string"},
+		{"unclosed parenthesis", "(name==a", 8, "expected ')'"},
+		{"unopened parenthesis", "name==a)", 7, "unexpected character ')'"},
+		{"unterminated list", "genres=in=(a,b", 14, "expected ',' or ')'"},
+		{"empty list", "genres=in=()", 11, "expected value"},
+		{"list for single value operator", "name=gt=(a,b)", 8, "requires a single value"},
+
+		{"invalid integer", "followers=gt=abc", 13, "invalid value 'abc' for field 'followers'"},
+		{"negative unsigned integer", "followers=gt=-1", 13, "invalid value '-1' for field 'followers'"},
+		{"integer out of range", "followers==4294967296", 11, "invalid value '4294967296' for field 'followers'"},
+		{"invalid float", "stats.popularity=gt=high", 20, "invalid value 'high' for field 'stats.popularity'"},
+		{"invalid boolean", "verified==maybe", 10, "invalid value 'maybe' for field 'verified'"},
+		{"invalid time", "createdAt=lt=yesterday", 13, "invalid value 'yesterday' for field 'createdAt'"},
+		{"invalid list element", "followers=in=(1,two)", 16, "invalid value 'two' for field 'followers'"},
+		{"invalid exists value", "name=ex=maybe", 8, "expected 'true' or 'false'"},
+		{"invalid size", "genres=size=-1", 12, "invalid size '-1'"},
+
+		{"size on scalar field", "name=size=2", 4, "requires an array field"},
+		{"all on scalar field", "name=all=(a)", 4, "requires an array field"},
+		{"prefix on non-string field", "followers=prefix=1", 9, "requires a string field"},
+		{"contains on non-string field", "verified=contains=t", 8, "requires a string field"},
+		{"prefix too long", "name=prefix=" + strings.Repeat("a", MaxRegexLength), 12, "is too long"},
+		{"wildcard too long", "name==*" + strings.Repeat("a", MaxRegexLength), 6, "is too long"},
+
+		{"depth limit exceeded", strings.Repeat("(", DefaultRSQLMaxDepth+1) + "name==a" + strings.Repeat(")", DefaultRSQLMaxDepth+1), DefaultRSQLMaxDepth, "maximum nesting depth of 8 exceeded"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := rsqlTestParser.Parse(test.expression)
+			if err == nil {
+				t.Fatalf("expected '%s' to be rejected", test.expression)
+			}
+
+			rsqlErr := &RSQLError{}
+			if !errors.As(err, &rsqlErr) {
+				t.Fatalf("expected an RSQL error, got %T: %s", err, err)
+			}
+
+			if rsqlErr.Position != test.position {
+				t.Errorf("expected position %d, got %d: %s", test.position, rsqlErr.Position, err)
+			}
+
+			if !strings.Contains(rsqlErr.Message, test.message) {
+				t.Errorf("expected message containing '%s', got '%s'", test.message, rsqlErr.Message)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Tests that a configured maximum nesting depth replaces the default.
+//
+// Parameters:
+//
+//	t The test context.
+func TestRSQLParseMaxDepth(t *testing.T) {
+	parser := &RSQLParser{
+		Schema:   rsqlTestParser.Schema,
+		MaxDepth: 2,
+	}
+
+	_, err := parser.Parse("((name==a),name==b)")
+	if err != nil {
+		t.Fatalf("expected nesting within the limit to be accepted, got '%s'", err)
+	}
+
+	_, err = parser.Parse("((( name==a )))")
+
+	rsqlErr := &RSQLError{}
+	if !errors.As(err, &rsqlErr) {
+		t.Fatalf("expected an RSQL error, got %v", err)
+	}
+
+	if rsqlErr.Position != 2 || !strings.Contains(rsqlErr.Message, "maximum nesting depth of 2 exceeded") {
+		t.Errorf("expected depth error at position 2, got '%s'", err)
+	}
+}
@@ -0,0 +1,254 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Description:
+//
+//	A document schema.
+//	Describes which fields of a document can be referred to from outside, e.g. in filter expressions.
+type Schema struct {
+
+	// The schema fields, indexed by their selector.
+	Fields map[string]SchemaField
+}
+
+// Description:
+//
+//	A single field of a document schema.
+type SchemaField struct {
+
+	// The document key of the field.
+	Key string
+
+	// The type of the field value. For arrays, the type of the array elements.
+	Type reflect.Type
+
+	// Whether the field is an array.
+	Array bool
+}
+
+// Description:
+//
+//	Creates a document schema from a data model.
+//	Every scalar and array field becomes a schema field. Embedded documents are flattened,
+//	using dotted paths. Selectors are derived from json tags, document keys from bson tags.
+//
+// Parameters:
+//
+//	model The data model, e.g. an empty struct value.
+//
+// Returns:
+//
+//	The created schema.
+func NewSchema(model interface{}) *Schema {
+	schema := &Schema{
+		Fields: make(map[string]SchemaField),
+	}
+
+	schema.addFields(reflect.TypeOf(model), "", "")
+	return schema
+}
+
+// Description:
+//
+//	Looks up a schema field by its selector.
+//
+// Parameters:
+//
+//	selector The field selector.
+//
+// Returns:
+//
+//	The schema field and whether it exists.
+func (schema *Schema) Field(selector string) (SchemaField, bool) {
+	field, ok := schema.Fields[selector]
+	return field, ok
+}
+
+// Description:
+//
+//	Adds all fields of a struct type to the schema.
+//
+// Parameters:
+//
+//	structType 		The struct type.
+//	selectorPrefix 	The selector prefix of the struct fields.
+//	keyPrefix 		The document key prefix of the struct fields.
+func (schema *Schema) addFields(structType reflect.Type, selectorPrefix string, keyPrefix string) {
+	for structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+
+	if structType.Kind() != reflect.Struct {
+		return
+	}
+
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		key, inline, skip := fieldName(field)
+		if skip {
+			continue
+		}
+
+		selector := key
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			name := strings.Split(tag, ",")[0]
+
+			if name == "-" {
+				continue
+			}
+
+			if name != "" {
+				selector = name
+			}
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if inline {
+			schema.addFields(fieldType, selectorPrefix, keyPrefix)
+			continue
+		}
+
+		selector = selectorPrefix + selector
+		key = keyPrefix + key
+
+		switch {
+		case fieldType == timeType:
+			schema.Fields[selector] = SchemaField{Key: key, Type: fieldType}
+		case fieldType.Kind() == reflect.Struct:
+			schema.addFields(fieldType, selector+".", key+".")
+		case (fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array) && fieldType.Elem().Kind() != reflect.Uint8:
+			schema.Fields[selector] = SchemaField{Key: key, Type: fieldType.Elem(), Array: true}
+		default:
+			schema.Fields[selector] = SchemaField{Key: key, Type: fieldType}
+		}
+	}
+}
+
+// Description:
+//
+//	Checks whether a document key refers to a schema field or to an embedded document of schema fields.
+//
+// Parameters:
+//
+//	key The dotted document key.
+//
+// Returns:
+//
+//	Whether the document key is known.
+func (schema *Schema) HasKey(key string) bool {
+	for _, field := range schema.Fields {
+		if field.Key == key || strings.HasPrefix(field.Key, key+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Checks whether all document keys referred to by a filter are known to the schema.
+//
+// Parameters:
+//
+//	filter The filter to check. A nil filter is always valid.
+//
+// Returns:
+//
+//	An error naming the first unknown document key, or an error if the filter contains an unsupported operator.
+func (schema *Schema) Validate(filter IQuery) error {
+	return schema.validate(filter, "")
+}
+
+// Description:
+//
+//	Checks whether all document keys referred to by a filter are known to the schema.
+//
+// Parameters:
+//
+//	filter 		The filter to check.
+//	keyPrefix 	The document key prefix of the filter keys, e.g. within an element match.
+//
+// Returns:
+//
+//	An error naming the first unknown document key, or an error if the filter contains an unsupported operator.
+func (schema *Schema) validate(filter IQuery, keyPrefix string) error {
+	var keys []string
+	var children []IQuery
+
+	switch filter := filter.(type) {
+	case nil:
+		return nil
+	case FilterOperatorAnd:
+		children = filter.And
+	case FilterOperatorOr:
+		children = filter.Or
+	case FilterOperatorNor:
+		children = filter.Nor
+	case FilterOperatorNot:
+		children = []IQuery{filter.Not}
+	case FilterOperatorEq:
+		keys = []string{filter.Key}
+	case FilterOperatorNeq:
+		keys = []string{filter.Key}
+	case FilterOperatorLt:
+		keys = []string{filter.Key}
+	case FilterOperatorLte:
+		keys = []string{filter.Key}
+	case FilterOperatorGt:
+		keys = []string{filter.Key}
+	case FilterOperatorGte:
+		keys = []string{filter.Key}
+	case FilterOperatorIn:
+		keys = []string{filter.Key}
+	case FilterOperatorNin:
+		keys = []string{filter.Key}
+	case FilterOperatorAll:
+		keys = []string{filter.Key}
+	case FilterOperatorExists:
+		keys = []string{filter.Key}
+	case FilterOperatorRegex:
+		keys = []string{filter.Key}
+	case FilterOperatorSize:
+		keys = []string{filter.Key}
+	case FilterOperatorElemMatch:
+		if !schema.HasKey(keyPrefix + filter.Key) {
+			return fmt.Errorf("query: unknown key '%s'", keyPrefix+filter.Key)
+		}
+
+		return schema.validate(filter.Match, keyPrefix+filter.Key+".")
+	default:
+		return fmt.Errorf("query: unsupported filter operator: %T", filter)
+	}
+
+	for _, key := range keys {
+		key = strings.TrimSuffix(keyPrefix+key, ".")
+
+		if !schema.HasKey(key) {
+			return fmt.Errorf("query: unknown key '%s'", key)
+		}
+	}
+
+	for _, child := range children {
+		err := schema.validate(child, keyPrefix)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Description:
+//
+//	Compiles the sort specification of the filter into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing the sort specification.
+//	Nil, if the filter has no sort specification.
+func (filter *Filter) CompileSort() bson.D {
+	if len(filter.Sort) == 0 {
+		return nil
+	}
+
+	result := bson.D{}
+
+	for _, sortKey := range filter.Sort {
+		order := sortKey.Order
+
+		if order != SortDescending {
+			order = SortAscending
+		}
+
+		result = append(result, bson.E{Key: sortKey.Key, Value: int(order)})
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Compiles the projection of the filter into a MongoDB BSON document.
+//	Keys within another projected key are omitted, e.g. 'stats.popularity' besides 'stats',
+//	since MongoDB rejects projections with colliding paths.
+//
+// Returns:
+//
+//	A MongoDB bson document representing the projection.
+//	Nil, if the filter has no projection.
+func (filter *Filter) CompileProjection() bson.M {
+	if len(filter.Projection) == 0 {
+		return nil
+	}
+
+	result := bson.M{}
+
+	for _, key := range filter.Projection {
+		if !projectsParent(filter.Projection, key) {
+			result[key] = 1
+		}
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Checks whether a projection contains a parent path of the given key.
+//
+// Parameters:
+//
+//	projection 	The projected keys.
+//	key 		The key to check.
+//
+// Returns:
+//
+//	Whether a parent path of the key is projected.
+func projectsParent(projection []string, key string) bool {
+	for _, other := range projection {
+		if strings.HasPrefix(key, other+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Compares two documents according to a sort specification.
+//	Values of different types are ordered as MongoDB orders BSON types.
+//	For array fields, the first element is used.
+//
+// Parameters:
+//
+//	sort 	The sort specification.
+//	left 	The left document.
+//	right 	The right document.
+//
+// Returns:
+//
+//	-1 if left sorts before right, 1 if left sorts after right, 0 otherwise.
+func CompareBy(sort []SortKey, left interface{}, right interface{}) int {
+	for _, sortKey := range sort {
+		order := compareSortValues(sortValue(left, sortKey.Key), sortValue(right, sortKey.Key))
+
+		if sortKey.Order == SortDescending {
+			order = -order
+		}
+
+		if order != 0 {
+			return order
+		}
+	}
+
+	return 0
+}
+
+// Description:
+//
+//	Resolves the value of a document used for sorting by the given key.
+//
+// Parameters:
+//
+//	document 	The document.
+//	key 		The document key to sort by.
+//
+// Returns:
+//
+//	The sort value. Nil, if the key does not exist.
+func sortValue(document interface{}, key string) interface{} {
+	values := Lookup(document, key)
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	if array := elements(values[0]); array != nil {
+		if len(array) == 0 {
+			return nil
+		}
+
+		return array[0]
+	}
+
+	return values[0]
+}
+
+// Description:
+//
+//	Compares two sort values.
+//
+// Parameters:
+//
+//	left 	The left value.
+//	right 	The right value.
+//
+// Returns:
+//
+//	-1 if left sorts before right, 1 if left sorts after right, 0 otherwise.
+func compareSortValues(left interface{}, right interface{}) int {
+	order, ok := Compare(left, right)
+	if ok {
+		return order
+	}
+
+	leftRank := typeRank(left)
+	rightRank := typeRank(right)
+
+	return compareOrdered(leftRank, rightRank)
+}
+
+// Description:
+//
+//	Determines the rank of a value in the MongoDB BSON type order.
+//
+// Parameters:
+//
+//	value The value.
+//
+// Returns:
+//
+//	The rank of the value type.
+func typeRank(value interface{}) int {
+	reflected := indirect(reflect.ValueOf(value))
+
+	if !reflected.IsValid() {
+		return 0
+	}
+
+	if _, ok := toTime(reflected); ok {
+		return 8
+	}
+
+	if reflected.Type() == objectIDType {
+		return 6
+	}
+
+	if _, ok := toNumber(reflected); ok {
+		return 1
+	}
+
+	switch {
+	case reflected.Kind() == reflect.String:
+		return 2
+	case isDocument(reflected):
+		return 3
+	case isSequence(reflected):
+		return 4
+	case reflected.Kind() == reflect.Bool:
+		return 7
+	}
+
+	return 5
+}
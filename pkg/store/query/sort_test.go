@@ -0,0 +1,41 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Description:
+//
+//	Tests the compilation of projections, which must not contain colliding paths.
+//
+// Parameters:
+//
+//	t The test context.
+func TestCompileProjection(t *testing.T) {
+	tests := []struct {
+		name       string
+		projection []string
+		expected   bson.M
+	}{
+		{"no projection", nil, nil},
+		{"distinct keys", []string{"name", "genres"}, bson.M{"name": 1, "genres": 1}},
+		{"duplicate keys", []string{"name", "_id", "name"}, bson.M{"name": 1, "_id": 1}},
+		{"child after parent", []string{"stats", "stats.popularity"}, bson.M{"stats": 1}},
+		{"child before parent", []string{"stats.popularity", "name", "stats"}, bson.M{"stats": 1, "name": 1}},
+		{"common prefix", []string{"stats", "statsHistory.popularity"}, bson.M{"stats": 1, "statsHistory.popularity": 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter := Filter{Projection: test.projection}
+			actual := filter.CompileProjection()
+
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
@@ -1,6 +1,12 @@
 package query
 
-import "go.mongodb.org/mongo-driver/bson"
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
 
 // Description:
 //
@@ -23,6 +29,175 @@ type UpdateOperatorSet struct {
 	Set map[string]interface{}
 }
 
+// Description:
+//
+//	Increments numeric fields by the given amounts.
+//	Missing fields are created with the given amount.
+type UpdateOperatorInc struct {
+
+	// The query interface implementation.
+	IQuery
+
+	// The key-amount mappings to increment by.
+	Inc map[string]interface{}
+}
+
+// Description:
+//
+//	Removes the given fields.
+type UpdateOperatorUnset struct {
+
+	// The query interface implementation.
+	IQuery
+
+	// The keys to remove.
+	Unset []string
+}
+
+// Description:
+//
+//	Appends values to array fields.
+//	Missing fields are created as arrays containing the given value.
+type UpdateOperatorPush struct {
+
+	// The query interface implementation.
+	IQuery
+
+	// The key-value mappings to append.
+	Push map[string]interface{}
+}
+
+// Description:
+//
+//	Removes all array elements equal to the given values.
+type UpdateOperatorPull struct {
+
+	// The query interface implementation.
+	IQuery
+
+	// The key-value mappings to remove.
+	Pull map[string]interface{}
+}
+
+// Description:
+//
+//	Appends values to array fields, unless the arrays already contain them.
+//	Missing fields are created as arrays containing the given value.
+type UpdateOperatorAddToSet struct {
+
+	// The query interface implementation.
+	IQuery
+
+	// The key-value mappings to add.
+	AddToSet map[string]interface{}
+}
+
+// Description:
+//
+//	Updates fields to the given values, if the given values are less than the current values.
+type UpdateOperatorMin struct {
+
+	// The query interface implementation.
+	IQuery
+
+	// The key-value mappings to compare with.
+	Min map[string]interface{}
+}
+
+// Description:
+//
+//	Updates fields to the given values, if the given values are greater than the current values.
+type UpdateOperatorMax struct {
+
+	// The query interface implementation.
+	IQuery
+
+	// The key-value mappings to compare with.
+	Max map[string]interface{}
+}
+
+// Description:
+//
+//	Multiplies numeric fields by the given factors.
+//	Missing fields are created with the value zero.
+type UpdateOperatorMul struct {
+
+	// The query interface implementation.
+	IQuery
+
+	// The key-factor mappings to multiply by.
+	Mul map[string]interface{}
+}
+
+// Description:
+//
+//	Renames fields.
+type UpdateOperatorRename struct {
+
+	// The query interface implementation.
+	IQuery
+
+	// The mappings of current keys to new keys.
+	Rename map[string]string
+}
+
+// Description:
+//
+//	Sets fields to the current date.
+type UpdateOperatorCurrentDate struct {
+
+	// The query interface implementation.
+	IQuery
+
+	// The keys to set to the current date.
+	CurrentDate []string
+}
+
+// Description:
+//
+//	Combines multiple update operators into a single update document.
+//	No field may be targeted by more than one of the combined operators.
+type UpdateOperatorCombine struct {
+
+	// The query interface implementation.
+	IQuery
+
+	// The update operators to combine.
+	Combine []IQuery
+}
+
+// Description:
+//
+//	Validates the update.
+//	Checks that no field is targeted more than once, neither directly nor through a parent field.
+//
+// Returns:
+//
+//	An error if the update is invalid.
+func (update *Update) Validate() error {
+	if update.Root == nil {
+		return nil
+	}
+
+	paths := make([]string, 0)
+	for _, operator := range flattenUpdate(update.Root) {
+		paths = append(paths, updatePaths(operator.Compile())...)
+	}
+
+	sort.Strings(paths)
+
+	for index := 1; index < len(paths); index++ {
+		previous := paths[index-1]
+		current := paths[index]
+
+		if previous == current || strings.HasPrefix(current, previous+".") {
+			return fmt.Errorf("query: conflicting update paths '%s' and '%s'", previous, current)
+		}
+	}
+
+	return nil
+}
+
 // Description:
 //
 //	Compiles the update operator and potential sub operators into a MongoDB BSON document.
@@ -33,3 +208,234 @@ type UpdateOperatorSet struct {
 func (update UpdateOperatorSet) Compile() bson.M {
 	return bson.M{"$set": update.Set}
 }
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorInc) Compile() bson.M {
+	return bson.M{"$inc": update.Inc}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorUnset) Compile() bson.M {
+	fields := bson.M{}
+
+	for _, key := range update.Unset {
+		fields[key] = ""
+	}
+
+	return bson.M{"$unset": fields}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorPush) Compile() bson.M {
+	return bson.M{"$push": update.Push}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorPull) Compile() bson.M {
+	return bson.M{"$pull": update.Pull}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorAddToSet) Compile() bson.M {
+	return bson.M{"$addToSet": update.AddToSet}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorMin) Compile() bson.M {
+	return bson.M{"$min": update.Min}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorMax) Compile() bson.M {
+	return bson.M{"$max": update.Max}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorMul) Compile() bson.M {
+	return bson.M{"$mul": update.Mul}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorRename) Compile() bson.M {
+	fields := bson.M{}
+
+	for key, newKey := range update.Rename {
+		fields[key] = newKey
+	}
+
+	return bson.M{"$rename": fields}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorCurrentDate) Compile() bson.M {
+	fields := bson.M{}
+
+	for _, key := range update.CurrentDate {
+		fields[key] = true
+	}
+
+	return bson.M{"$currentDate": fields}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//	Operators of the same kind are merged into a single field mapping.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorCombine) Compile() bson.M {
+	result := bson.M{}
+
+	for _, operator := range flattenUpdate(update) {
+		for name, value := range operator.Compile() {
+			fields, ok := result[name].(bson.M)
+			if !ok {
+				fields = bson.M{}
+				result[name] = fields
+			}
+
+			for key, fieldValue := range updateFields(value) {
+				fields[key] = fieldValue
+			}
+		}
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Flattens nested update operator combinations.
+//
+// Parameters:
+//
+//	operator The update operator to flatten.
+//
+// Returns:
+//
+//	All update operators which are not combinations.
+func flattenUpdate(operator IQuery) []IQuery {
+	combine, ok := operator.(UpdateOperatorCombine)
+	if !ok {
+		return []IQuery{operator}
+	}
+
+	result := make([]IQuery, 0)
+	for _, sub := range combine.Combine {
+		result = append(result, flattenUpdate(sub)...)
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Collects all fields targeted by a compiled update document.
+//	Renamed fields target both the current and the new key.
+//
+// Parameters:
+//
+//	update The compiled update document.
+//
+// Returns:
+//
+//	All targeted fields.
+func updatePaths(update bson.M) []string {
+	paths := make([]string, 0)
+
+	for name, value := range update {
+		for key, fieldValue := range updateFields(value) {
+			paths = append(paths, key)
+
+			if newKey, ok := fieldValue.(string); ok && name == "$rename" {
+				paths = append(paths, newKey)
+			}
+		}
+	}
+
+	return paths
+}
+
+// Description:
+//
+//	Converts the value of a compiled update operator into its field mappings.
+//
+// Parameters:
+//
+//	value The compiled update operator value.
+//
+// Returns:
+//
+//	The field mappings. Empty, if the value is not a mapping.
+func updateFields(value interface{}) map[string]interface{} {
+	switch fields := value.(type) {
+	case map[string]interface{}:
+		return fields
+	case bson.M:
+		return fields
+	}
+
+	return map[string]interface{}{}
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+
+	"github.com/gostream-official/artists/pkg/store/query"
+)
+
+// Description:
+//
+//	The store interface.
+//	A storage-agnostic abstraction over a collection of documents.
+//
+// Type Parameters:
+//
+//	T The type of document stored in the store.
+type Store[T interface{}] interface {
+
+	// Description:
+	//
+	//	Creates a new item.
+	//
+	// Parameters:
+	//
+	//	ctx 	The operation context. Cancels the operation when done.
+	//	item 	The item to create.
+	//
+	// Returns:
+	//
+	//	ErrDuplicateKey if the item violates a unique index, another error if creation fails.
+	CreateItem(ctx context.Context, item interface{}) error
+
+	// Description:
+	//
+	//	Queries items in the store.
+	//
+	// Parameters:
+	//
+	//	ctx 	The operation context. Cancels the operation when done.
+	//	filter 	The query filter to use.
+	//
+	// Returns:
+	//
+	//	An array of all items matching the given query filter.
+	//	An error if the query fails.
+	FindItems(ctx context.Context, filter *query.Filter) ([]T, error)
+
+	// Description:
+	//
+	//	Updates a single item.
+	//
+	// Parameters:
+	//
+	//	ctx 	The operation context. Cancels the operation when done.
+	//	filter 	The filter used for searching the documents to update.
+	//	update 	The update operator used for updating the filtered documents.
+	//
+	// Returns:
+	//
+	//	The number of modified documents.
+	//	An error if the update fails.
+	UpdateItem(ctx context.Context, filter *query.Filter, update *query.Update) (int64, error)
+
+	// Description:
+	//
+	//	Updates a single item, if it has the expected version (compare-and-set).
+	//	Increments the version of the updated item.
+	//
+	// Parameters:
+	//
+	//	ctx 	The operation context. Cancels the operation when done.
+	//	filter 	The filter used for searching the document to update.
+	//	version The expected version of the document.
+	//	update 	The update operator used for updating the filtered document.
+	//
+	// Returns:
+	//
+	//	ErrNotFound if no document matches the filter,
+	//	ErrVersionMismatch if the document has another version,
+	//	another error if the update fails.
+	UpdateItemVersion(ctx context.Context, filter *query.Filter, version int64, update *query.Update) error
+
+	// Description:
+	//
+	//	Deletes an item by its ID.
+	//
+	// Parameters:
+	//
+	//	ctx The operation context. Cancels the operation when done.
+	//	id 	The ID of the document to delete.
+	//
+	// Returns:
+	//
+	//	The number of deleted documents.
+	//	An error if the request fails.
+	DeleteItem(ctx context.Context, id string) (int64, error)
+
+	// Description:
+	//
+	//	Deletes an item by its ID, if it has the expected version (compare-and-set).
+	//
+	// Parameters:
+	//
+	//	ctx 	The operation context. Cancels the operation when done.
+	//	id 		The ID of the document to delete.
+	//	version The expected version of the document.
+	//
+	// Returns:
+	//
+	//	ErrNotFound if the document does not exist,
+	//	ErrVersionMismatch if the document has another version,
+	//	another error if the request fails.
+	DeleteItemVersion(ctx context.Context, id string, version int64) error
+
+	// Description:
+	//
+	//	Creates an index.
+	//	Creating an index which already exists with the same definition has no effect.
+	//
+	// Parameters:
+	//
+	//	ctx 	The operation context. Cancels the operation when done.
+	//	index 	The index to create.
+	//
+	// Returns:
+	//
+	//	ErrDuplicateKey if the documents violate the unique index, another error if creation fails.
+	CreateIndex(ctx context.Context, index Index) error
+
+	// Description:
+	//
+	//	Drops an index by its name.
+	//	Dropping an index which does not exist has no effect.
+	//
+	// Parameters:
+	//
+	//	ctx 	The operation context. Cancels the operation when done.
+	//	name 	The name of the index to drop.
+	//
+	// Returns:
+	//
+	//	An error if the index cannot be dropped.
+	DropIndex(ctx context.Context, name string) error
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"context"
+)
+
+// Description:
+//
+//	The transactor interface.
+//	Runs store operations atomically, across all stores of the same instance.
+type Transactor interface {
+
+	// Description:
+	//
+	//	Runs a function within a transaction.
+	//	Store operations take part in the transaction, if they are called with the context passed to the function.
+	//	The transaction is committed if the function succeeds and rolled back if it fails.
+	//	The function may be called more than once, if the transaction is retried. Transactions must not be nested.
+	//
+	// Parameters:
+	//
+	//	ctx The operation context.
+	//	fn 	The function to run.
+	//
+	// Returns:
+	//
+	//	The error returned by the function, or an error if the transaction fails.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
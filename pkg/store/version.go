@@ -0,0 +1,93 @@
+package store
+
+import (
+	"errors"
+
+	"github.com/gostream-official/artists/pkg/store/query"
+)
+
+// Description:
+//
+//	The document key of the item version.
+//	The version is incremented by every versioned update. Documents without version have version zero.
+const VersionKey = "version"
+
+// Description:
+//
+//	The error returned when a versioned operation does not find the item.
+var ErrNotFound = errors.New("store: item not found")
+
+// Description:
+//
+//	The error returned when a versioned operation finds the item in another version.
+var ErrVersionMismatch = errors.New("store: version mismatch")
+
+// Description:
+//
+//	Restricts a filter to documents of the given version.
+//
+// Parameters:
+//
+//	filter 	The filter to restrict. May be nil.
+//	version The expected version.
+//
+// Returns:
+//
+//	The restricted filter.
+func versionFilter(filter query.IQuery, version int64) query.IQuery {
+	var condition query.IQuery = query.FilterOperatorEq{
+		Key:   VersionKey,
+		Value: version,
+	}
+
+	if version == 0 {
+		condition = query.FilterOperatorOr{
+			Or: []query.IQuery{
+				condition,
+				query.FilterOperatorExists{
+					Key:    VersionKey,
+					Exists: false,
+				},
+			},
+		}
+	}
+
+	if filter == nil {
+		return condition
+	}
+
+	return query.FilterOperatorAnd{
+		And: []query.IQuery{filter, condition},
+	}
+}
+
+// Description:
+//
+//	Extends an update by incrementing the version.
+//
+// Parameters:
+//
+//	update The update to extend.
+//
+// Returns:
+//
+//	The extended update.
+func versionUpdate(update *query.Update) *query.Update {
+	increment := query.UpdateOperatorInc{
+		Inc: map[string]interface{}{
+			VersionKey: int64(1),
+		},
+	}
+
+	if update.Root == nil {
+		return &query.Update{
+			Root: increment,
+		}
+	}
+
+	return &query.Update{
+		Root: query.UpdateOperatorCombine{
+			Combine: []query.IQuery{update.Root, increment},
+		},
+	}
+}